@@ -0,0 +1,420 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts"
+	"github.com/jeandeaual/tts-deckconverter/tts/upload"
+)
+
+// chestBrowser lists the saved objects of the chest as a tree of folders.
+type chestBrowser struct {
+	win  fyne.Window
+	root string
+	// entries are the saved objects of the chest, indexed by path.
+	entries map[string]tts.ChestEntry
+	// children are the folders and saved objects displayed in each folder,
+	// indexed by the path of the folder.
+	children map[string][]string
+	query    string
+	selected string
+
+	tree      *widget.Tree
+	thumbnail *canvas.Image
+	nameLabel *widget.Label
+	infoLabel *widget.Label
+	actions   *fyne.Container
+}
+
+func newChestBrowser(win fyne.Window, root string) *chestBrowser {
+	b := &chestBrowser{
+		win:      win,
+		root:     root,
+		entries:  make(map[string]tts.ChestEntry),
+		children: make(map[string][]string),
+	}
+
+	b.tree = widget.NewTree(
+		func(uid widget.TreeNodeID) []widget.TreeNodeID {
+			return b.children[b.folder(uid)]
+		},
+		func(uid widget.TreeNodeID) bool {
+			_, isEntry := b.entries[uid]
+			return !isEntry
+		},
+		func(branch bool) fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(uid widget.TreeNodeID, branch bool, node fyne.CanvasObject) {
+			name := filepath.Base(uid)
+			if entry, isEntry := b.entries[uid]; isEntry {
+				name = entry.Name
+			}
+			node.(*widget.Label).SetText(name)
+		},
+	)
+	b.tree.OnSelected = b.selectNode
+
+	b.thumbnail = &canvas.Image{FillMode: canvas.ImageFillContain}
+	b.thumbnail.SetMinSize(fyne.NewSize(128, 128))
+	b.nameLabel = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	b.infoLabel = widget.NewLabel("")
+	b.infoLabel.Wrapping = fyne.TextWrapWord
+
+	return b
+}
+
+// folder returns the path of the folder corresponding to a tree node.
+func (b *chestBrowser) folder(uid widget.TreeNodeID) string {
+	if len(uid) == 0 {
+		return b.root
+	}
+	return uid
+}
+
+// load reads the saved objects of the chest again.
+func (b *chestBrowser) load() {
+	entries, err := tts.ListChest(b.root)
+	if err != nil {
+		showErrorf(b.win, "Couldn't read the chest: %w", err)
+		return
+	}
+
+	b.entries = make(map[string]tts.ChestEntry, len(entries))
+	for _, entry := range entries {
+		b.entries[entry.Path] = entry
+	}
+
+	b.filter(b.query)
+}
+
+// filter only displays the saved objects matching query, and the folders
+// containing them.
+func (b *chestBrowser) filter(query string) {
+	b.query = query
+	b.children = make(map[string][]string)
+	added := make(map[string]bool)
+
+	for path, entry := range b.entries {
+		if !entry.Matches(query) {
+			continue
+		}
+
+		// Add the saved object and its parent folders
+		for child := path; child != b.root && !added[child]; child = filepath.Dir(child) {
+			parent := filepath.Dir(child)
+			b.children[parent] = append(b.children[parent], child)
+			added[child] = true
+			if parent == child {
+				// Outside of the chest
+				break
+			}
+		}
+	}
+
+	for _, children := range b.children {
+		sort.Slice(children, func(i, j int) bool {
+			// Folders first
+			_, iIsEntry := b.entries[children[i]]
+			_, jIsEntry := b.entries[children[j]]
+			if iIsEntry != jIsEntry {
+				return jIsEntry
+			}
+			return strings.ToLower(children[i]) < strings.ToLower(children[j])
+		})
+	}
+
+	b.tree.Refresh()
+	if len(query) > 0 {
+		b.tree.OpenAllBranches()
+	}
+
+	if _, found := b.entries[b.selected]; !found || !added[b.selected] {
+		b.tree.UnselectAll()
+		b.showEntry(nil)
+	}
+}
+
+func (b *chestBrowser) selectNode(uid widget.TreeNodeID) {
+	entry, isEntry := b.entries[uid]
+	if !isEntry {
+		b.selected = ""
+		b.showEntry(nil)
+		return
+	}
+
+	b.selected = uid
+	b.showEntry(&entry)
+}
+
+// showEntry displays the details of a saved object.
+func (b *chestBrowser) showEntry(entry *tts.ChestEntry) {
+	if entry == nil {
+		b.selected = ""
+		b.thumbnail.File = ""
+		b.thumbnail.Refresh()
+		b.nameLabel.SetText("")
+		b.infoLabel.SetText("")
+		b.actions.Hide()
+		return
+	}
+
+	b.thumbnail.File = entry.Thumbnail
+	b.thumbnail.Refresh()
+	b.nameLabel.SetText(entry.Name)
+
+	info := entry.Path + "\n\n" + strconv.Itoa(len(entry.CardNames)) + " different cards"
+	if len(entry.DeckNames) > 1 {
+		info += " in " + strconv.Itoa(len(entry.DeckNames)) + " decks"
+	}
+	b.infoLabel.SetText(info)
+	b.actions.Show()
+}
+
+func (b *chestBrowser) rename() {
+	path := b.selected
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(tts.SavedObjectName(path))
+
+	dialog.ShowForm("Rename", "Rename", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		newPath, err := tts.RenameSavedObject(path, nameEntry.Text)
+		if err != nil {
+			showErrorf(b.win, "Couldn't rename %s: %w", path, err)
+		}
+		b.reloadAndSelect(newPath)
+	}, b.win)
+}
+
+func (b *chestBrowser) move() {
+	path := b.selected
+	folderEntry := widget.NewEntry()
+	if rel, err := filepath.Rel(b.root, filepath.Dir(path)); err == nil && rel != "." {
+		folderEntry.SetText(rel)
+	}
+	folderEntry.SetPlaceHolder("Root of the chest")
+
+	dialog.ShowForm("Move", "Move", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Folder", folderEntry),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		folder := filepath.Join(b.root, folderEntry.Text)
+		if rel, err := filepath.Rel(b.root, folder); err != nil || strings.HasPrefix(rel, "..") {
+			showErrorf(b.win, "The folder must be inside the chest")
+			return
+		}
+		if plugins.CheckInvalidFolderName(folder) {
+			showErrorf(b.win, "Invalid folder name: %s", folderEntry.Text)
+			return
+		}
+		newPath, err := tts.MoveSavedObject(path, folder)
+		if err != nil {
+			showErrorf(b.win, "Couldn't move %s: %w", path, err)
+		}
+		b.reloadAndSelect(newPath)
+	}, b.win)
+}
+
+func (b *chestBrowser) delete() {
+	path := b.selected
+
+	dialog.ShowConfirm(
+		"Delete",
+		fmt.Sprintf("This will delete the following saved object and its thumbnail:\n%s\n\nContinue?", path),
+		func(ok bool) {
+			if !ok {
+				log.Debug("Deletion cancelled by user")
+				return
+			}
+			if err := tts.DeleteSavedObject(path); err != nil {
+				showErrorf(b.win, "Couldn't delete %s: %w", path, err)
+			}
+			b.reloadAndSelect("")
+		},
+		b.win,
+	)
+}
+
+// push spawns the selected saved object in the running game.
+func (b *chestBrowser) push() {
+	path := b.selected
+
+	object, err := tts.ReadSavedObjectFile(path)
+	if err != nil {
+		showErrorf(b.win, "Couldn't read %s: %w", path, err)
+		return
+	}
+
+	if err := tts.PushSavedObject(object, tts.DefaultEditorAddress); err != nil {
+		showErrorf(b.win, "Couldn't push %s to the game: %w", path, err)
+	}
+}
+
+// regenerate retrieves the cards of the selected saved object again, with
+// the settings of a plugin, and overwrites it.
+func (b *chestBrowser) regenerate(settings pluginSettings, uploaderName string, compact bool) {
+	path := b.selected
+	mode := settings.plugin.PluginID()
+	options := settings.options()
+	backURL := settings.backURL()
+	uploader := findUploader(uploaderName)
+
+	log.Infof("Regenerating %s with mode %s and options %v", path, mode, options)
+
+	progress := newProgressBar("Regenerating…", b.win)
+
+	go func() {
+		defer b.reloadAndSelect(path)
+
+		deck, err := dc.Reload(path, mode, options)
+		if err != nil {
+			progress.Hide()
+			showErrorf(b.win, "Couldn't read the deck: %w", err)
+			return
+		}
+
+		if uploader != nil {
+			errs := tts.GenerateTemplates([][]*plugins.Deck{{deck}}, filepath.Dir(path), *uploader)
+			for _, err := range errs {
+				if !errors.Is(err, upload.ErrUploadSize) {
+					progress.Hide()
+					showErrorf(b.win, "Couldn't generate the template: %w", err)
+					return
+				}
+			}
+		}
+
+		err = tts.Refresh(path, deck, backURL, !compact)
+		progress.Hide()
+		if err != nil {
+			showErrorf(b.win, "Couldn't regenerate the deck: %w", err)
+			return
+		}
+
+		dialog.ShowInformation("Success", "Regenerated "+path, b.win)
+	}()
+
+	progress.Show()
+}
+
+// reloadAndSelect reads the chest again, then selects the saved object
+// found at path (if any).
+func (b *chestBrowser) reloadAndSelect(path string) {
+	b.load()
+
+	if _, found := b.entries[path]; !found {
+		b.tree.UnselectAll()
+		b.showEntry(nil)
+		return
+	}
+
+	// Open the parent folders of the saved object
+	for folder := filepath.Dir(path); folder != b.root && folder != filepath.Dir(folder); folder = filepath.Dir(folder) {
+		b.tree.OpenBranch(folder)
+	}
+	b.tree.Select(path)
+}
+
+func chestScreen(
+	win fyne.Window,
+	chestPath string,
+	uploaderSelect *widget.Select,
+	compactCheck *widget.Check,
+	settings map[string]pluginSettings,
+) fyne.CanvasObject {
+	b := newChestBrowser(win, chestPath)
+
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("Search by deck or card name")
+	searchEntry.OnChanged = b.filter
+
+	// Only the plugins able to read back their cards can regenerate decks
+	modes := make(map[string]pluginSettings)
+	modeNames := make([]string, 0, len(settings))
+	for _, pluginSettings := range settings {
+		if _, ok := pluginSettings.plugin.(plugins.Exporter); ok {
+			modes[pluginSettings.plugin.PluginName()] = pluginSettings
+			modeNames = append(modeNames, pluginSettings.plugin.PluginName())
+		}
+	}
+	sort.Strings(modeNames)
+	modeSelect := widget.NewSelect(modeNames, nil)
+	modeSelect.PlaceHolder = "Game"
+
+	b.actions = container.NewVBox(
+		container.NewHBox(
+			widget.NewButtonWithIcon("Rename", theme.DocumentCreateIcon(), b.rename),
+			widget.NewButtonWithIcon("Move", theme.FolderOpenIcon(), b.move),
+			widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), b.delete),
+			widget.NewButtonWithIcon("Push to game", theme.MailSendIcon(), b.push),
+		),
+		widget.NewLabel("Regenerate with the current settings of:"),
+		container.NewHBox(
+			modeSelect,
+			widget.NewButtonWithIcon("Regenerate", theme.ViewRefreshIcon(), func() {
+				pluginSettings, found := modes[modeSelect.Selected]
+				if !found {
+					showErrorf(win, "Select the game of the deck first")
+					return
+				}
+				b.regenerate(pluginSettings, uploaderSelect.Selected, compactCheck.Checked)
+			}),
+		),
+	)
+	b.actions.Hide()
+
+	details := container.NewVBox(
+		b.thumbnail,
+		b.nameLabel,
+		b.infoLabel,
+		b.actions,
+	)
+
+	reloadButton := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() {
+		b.reloadAndSelect(b.selected)
+	})
+
+	b.load()
+
+	split := container.NewHSplit(b.tree, container.NewVScroll(details))
+	split.Offset = 0.5
+
+	toolbar := container.New(
+		layout.NewBorderLayout(nil, nil, nil, reloadButton),
+		searchEntry,
+		reloadButton,
+	)
+
+	return container.New(
+		layout.NewBorderLayout(
+			toolbar,
+			nil,
+			nil,
+			nil,
+		),
+		toolbar,
+		split,
+	)
+}
@@ -66,6 +66,25 @@ func newProgressBar(title string, win fyne.Window) dialog.Dialog {
 	return progress
 }
 
+// showGenerated displays the files generated for the decks, and offers to
+// spawn the decks in the running game.
+func showGenerated(result string, decks []*plugins.Deck, win fyne.Window) {
+	confirm := dialog.NewConfirm("Success", result, func(push bool) {
+		if !push {
+			return
+		}
+		go func() {
+			// The back URL has already been set on the decks by tts.Generate
+			if err := tts.Push(decks, "", tts.DefaultEditorAddress); err != nil {
+				showErrorf(win, "Couldn't push the deck(s) to the game: %w", err)
+			}
+		}()
+	}, win)
+	confirm.SetConfirmText("Push to game")
+	confirm.SetDismissText("Close")
+	confirm.Show()
+}
+
 func handleTarget(
 	target string,
 	mode string,
@@ -137,7 +156,7 @@ func handleTarget(
 
 		progress.Hide()
 
-		dialog.ShowInformation("Success", result, win)
+		showGenerated(result, decks, win)
 	}()
 
 	progress.Show()
@@ -215,7 +234,7 @@ func handleText(
 
 		progress.Hide()
 
-		dialog.ShowInformation("Success", result, win)
+		showGenerated(result, decks, win)
 	}()
 
 	progress.Show()
@@ -274,11 +293,24 @@ func convertOptions(optionWidgets map[string]interface{}) map[string]string {
 	options := make(map[string]string)
 
 	for name, optionWidget := range optionWidgets {
+		// The options whose dependencies aren't enabled are left out
+		if disableable, ok := optionWidget.(fyne.Disableable); ok && disableable.Disabled() {
+			continue
+		}
+
 		switch w := optionWidget.(type) {
 		case *widget.Entry:
-			options[name] = w.Text
+			// Empty entries use the default value of the option
+			if len(w.Text) > 0 {
+				options[name] = w.Text
+			}
 		case *widget.RadioGroup:
 			options[name] = w.Selected
+		case *widget.CheckGroup:
+			// Empty selections use the default value of the option
+			if len(w.Selected) > 0 {
+				options[name] = strings.Join(w.Selected, ",")
+			}
 		case *widget.Check:
 			options[name] = strconv.FormatBool(w.Checked)
 		default:
@@ -289,6 +321,42 @@ func convertOptions(optionWidgets map[string]interface{}) map[string]string {
 	return options
 }
 
+// linkOptionDependencies disables the option widgets while the bool
+// options they require aren't checked.
+func linkOptionDependencies(options plugins.Options, optionWidgets map[string]interface{}) {
+	update := func() {
+		for name, option := range options {
+			dependent, ok := optionWidgets[name].(fyne.Disableable)
+			if !ok || len(option.Requires) == 0 {
+				continue
+			}
+
+			enabled := true
+			for _, dependency := range option.Requires {
+				if check, ok := optionWidgets[dependency].(*widget.Check); ok && !check.Checked {
+					enabled = false
+				}
+			}
+
+			if enabled {
+				dependent.Enable()
+			} else {
+				dependent.Disable()
+			}
+		}
+	}
+
+	for _, option := range options {
+		for _, dependency := range option.Requires {
+			if check, ok := optionWidgets[dependency].(*widget.Check); ok {
+				check.OnChanged = func(bool) { update() }
+			}
+		}
+	}
+
+	update()
+}
+
 func selectedBackURL(backSelect *widget.Select, customBack *widget.Entry, plugin plugins.Plugin) string {
 	if backSelect.Selected == customBackLabel {
 		return customBack.Text
@@ -301,6 +369,34 @@ func selectedBackURL(backSelect *widget.Select, customBack *widget.Entry, plugin
 	return ""
 }
 
+// findUploader returns the template uploader with the given name, or nil if
+// there is none (i.e. no template should be generated).
+func findUploader(name string) *upload.TemplateUploader {
+	for _, uploader := range upload.TemplateUploaders {
+		if (*uploader).UploaderName() == name {
+			return uploader
+		}
+	}
+	return nil
+}
+
+// pluginSettings gives access to the settings selected on the screen of a
+// plugin.
+type pluginSettings struct {
+	plugin        plugins.Plugin
+	backSelect    *widget.Select
+	customBack    *widget.Entry
+	optionWidgets map[string]interface{}
+}
+
+func (s pluginSettings) backURL() string {
+	return selectedBackURL(s.backSelect, s.customBack, s.plugin)
+}
+
+func (s pluginSettings) options() map[string]string {
+	return convertOptions(s.optionWidgets)
+}
+
 func createURLTab(
 	win fyne.Window,
 	folderEntry *widget.Entry,
@@ -337,12 +433,7 @@ func createURLTab(
 					return
 				}
 
-				var selectedUploader *upload.TemplateUploader
-				for _, uploader := range upload.TemplateUploaders {
-					if (*uploader).UploaderName() == uploaderSelect.Selected {
-						selectedUploader = uploader
-					}
-				}
+				selectedUploader := findUploader(uploaderSelect.Selected)
 
 				target := urlEntry.Text
 				mode := plugin.PluginID()
@@ -444,12 +535,7 @@ func createTextTab(
 					return
 				}
 
-				var selectedUploader *upload.TemplateUploader
-				for _, uploader := range upload.TemplateUploaders {
-					if (*uploader).UploaderName() == uploaderSelect.Selected {
-						selectedUploader = uploader
-					}
-				}
+				selectedUploader := findUploader(uploaderSelect.Selected)
 
 				text := textInput.Text
 				deckName := deckNameInput.Text
@@ -557,12 +643,7 @@ func createFileTab(
 					return
 				}
 
-				var selectedUploader *upload.TemplateUploader
-				for _, uploader := range upload.TemplateUploaders {
-					if (*uploader).UploaderName() == uploaderSelect.Selected {
-						selectedUploader = uploader
-					}
-				}
+				selectedUploader := findUploader(uploaderSelect.Selected)
 
 				target := fileEntry.Text
 				mode := plugin.PluginID()
@@ -588,7 +669,13 @@ func createFileTab(
 	))
 }
 
-func pluginScreen(win fyne.Window, folderEntry *widget.Entry, uploaderSelect *widget.Select, compactCheck *widget.Check, plugin plugins.Plugin) fyne.CanvasObject {
+func pluginScreen(
+	win fyne.Window,
+	folderEntry *widget.Entry,
+	uploaderSelect *widget.Select,
+	compactCheck *widget.Check,
+	plugin plugins.Plugin,
+) (fyne.CanvasObject, pluginSettings) {
 	options := plugin.AvailableOptions()
 	optionsVBox := container.NewVBox()
 
@@ -668,17 +755,96 @@ func pluginScreen(win fyne.Window, folderEntry *widget.Entry, uploaderSelect *wi
 			optionWidgets[name] = radio
 
 			widgetsVBox.Add(radio)
-		case plugins.OptionTypeInt:
+		case plugins.OptionTypeInt, plugins.OptionTypeFloat, plugins.OptionTypeString:
 			widgetsVBox.Add(widget.NewLabel(plugins.CapitalizeString(option.Description)))
 
 			entry := widget.NewEntry()
-			entry.SetPlaceHolder(plugins.CapitalizeString(option.DefaultValue.(string)))
+			if option.DefaultValue != nil {
+				entry.SetPlaceHolder(plugins.CapitalizeString(plugins.FormatOptionValue(option.DefaultValue)))
+			}
 			optionWidgets[name] = entry
 
 			widgetsVBox.Add(entry)
+		case plugins.OptionTypeList:
+			widgetsVBox.Add(widget.NewLabel(plugins.CapitalizeString(option.Description)))
+
+			if len(option.AllowedValues) > 0 {
+				checkGroup := widget.NewCheckGroup(option.AllowedValues, nil)
+				if defaultValues, ok := option.DefaultValue.([]string); ok {
+					checkGroup.SetSelected(defaultValues)
+				}
+				optionWidgets[name] = checkGroup
+
+				widgetsVBox.Add(checkGroup)
+				continue
+			}
+
+			entry := widget.NewEntry()
+			entry.SetPlaceHolder("Comma-separated values")
+			if option.DefaultValue != nil {
+				entry.SetPlaceHolder(plugins.FormatOptionValue(option.DefaultValue))
+			}
+			optionWidgets[name] = entry
+
+			widgetsVBox.Add(entry)
+		case plugins.OptionTypePath:
+			widgetsVBox.Add(widget.NewLabel(plugins.CapitalizeString(option.Description)))
+
+			entry := widget.NewEntry()
+			if option.DefaultValue != nil {
+				entry.SetPlaceHolder(plugins.FormatOptionValue(option.DefaultValue))
+			}
+			optionWidgets[name] = entry
+
+			fileButton := widget.NewButtonWithIcon("File…", theme.FolderOpenIcon(), func() {
+				dialog.ShowFileOpen(
+					func(file fyne.URIReadCloser, err error) {
+						if err != nil {
+							showErrorf(win, "Error when trying to select file: %v", err)
+							return
+						}
+						if file == nil {
+							// Cancelled
+							return
+						}
+						// Only the path is needed
+						if cerr := file.Close(); cerr != nil {
+							log.Errorf("Error when trying to close file %s: %v", file.URI().String(), cerr)
+						}
+						entry.SetText(file.URI().Path())
+					},
+					win,
+				)
+			})
+
+			folderButton := widget.NewButtonWithIcon("Folder…", theme.FolderOpenIcon(), func() {
+				dialog.ShowFolderOpen(
+					func(folder fyne.ListableURI, err error) {
+						if err != nil {
+							showErrorf(win, "Error when trying to select folder: %v", err)
+							return
+						}
+						if folder == nil {
+							// Cancelled
+							return
+						}
+						entry.SetText(folder.Path())
+					},
+					win,
+				)
+			})
+			buttons := container.NewHBox(fileButton, folderButton)
+
+			widgetsVBox.Add(container.New(
+				layout.NewBorderLayout(nil, nil, nil, buttons),
+				entry,
+				buttons,
+			))
 		case plugins.OptionTypeBool:
 			check := widget.NewCheck(plugins.CapitalizeString(option.Description), nil)
-			check.SetChecked(option.DefaultValue.(bool))
+			if checked, ok := option.DefaultValue.(bool); ok {
+				check.SetChecked(checked)
+			}
 			optionWidgets[name] = check
 
 			widgetsVBox.Add(check)
@@ -688,6 +854,8 @@ func pluginScreen(win fyne.Window, folderEntry *widget.Entry, uploaderSelect *wi
 		}
 	}
 
+	linkOptionDependencies(options, optionWidgets)
+
 	widgetsContainer := container.NewVScroll(widgetsVBox)
 	widgetsContainer.SetMinSize(fyne.NewSize(0, 120))
 	optionsVBox.Add(widgetsContainer)
@@ -733,6 +901,13 @@ func pluginScreen(win fyne.Window, folderEntry *widget.Entry, uploaderSelect *wi
 
 	backSelect.SetSelected(lastSelected)
 
+	settings := pluginSettings{
+		plugin:        plugin,
+		backSelect:    backSelect,
+		customBack:    customBack,
+		optionWidgets: optionWidgets,
+	}
+
 	return container.New(
 		layout.NewBorderLayout(
 			optionsVBox,
@@ -742,7 +917,7 @@ func pluginScreen(win fyne.Window, folderEntry *widget.Entry, uploaderSelect *wi
 		),
 		optionsVBox,
 		tabContainer,
-	)
+	), settings
 }
 
 func main() {
@@ -786,8 +961,23 @@ func main() {
 	application := app.NewWithID(appID)
 
 	win := application.NewWindow(appName)
+
+	conf, configPath := loadConfig(win)
+	if len(conf.DataDir) > 0 {
+		tts.SetDataDir(conf.DataDir)
+	}
+	// Set once all the screens have been created
+	var currentSettings appSettings
+
 	win.SetMainMenu(fyne.NewMainMenu(
 		fyne.NewMenu("Menu",
+			fyne.NewMenuItem("Profiles", func() {
+				if len(configPath) == 0 {
+					showErrorf(win, "The configuration file couldn't be found or loaded, fix it and restart to manage the profiles")
+					return
+				}
+				showProfilesWindow(application, conf, configPath, currentSettings)
+			}),
 			fyne.NewMenuItem("Settings", func() {
 				settingsWindow := application.NewWindow("Fyne Settings")
 				settingsWindow.SetContent(settings.NewSettings().LoadAppearanceScreen(settingsWindow))
@@ -891,7 +1081,8 @@ func main() {
 
 	compactCheck := widget.NewCheck("Compact file", nil)
 
-	tabItems := make([]*container.TabItem, 0, len(availablePlugins))
+	tabItems := make([]*container.TabItem, 0, len(availablePlugins)+1)
+	settings := make(map[string]pluginSettings, len(availablePlugins))
 
 	for _, pluginName := range availablePlugins {
 		plugin, found := dc.Plugins[pluginName]
@@ -899,7 +1090,26 @@ func main() {
 			log.Fatalf("Invalid mode: %s", pluginName)
 		}
 
-		tabItems = append(tabItems, container.NewTabItem(plugin.PluginName(), pluginScreen(win, folderEntry, uploaderSelect, compactCheck, plugin)))
+		screen, pluginSettings := pluginScreen(win, folderEntry, uploaderSelect, compactCheck, plugin)
+		settings[pluginName] = pluginSettings
+		tabItems = append(tabItems, container.NewTabItem(plugin.PluginName(), screen))
+	}
+
+	currentSettings = appSettings{
+		folderEntry:    folderEntry,
+		chestPath:      chestPath,
+		uploaderSelect: uploaderSelect,
+		compactCheck:   compactCheck,
+		plugins:        settings,
+	}
+	currentSettings.apply(conf.Settings)
+
+	if len(chestPath) > 0 {
+		tabItems = append(tabItems, container.NewTabItemWithIcon(
+			"Chest",
+			theme.FolderIcon(),
+			chestScreen(win, chestPath, uploaderSelect, compactCheck, settings),
+		))
 	}
 
 	tabs := container.NewAppTabs(tabItems...)
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	cfg "github.com/jeandeaual/tts-deckconverter/config"
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts/upload"
+)
+
+const defaultProfileLabel = "Default settings"
+
+// current returns the settings selected on the screen of a plugin, to be
+// saved in the configuration file.
+func (s pluginSettings) current() cfg.PluginSettings {
+	var current cfg.PluginSettings
+
+	if s.backSelect.Selected == customBackLabel {
+		current.BackURL = s.customBack.Text
+	} else {
+		for key, back := range s.plugin.AvailableBacks() {
+			if plugins.CapitalizeString(back.Description) == s.backSelect.Selected {
+				current.Back = key
+			}
+		}
+	}
+
+	for name, value := range s.options() {
+		// Empty entries use the default value of the plugin
+		if len(value) == 0 {
+			continue
+		}
+		if current.Options == nil {
+			current.Options = make(map[string]string)
+		}
+		current.Options[name] = value
+	}
+
+	return current
+}
+
+// apply selects the settings read from the configuration file on the screen
+// of a plugin.
+func (s pluginSettings) apply(settings cfg.PluginSettings) {
+	if len(settings.BackURL) > 0 {
+		s.backSelect.SetSelected(customBackLabel)
+		s.customBack.SetText(settings.BackURL)
+	} else if back, found := s.plugin.AvailableBacks()[settings.Back]; found {
+		s.backSelect.SetSelected(plugins.CapitalizeString(back.Description))
+	}
+
+	options, err := s.plugin.AvailableOptions().ValidateNormalize(settings.Options)
+	if err != nil {
+		log.Errorf("Invalid options for %s: %v", s.plugin.PluginID(), err)
+		return
+	}
+
+	for name, value := range options {
+		switch w := s.optionWidgets[name].(type) {
+		case *widget.Entry:
+			// Leave the default values as placeholders
+			if _, set := settings.Options[name]; set {
+				w.SetText(plugins.FormatOptionValue(value))
+			} else {
+				w.SetText("")
+			}
+		case *widget.CheckGroup:
+			if selected, ok := value.([]string); ok {
+				w.SetSelected(selected)
+			}
+		case *widget.RadioGroup:
+			if selected, ok := value.(string); ok {
+				w.SetSelected(selected)
+			}
+		case *widget.Check:
+			if checked, ok := value.(bool); ok {
+				w.SetChecked(checked)
+			}
+		}
+	}
+}
+
+// appSettings gives access to the settings selected in the whole
+// application.
+type appSettings struct {
+	folderEntry    *widget.Entry
+	chestPath      string
+	uploaderSelect *widget.Select
+	compactCheck   *widget.Check
+	plugins        map[string]pluginSettings
+}
+
+// update returns settings, updated with the values selected in the
+// application. The settings which can't be changed from the GUI are kept.
+func (s appSettings) update(settings cfg.Settings) cfg.Settings {
+	settings.Output = s.folderEntry.Text
+	settings.Chest = ""
+	if len(s.chestPath) > 0 {
+		if rel, err := filepath.Rel(s.chestPath, s.folderEntry.Text); err == nil && !strings.HasPrefix(rel, "..") {
+			settings.Output = ""
+			settings.Chest = filepath.ToSlash(rel)
+			if settings.Chest == "." {
+				settings.Chest = "/"
+			}
+		}
+	}
+
+	settings.Template = ""
+	for key, uploader := range upload.TemplateUploaders {
+		if (*uploader).UploaderName() == s.uploaderSelect.Selected {
+			settings.Template = key
+		}
+	}
+
+	settings.Compact = cfg.Bool(s.compactCheck.Checked)
+
+	settings.Plugins = make(map[string]cfg.PluginSettings, len(s.plugins))
+	for pluginID, screen := range s.plugins {
+		settings.Plugins[pluginID] = screen.current()
+	}
+
+	return settings
+}
+
+// apply selects the settings read from the configuration file in the
+// application.
+func (s appSettings) apply(settings cfg.Settings) {
+	if len(settings.Output) > 0 {
+		s.folderEntry.SetText(settings.Output)
+	} else if len(settings.Chest) > 0 && len(s.chestPath) > 0 {
+		s.folderEntry.SetText(filepath.Join(s.chestPath, filepath.FromSlash(settings.Chest)))
+	}
+
+	if uploader, found := upload.TemplateUploaders[settings.Template]; found {
+		s.uploaderSelect.SetSelected((*uploader).UploaderName())
+	}
+
+	if settings.Compact != nil {
+		s.compactCheck.SetChecked(*settings.Compact)
+	}
+
+	for pluginID, selected := range settings.Plugins {
+		if screen, found := s.plugins[pluginID]; found {
+			screen.apply(selected)
+		}
+	}
+}
+
+// showProfilesWindow displays a window used to load the default settings
+// and the profiles of the configuration file, or to save the current
+// settings to them.
+func showProfilesWindow(application fyne.App, config *cfg.Config, configPath string, s appSettings) {
+	win := application.NewWindow("Profiles")
+
+	profileSelect := widget.NewSelect(nil, nil)
+	refreshProfiles := func(selected string) {
+		profileSelect.Options = append([]string{defaultProfileLabel}, config.ProfileNames()...)
+		profileSelect.SetSelected(selected)
+	}
+	refreshProfiles(defaultProfileLabel)
+
+	newProfileEntry := widget.NewEntry()
+	newProfileEntry.SetPlaceHolder("New profile name")
+
+	// profileName returns the name of the selected profile in the
+	// configuration file (empty for the default settings).
+	profileName := func() string {
+		if profileSelect.Selected == defaultProfileLabel {
+			return ""
+		}
+		return profileSelect.Selected
+	}
+
+	save := func(selected string) {
+		if err := config.Save(configPath); err != nil {
+			showErrorf(win, "Couldn't save the settings: %w", err)
+			// Discard the invalid changes
+			if loaded, lerr := cfg.Load(configPath); lerr == nil {
+				*config = *loaded
+			}
+			refreshProfiles(defaultProfileLabel)
+			return
+		}
+		refreshProfiles(selected)
+		log.Infof("Saved the settings to %s", configPath)
+	}
+
+	loadButton := widget.NewButtonWithIcon("Load", theme.DownloadIcon(), func() {
+		settings, err := config.Profile(profileName())
+		if err != nil {
+			showErrorf(win, "Couldn't load the profile: %w", err)
+			return
+		}
+		s.apply(settings)
+	})
+
+	saveButton := widget.NewButtonWithIcon("Save current settings", theme.DocumentSaveIcon(), func() {
+		name := profileName()
+		if len(name) == 0 {
+			config.Settings = s.update(config.Settings)
+			save(defaultProfileLabel)
+			return
+		}
+		config.Profiles[name] = s.update(config.Profiles[name])
+		save(name)
+	})
+
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		name := profileName()
+		if len(name) == 0 {
+			showErrorf(win, "The default settings can't be deleted")
+			return
+		}
+		delete(config.Profiles, name)
+		save(defaultProfileLabel)
+	})
+
+	createButton := widget.NewButtonWithIcon("Create", theme.ContentAddIcon(), func() {
+		name := strings.TrimSpace(newProfileEntry.Text)
+		if len(name) == 0 || name == defaultProfileLabel {
+			showErrorf(win, "Invalid profile name")
+			return
+		}
+		if config.Profiles == nil {
+			config.Profiles = make(map[string]cfg.Settings)
+		}
+		config.Profiles[name] = s.update(config.Profiles[name])
+		newProfileEntry.SetText("")
+		save(name)
+	})
+
+	win.SetContent(container.NewVBox(
+		widget.NewLabel("Configuration file: "+configPath),
+		widget.NewLabel("Profile:"),
+		profileSelect,
+		container.NewHBox(loadButton, saveButton, deleteButton),
+		widget.NewSeparator(),
+		widget.NewLabel("Save the current settings as a new profile:"),
+		newProfileEntry,
+		container.NewHBox(createButton),
+	))
+	win.Resize(fyne.NewSize(480, 0))
+	win.Show()
+}
+
+// loadConfig reads the configuration file, or returns an empty configuration
+// if it can't be read.
+// The path of the file is only returned if it has been loaded, so that an
+// invalid file is never overwritten by the empty configuration.
+func loadConfig(win fyne.Window) (*cfg.Config, string) {
+	configPath, err := cfg.DefaultPath()
+	if err != nil {
+		log.Errorf("Couldn't find the configuration folder: %v", err)
+		return &cfg.Config{}, ""
+	}
+
+	config, err := cfg.Load(configPath)
+	if err != nil {
+		dialog.ShowError(err, win)
+		return &cfg.Config{}, ""
+	}
+
+	return config, configPath
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	cfg "github.com/jeandeaual/tts-deckconverter/config"
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/tts"
+)
+
+// targetResult is the outcome of the processing of a target.
+type targetResult struct {
+	target string
+	// decks are the decks whose files were generated.
+	decks []tts.GeneratedDeck
+	errs  []error
+}
+
+// targetConfigs returns the configuration of each target to process: the
+// targets given as arguments and the ones listed in the manifest, where
+// folders are replaced by the files they contain.
+func targetConfigs(config appConfig) ([]appConfig, error) {
+	configs := []appConfig{}
+
+	for _, target := range config.targets {
+		expanded, err := expandTarget(config, target)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, expanded...)
+	}
+
+	if len(config.manifest) == 0 {
+		return configs, nil
+	}
+
+	manifest, err := cfg.LoadManifest(config.manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range manifest.Targets {
+		var (
+			entryConfig appConfig
+			expanded    []appConfig
+		)
+		entryConfig, err = manifestTargetConfig(config, entry)
+		if err != nil {
+			return nil, err
+		}
+		expanded, err = expandTarget(entryConfig, entry.Target)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, expanded...)
+	}
+
+	return configs, nil
+}
+
+// expandTarget returns the configuration of target, or of each of its files
+// if it is a folder.
+func expandTarget(config appConfig, target string) ([]appConfig, error) {
+	info, err := os.Stat(target)
+	if err != nil || !info.IsDir() {
+		return []appConfig{newTargetConfig(config, target)}, nil
+	}
+
+	if config.recursive {
+		return walkTree(config, target)
+	}
+
+	files, err := folderFiles(target)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make([]appConfig, 0, len(files))
+	for _, file := range files {
+		configs = append(configs, newTargetConfig(config, file))
+	}
+
+	return configs, nil
+}
+
+// newTargetConfig returns a copy of config used to process target, with the
+// settings of its plugin in the configuration file.
+func newTargetConfig(config appConfig, target string) appConfig {
+	targetConfig := config
+	targetConfig.target = target
+	targetConfig.options = make(options, len(config.options))
+	for key, value := range config.options {
+		targetConfig.options[key] = value
+	}
+
+	if len(targetConfig.mode) == 0 {
+		detectTarget(&targetConfig, target)
+	}
+	applyPluginSettings(&targetConfig, targetConfig.settings, targetConfig.mode)
+
+	return targetConfig
+}
+
+// detectTarget sets the mode of config to the plugin handling target, and
+// keeps the format recognized from its content, so that the target isn't
+// read again when it is parsed.
+func detectTarget(config *appConfig, target string) {
+	detection, found := dc.DetectTarget(target)
+	if !found {
+		return
+	}
+
+	config.mode = detection.PluginID
+	config.detectedFormat = detection.Format
+}
+
+// manifestTargetConfig returns a copy of config overridden by the settings
+// of a manifest entry.
+func manifestTargetConfig(config appConfig, entry cfg.Target) (appConfig, error) {
+	entryConfig := config
+	entryConfig.options = make(options, len(config.options)+len(entry.Options))
+	for key, value := range config.options {
+		entryConfig.options[key] = value
+	}
+	for key, value := range entry.Options {
+		entryConfig.options[key] = value
+	}
+
+	if len(entry.Mode) > 0 {
+		entryConfig.mode = entry.Mode
+	}
+
+	if len(entryConfig.mode) == 0 {
+		detectTarget(&entryConfig, entry.Target)
+	}
+	pluginID := entryConfig.mode
+
+	if len(entry.BackURL) > 0 {
+		entryConfig.backURL = entry.BackURL
+	} else if len(entry.Back) > 0 {
+		// The back has been validated when loading the manifest
+		entryConfig.backURL = dc.Plugins[pluginID].AvailableBacks()[entry.Back].URL
+	}
+
+	// The options of the entry can rely on the ones of the command line, so
+	// the requirements are checked once they are merged
+	if plugin, found := dc.Plugins[pluginID]; found {
+		if _, err := plugin.AvailableOptions().ValidateNormalize(entryConfig.options); err != nil {
+			return entryConfig, fmt.Errorf("invalid manifest %s: target %s: %w", config.manifest, entry.Target, err)
+		}
+	}
+
+	if len(entry.Output) > 0 {
+		if !config.dryRun {
+			if err := checkCreateDir(entry.Output); err != nil {
+				return entryConfig, err
+			}
+		}
+		entryConfig.outputFolder = entry.Output
+	}
+
+	return entryConfig, nil
+}
+
+// folderFiles returns the files found in folder. Its subfolders are ignored.
+func folderFiles(folder string) ([]string, error) {
+	log.Debugf("Processing directory %s", folder)
+
+	files := []string{}
+
+	err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == folder {
+			// The WalkFun is first called with the folder itself as argument
+			// Skip it
+			return nil
+		}
+
+		if info.IsDir() {
+			log.Infof("Ignoring directory %s", path)
+			// Do not process the files in the subfolder
+			return filepath.SkipDir
+		}
+
+		// Do not process the file inside the WalkFun, overwise if we
+		// generate files inside the target directory, these generated
+		// files will be picked up by filepath.Walk
+		files = append(files, path)
+
+		return nil
+	})
+
+	return files, err
+}
+
+// handleTargets processes the targets concurrently, with at most jobs
+// targets at the same time.
+// The plugins wait for their own rate limiter before each API call, which
+// is shared by all the targets.
+func handleTargets(configs []appConfig, jobs int) []targetResult {
+	results := make([]targetResult, len(configs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				results[index] = handleTarget(configs[index])
+			}
+		}()
+	}
+
+	for index := range configs {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// writeReport writes the status of each target, followed by the number of
+// targets which succeeded and failed.
+func writeReport(w io.Writer, results []targetResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(tw, "STATUS\tTARGET\tERRORS"); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		status := "ok"
+		if len(result.errs) > 0 {
+			status = "failed"
+			failed++
+		}
+
+		errs := make([]string, 0, len(result.errs))
+		for _, err := range result.errs {
+			errs = append(errs, err.Error())
+		}
+
+		_, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", status, result.target, strings.Join(errs, "; "))
+		if err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d targets processed: %d succeeded, %d failed\n", len(results), len(results)-failed, failed)
+	return err
+}
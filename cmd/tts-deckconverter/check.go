@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/tts"
+)
+
+func runCheck(args []string) {
+	var (
+		jsonOutput    bool
+		concurrency   int
+		slowThreshold time.Duration
+		timeout       time.Duration
+		debug         bool
+	)
+
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(
+			flags.Output(),
+			"Usage: %s check [flags] [SAVED_OBJECT|FOLDER...]\n\n"+
+				"Check the card images of Tabletop Simulator saved objects, and report the broken or slow links,\n"+
+				"as well as the template sheets whose size doesn't match their number of cards.\n"+
+				"The whole chest is checked if no saved object or folder is given.\n"+
+				"The exit code is 1 if a problem was found.\n\n"+
+				"Flags:\n",
+			filepath.Base(os.Args[0]),
+		)
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&jsonOutput, "json", false, "write the results as JSON")
+	flags.IntVar(&concurrency, "concurrency", tts.DefaultCheckConcurrency, "number of links checked at the same time")
+	flags.DurationVar(&slowThreshold, "slow", tts.DefaultSlowThreshold, "duration after which a link is reported as slow (0 to disable)")
+	flags.DurationVar(&timeout, "timeout", tts.DefaultCheckTimeout, "timeout of each request")
+	flags.BoolVar(&debug, "debug", false, "enable debug logging")
+
+	// Errors are handled by flag.ExitOnError
+	_ = flags.Parse(args)
+
+	logger := setUpLogger(debug)
+	defer syncLogger(logger)
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		chestPath, err := tts.FindChestPath()
+		if err != nil {
+			log.Fatal(err)
+		}
+		paths = []string{chestPath}
+	}
+
+	result, errs := tts.CheckLinks(
+		paths,
+		tts.WithConcurrency(concurrency),
+		tts.WithSlowThreshold(slowThreshold),
+		tts.WithTimeout(timeout),
+	)
+	for _, err := range errs {
+		log.Error(err)
+	}
+
+	var err error
+	if jsonOutput {
+		err = writeJSON(os.Stdout, result)
+	} else {
+		err = writeCheckTable(os.Stdout, result)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(result.Problems) > 0 || len(errs) > 0 {
+		syncLogger(logger)
+		os.Exit(1)
+	}
+}
+
+// writeJSON writes value as indented JSON.
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
+
+func writeCheckTable(w io.Writer, result tts.CheckResult) error {
+	if len(result.Problems) == 0 {
+		_, err := fmt.Fprintf(w, "No problem found in %d links from %d saved objects\n", result.Links, result.Files)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(tw, "PROBLEM\tURL\tDETAIL\tFILES"); err != nil {
+		return err
+	}
+	for _, problem := range result.Problems {
+		_, err := fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%s\n",
+			problem.Problem,
+			problem.URL,
+			problem.Detail,
+			strings.Join(problem.Files, ", "),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d problems found in %d links from %d saved objects\n", len(result.Problems), result.Links, result.Files)
+	return err
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// command is a subcommand of the CLI, called with
+// "tts-deckconverter COMMAND [flags] [arguments]".
+type command struct {
+	// description of the command, displayed in the usage message.
+	description string
+	// run executes the command with the arguments following its name.
+	run func(args []string)
+	// hidden commands aren't displayed in the usage message (e.g. the ones
+	// called by the completion scripts).
+	hidden bool
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"check": {
+			description: "check the card images of Tabletop Simulator saved objects for broken or slow links",
+			run:         runCheck,
+		},
+		"completion": {
+			description: "write the completion script of bash, zsh or fish",
+			run:         runCompletion,
+		},
+		completeCommand: {
+			run:    runComplete,
+			hidden: true,
+		},
+		"export": {
+			description: "write the decks of Tabletop Simulator saved objects back to a decklist",
+			run:         runExport,
+		},
+		"help": {
+			description: "display the supported URLs, file extensions, options, card backs and deck formats of a plugin, with examples",
+			run:         runHelp,
+		},
+		"list": {
+			description: "list the plugins, their options, deck formats and card backs, or the template uploaders",
+			run:         runList,
+		},
+		"refresh": {
+			description: "retrieve the cards of Tabletop Simulator saved objects again, and rewrite them in place",
+			run:         runRefresh,
+		},
+		"serve": {
+			description: "convert decks through a REST API",
+			run:         runServe,
+		},
+	}
+}
+
+func printCommands(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name, command := range commands {
+		if !command.hidden {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n        %s\n", name, commands[name].description)
+	}
+}
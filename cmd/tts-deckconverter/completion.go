@@ -0,0 +1,487 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts"
+)
+
+// completeCommand is the hidden command called by the completion scripts,
+// with the words of the command line following the program name. It
+// writes the candidates for the last word, one per line, followed by
+// completeFiles if file names should be completed too.
+const completeCommand = "__complete"
+
+// completeFiles is the last line written by completeCommand when file
+// names should be completed too.
+const completeFiles = ":files"
+
+// completionScripts are the completion scripts, by shell.
+// {{program}} is replaced by the name of the program, and {{function}} by
+// a function name derived from it.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for {{program}}
+# Load it with: source <({{program}} completion bash)
+
+_{{function}}() {
+    local line="${COMP_LINE:0:COMP_POINT}"
+    local -a words candidates
+    read -r -a words <<< "$line"
+    if [[ -z "$line" || "$line" =~ [[:space:]]$ ]]; then
+        words+=("")
+    fi
+    local cur="${words[${#words[@]}-1]}"
+
+    local IFS=$'\n'
+    candidates=($("${words[0]}" ` + completeCommand + ` "${words[@]:1}" 2>/dev/null))
+
+    local files=0
+    if [[ ${#candidates[@]} -gt 0 && "${candidates[${#candidates[@]}-1]}" == "` + completeFiles + `" ]]; then
+        files=1
+        unset "candidates[${#candidates[@]}-1]"
+    fi
+
+    # Remove the descriptions
+    candidates=("${candidates[@]%%$'\t'*}")
+
+    # Bash only replaces what follows the last "=" or ":" of the word
+    if [[ "$cur" == *[=:]* ]]; then
+        local prefix="${cur%"${cur##*[=:]}"}"
+        candidates=("${candidates[@]#"$prefix"}")
+    fi
+
+    COMPREPLY=("${candidates[@]}")
+    if [[ $files -eq 1 ]]; then
+        COMPREPLY+=($(compgen -f -- "$cur"))
+    fi
+
+    # Don't add a space after the option keys
+    if [[ ${#COMPREPLY[@]} -gt 0 && "${COMPREPLY[*]}" == *= ]] && type compopt &>/dev/null; then
+        compopt -o nospace
+    fi
+}
+
+complete -F _{{function}} {{program}}
+`,
+	"zsh": `#compdef {{program}}
+# zsh completion for {{program}}
+# Load it with: source <({{program}} completion zsh)
+
+_{{function}}() {
+    local -a lines candidates keys
+    local line value description files=0
+
+    lines=("${(@f)$(${words[1]} ` + completeCommand + ` "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+
+    for line in "${lines[@]}"; do
+        [[ -z "$line" ]] && continue
+        if [[ "$line" == "` + completeFiles + `" ]]; then
+            files=1
+            continue
+        fi
+
+        value="${line%%$'\t'*}"
+        description=""
+        [[ "$line" == *$'\t'* ]] && description="${line#*$'\t'}"
+        # The colons separate the values from their description
+        value="${value//:/\\:}"
+
+        if [[ "$value" == *= ]]; then
+            keys+=("${value}${description:+:$description}")
+        else
+            candidates+=("${value}${description:+:$description}")
+        fi
+    done
+
+    (( ${#candidates} )) && _describe -t values 'value' candidates
+    # Don't add a space after the option keys
+    (( ${#keys} )) && _describe -t keys 'key' keys -S ''
+    (( files )) && _files
+
+    return 0
+}
+
+compdef _{{function}} {{program}}
+`,
+	"fish": `# fish completion for {{program}}
+# Load it with: {{program}} completion fish | source
+
+function __{{function}}_complete
+    set -l tokens (commandline -opc)
+    set -l program $tokens[1]
+    set -e tokens[1]
+    set -l current (commandline -ct)
+
+    for candidate in ($program ` + completeCommand + ` $tokens "$current" 2>/dev/null)
+        if test "$candidate" = "` + completeFiles + `"
+            __fish_complete_path "$current"
+        else
+            echo $candidate
+        end
+    end
+end
+
+complete -c {{program}} -f -a '(__{{function}}_complete)'
+`,
+}
+
+func runCompletion(args []string) {
+	shells := make([]string, 0, len(completionScripts))
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+
+	program := filepath.Base(os.Args[0])
+
+	flags := flag.NewFlagSet("completion", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(
+			flags.Output(),
+			"Usage: %s completion %s\n\n"+
+				"Write the completion script of a shell to stdout. It completes the modes, card backs, plugin options, deck formats and template uploaders, e.g.:\n\n"+
+				"  source <(%s completion bash)\n"+
+				"  %s completion fish > ~/.config/fish/completions/%s.fish\n",
+			program,
+			strings.Join(shells, "|"),
+			program,
+			program,
+			program,
+		)
+		flags.PrintDefaults()
+	}
+
+	// Errors are handled by flag.ExitOnError
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprint(os.Stderr, "A shell is required\n\n")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	script, found := completionScripts[flags.Arg(0)]
+	if !found {
+		fmt.Fprintf(os.Stderr, "Invalid shell: %s\n\n", flags.Arg(0))
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	function := strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+		return r
+	}, program)
+
+	fmt.Print(strings.NewReplacer("{{program}}", program, "{{function}}", function).Replace(script))
+}
+
+func runComplete(args []string) {
+	candidates, files := completeArgs(args)
+
+	for _, candidate := range candidates {
+		if len(candidate.description) > 0 {
+			fmt.Printf("%s\t%s\n", candidate.value, candidate.description)
+		} else {
+			fmt.Println(candidate.value)
+		}
+	}
+	if files {
+		fmt.Println(completeFiles)
+	}
+}
+
+// candidate is a completion candidate.
+type candidate struct {
+	value       string
+	description string
+}
+
+// valueFlags are the flags of the commands whose values can be completed.
+var valueFlags = []string{"mode", "back", "option", "format", "template", "on-conflict"}
+
+// completeArgs returns the candidates for the last of args, which are the
+// words of the command line following the program name. files is true if
+// file names should be completed too.
+func completeArgs(args []string) (candidates []candidate, files bool) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	current := args[len(args)-1]
+	previous := args[:len(args)-1]
+
+	command, isCommand := "", false
+	if len(previous) > 0 {
+		_, isCommand = commands[previous[0]]
+		if isCommand {
+			command = previous[0]
+			previous = previous[1:]
+		}
+	}
+
+	// The flags of the commands are defined when they run, so only the
+	// values of the flags they share with the conversion are completed
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	defineFlags(flags, &appConfig{options: make(options)}, &mainFlags{})
+
+	takesValue := func(name string) bool {
+		if isCommand {
+			return plugins.IndexOf(name, valueFlags) >= 0
+		}
+		f := flags.Lookup(name)
+		if f == nil {
+			return false
+		}
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		return !ok || !boolFlag.IsBoolFlag()
+	}
+
+	mode := selectedMode(previous)
+
+	// "-flag value"
+	if len(previous) > 0 {
+		if name, ok := flagName(previous[len(previous)-1]); ok && !strings.Contains(name, "=") && takesValue(name) {
+			candidates, files = completeFlagValue(name, mode, current)
+			return filterCandidates(candidates, current), files
+		}
+	}
+
+	if strings.HasPrefix(current, "-") {
+		// "-flag=value"
+		if i := strings.Index(current, "="); i >= 0 {
+			name, _ := flagName(current[:i])
+			values, valueFiles := completeFlagValue(name, mode, current[i+1:])
+			for _, value := range values {
+				candidates = append(candidates, candidate{
+					value:       current[:i+1] + value.value,
+					description: value.description,
+				})
+			}
+			return filterCandidates(candidates, current), valueFiles
+		}
+
+		if isCommand {
+			return nil, false
+		}
+
+		dashes := "-"
+		if strings.HasPrefix(current, "--") {
+			dashes = "--"
+		}
+		flags.VisitAll(func(f *flag.Flag) {
+			candidates = append(candidates, candidate{
+				value:       dashes + f.Name,
+				description: strings.SplitN(f.Usage, "\n", 2)[0],
+			})
+		})
+		return filterCandidates(candidates, current), false
+	}
+
+	switch {
+	case !isCommand && len(previous) == 0:
+		// A command, or the first target
+		names := make([]string, 0, len(commands))
+		for name, c := range commands {
+			if !c.hidden {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			candidates = append(candidates, candidate{value: name, description: commands[name].description})
+		}
+		return filterCandidates(candidates, current), true
+	case command == "help":
+		return filterCandidates(pluginCandidates(), current), false
+	case command == "completion":
+		for shell := range completionScripts {
+			candidates = append(candidates, candidate{value: shell})
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].value < candidates[j].value
+		})
+		return filterCandidates(candidates, current), false
+	case command == "list":
+		if len(positionalArgs(previous, takesValue)) == 0 {
+			for _, kind := range listKinds {
+				candidates = append(candidates, candidate{value: kind})
+			}
+		} else {
+			candidates = pluginCandidates()
+		}
+		return filterCandidates(candidates, current), false
+	default:
+		return nil, true
+	}
+}
+
+// flagName returns the name of the flag of an argument, without the dashes,
+// or false if it isn't a flag.
+func flagName(arg string) (string, bool) {
+	if len(arg) < 2 || arg[0] != '-' || arg == "--" {
+		return "", false
+	}
+
+	return strings.TrimLeft(arg, "-"), true
+}
+
+// selectedMode returns the value of the last "-mode" flag of args.
+func selectedMode(args []string) string {
+	var mode string
+
+	for i, arg := range args {
+		name, ok := flagName(arg)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "mode" && i+1 < len(args):
+			mode = args[i+1]
+		case strings.HasPrefix(name, "mode="):
+			mode = strings.TrimPrefix(name, "mode=")
+		}
+	}
+
+	return mode
+}
+
+// positionalArgs returns the arguments of args which aren't flags or flag
+// values.
+func positionalArgs(args []string, takesValue func(name string) bool) []string {
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		name, ok := flagName(args[i])
+		if !ok {
+			positional = append(positional, args[i])
+			continue
+		}
+		if !strings.Contains(name, "=") && takesValue(name) {
+			// Skip the value
+			i++
+		}
+	}
+
+	return positional
+}
+
+// filterCandidates returns the candidates starting with prefix.
+func filterCandidates(candidates []candidate, prefix string) []candidate {
+	filtered := make([]candidate, 0, len(candidates))
+
+	for _, c := range candidates {
+		if strings.HasPrefix(c.value, prefix) {
+			filtered = append(filtered, c)
+		}
+	}
+
+	return filtered
+}
+
+// selectedPlugins returns the descriptions of the plugin of mode, or of all
+// the plugins if it isn't set.
+func selectedPlugins(mode string) []dc.PluginInfo {
+	if plugin, found := dc.Plugins[mode]; found {
+		return []dc.PluginInfo{dc.DescribePlugin(plugin)}
+	}
+
+	return dc.DescribePlugins()
+}
+
+func pluginCandidates() []candidate {
+	descriptions := dc.DescribePlugins()
+	candidates := make([]candidate, 0, len(descriptions))
+
+	for _, description := range descriptions {
+		candidates = append(candidates, candidate{value: description.ID, description: description.Name})
+	}
+
+	return candidates
+}
+
+// completeFlagValue returns the candidates for the value of the flag name,
+// for the plugin of mode (or all of them, if it isn't set). files is true
+// if the value can be a file name.
+func completeFlagValue(name, mode, value string) (candidates []candidate, files bool) {
+	// Only list each value once when several plugins have it
+	seen := make(map[string]bool)
+	add := func(value, description string) {
+		if !seen[value] {
+			seen[value] = true
+			candidates = append(candidates, candidate{value: value, description: description})
+		}
+	}
+
+	switch name {
+	case "mode":
+		return pluginCandidates(), false
+	case "back":
+		// A back can only be chosen along with a mode
+		if _, found := dc.Plugins[mode]; !found {
+			return nil, false
+		}
+		description := selectedPlugins(mode)[0]
+		keys := make([]string, 0, len(description.Backs))
+		for key := range description.Backs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			add(key, description.Backs[key].Description)
+		}
+	case "option":
+		key := value
+		if i := strings.Index(value, "="); i >= 0 {
+			key = value[:i]
+		}
+		for _, description := range selectedPlugins(mode) {
+			for _, option := range description.Options {
+				switch {
+				case !strings.Contains(value, "="):
+					add(option.Name+"=", option.Description)
+				case option.Name != key:
+				case len(option.AllowedValues) > 0:
+					for _, allowed := range option.AllowedValues {
+						add(option.Name+"="+allowed, "")
+					}
+				case option.Type == plugins.OptionTypeBool.String():
+					add(option.Name+"=true", "")
+					add(option.Name+"=false", "")
+				}
+			}
+		}
+	case "format":
+		add(dc.GenericFormat, "generic format of the plugin")
+		for _, description := range selectedPlugins(mode) {
+			for _, format := range description.Formats {
+				add(format, description.Name)
+			}
+		}
+	case "template":
+		for _, uploader := range dc.DescribeUploaders() {
+			add(uploader.ID, uploader.Description)
+		}
+	case "on-conflict":
+		for _, policy := range tts.ConflictPolicies {
+			add(string(policy), "")
+		}
+	case "name", "backURL", "filename", "thumbnail", "jobs", "profile":
+		// Free text
+		return nil, false
+	default:
+		// The other flags take file names or folders
+		return nil, true
+	}
+
+	return candidates, false
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	cfg "github.com/jeandeaual/tts-deckconverter/config"
+	"github.com/jeandeaual/tts-deckconverter/tts"
+)
+
+// defaultConfigPath returns the path of the configuration file in the user
+// configuration folder, to be displayed in the usage.
+func defaultConfigPath() string {
+	path, err := cfg.DefaultPath()
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// loadSettings reads the configuration file found at path (the default one
+// if path is empty), and returns the settings of profile (the default
+// settings if profile is empty).
+// The TTS data folder set in the configuration file is used from then on.
+func loadSettings(path, profile string) (cfg.Settings, error) {
+	if len(path) == 0 {
+		var err error
+		path, err = cfg.DefaultPath()
+		if err != nil {
+			// No configuration folder, only use the flags
+			return cfg.Settings{}, nil
+		}
+	}
+
+	config, err := cfg.Load(path)
+	if err != nil {
+		return cfg.Settings{}, err
+	}
+
+	if len(config.DataDir) > 0 {
+		tts.SetDataDir(config.DataDir)
+	}
+
+	return config.Profile(profile)
+}
+
+// applySettings sets the values of config which haven't been set with
+// flags from the settings of the configuration file.
+// The settings of the plugins are applied to each target with
+// applyPluginSettings.
+func applySettings(config *appConfig, settings cfg.Settings, flags *flag.FlagSet) {
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	setString := func(name string, value *string, setting string) {
+		if !set[name] && len(setting) > 0 {
+			*value = setting
+		}
+	}
+	setBool := func(name string, value *bool, setting *bool) {
+		if !set[name] && setting != nil {
+			*value = *setting
+		}
+	}
+
+	// The output folder and chest are ignored when the decks are written to
+	// a stream or an archive
+	if !set["output"] && !set["chest"] && !set["zip"] {
+		setString("output", &config.outputFolder, settings.Output)
+		setString("chest", &config.chest, settings.Chest)
+	}
+	setString("template", &config.templateMode, settings.Template)
+	setBool("compact", &config.compact, settings.Compact)
+	setBool("reproducible", &config.reproducible, settings.Reproducible)
+	setBool("banner", &config.banner, settings.Banner)
+	setBool("cache", &config.cache, settings.Cache)
+	setString("on-conflict", &config.onConflict, settings.OnConflict)
+	setString("filename", &config.filenameTemplate, settings.Filename)
+}
+
+// applyPluginSettings sets the back and the options of config which haven't
+// been set yet from the settings of the plugin pluginID in the
+// configuration file.
+func applyPluginSettings(config *appConfig, settings cfg.Settings, pluginID string) {
+	plugin, found := dc.Plugins[pluginID]
+	if !found {
+		return
+	}
+	pluginSettings := settings.Plugins[pluginID]
+
+	if len(config.backURL) == 0 {
+		config.backURL = pluginSettings.CardBackURL(plugin)
+	}
+	for key, value := range pluginSettings.Options {
+		// The options set with flags take precedence
+		if _, isSet := config.options[key]; !isSet {
+			config.options[key] = value
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cfg "github.com/jeandeaual/tts-deckconverter/config"
+)
+
+func TestApplySettingsOutput(t *testing.T) {
+	settings := cfg.Settings{Output: "decks", Chest: "Decks"}
+
+	testCases := []struct {
+		name   string
+		args   []string
+		output string
+		chest  string
+	}{
+		{name: "no flag", output: "decks", chest: "Decks"},
+		{name: "output", args: []string{"-output", "other"}, output: "other"},
+		{name: "stream", args: []string{"-output", "-"}, output: "-"},
+		{name: "chest", args: []string{"-chest", "Other"}, chest: "Other"},
+		{name: "zip", args: []string{"-zip", "decks.zip"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var config appConfig
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(ioutil.Discard)
+			flags.StringVar(&config.outputFolder, "output", "", "")
+			flags.StringVar(&config.chest, "chest", "", "")
+			flags.StringVar(&config.zip, "zip", "", "")
+			if !assert.Nil(t, flags.Parse(testCase.args)) {
+				return
+			}
+
+			applySettings(&config, settings, flags)
+
+			assert.Equal(t, testCase.output, config.outputFolder)
+			assert.Equal(t, testCase.chest, config.chest)
+		})
+	}
+}
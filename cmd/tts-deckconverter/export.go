@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts"
+)
+
+func runExport(args []string) {
+	var (
+		format     string
+		outputPath string
+		debug      bool
+	)
+
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(
+			flags.Output(),
+			"Usage: %s export -format FORMAT SAVED_OBJECT...\n\n"+
+				"Write the decks of Tabletop Simulator saved objects back to a decklist.\n"+
+				"Pass the sideboard (or extra deck) files along with the main deck to export them together.\n\n"+
+				"Flags:\n",
+			filepath.Base(os.Args[0]),
+		)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&format, "format", "", "format of the decklist: "+strings.Join(dc.AvailableExportFormats(), ", "))
+	flags.StringVar(&outputPath, "output", "", "destination file (defaults to the standard output)")
+	flags.BoolVar(&debug, "debug", false, "enable debug logging")
+
+	// Errors are handled by flag.ExitOnError
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Fprint(os.Stderr, "A saved object is required\n\n")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	handler, found := dc.ExportHandlers[format]
+	if !found {
+		fmt.Fprintf(os.Stderr, "Invalid format: %s\n\n", format)
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	logger := setUpLogger(debug)
+	defer syncLogger(logger)
+
+	var decks []*plugins.Deck
+
+	for _, path := range flags.Args() {
+		log.Infof("Reading %s", path)
+
+		object, err := tts.ReadSavedObjectFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		decks = append(decks, tts.ExtractDecks(object, tts.SavedObjectName(path))...)
+	}
+
+	err := export(handler, decks, outputPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func export(handler plugins.ExportHandler, decks []*plugins.Deck, outputPath string) (err error) {
+	var w io.Writer = os.Stdout
+
+	if len(outputPath) > 0 {
+		var file *os.File
+		file, err = os.Create(outputPath)
+		if err != nil {
+			return
+		}
+		defer func() {
+			if cerr := file.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}()
+		w = file
+
+		log.Infof("Writing %s", outputPath)
+	}
+
+	err = handler(w, decks)
+	if err != nil {
+		err = fmt.Errorf("couldn't export the decks: %w", err)
+	}
+
+	return
+}
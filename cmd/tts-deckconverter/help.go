@@ -0,0 +1,165 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+func runHelp(args []string) {
+	flags := flag.NewFlagSet("help", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(
+			flags.Output(),
+			"Usage: %s help PLUGIN\n\n"+
+				"Display the supported URLs, file extensions, options, card backs and deck formats of a plugin, with examples.\n\n"+
+				"Plugins:\n",
+			filepath.Base(os.Args[0]),
+		)
+		rows := make([]string, 0, len(dc.Plugins))
+		for _, description := range dc.DescribePlugins() {
+			rows = append(rows, "  "+description.ID+"\t"+description.Name)
+		}
+		_ = writeTable(flags.Output(), "  ID\tNAME", rows)
+	}
+
+	// Errors are handled by flag.ExitOnError
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	plugin, found := dc.Plugins[flags.Arg(0)]
+	if !found {
+		fmt.Fprintf(os.Stderr, "Invalid mode: %s\n\n", flags.Arg(0))
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	if err := writePluginHelp(os.Stdout, plugin); err != nil {
+		fmt.Fprintln(os.Stderr, plugins.CapitalizeString(err.Error()))
+		os.Exit(1)
+	}
+}
+
+// writePluginHelp writes the help page of a plugin.
+func writePluginHelp(w io.Writer, plugin plugins.Plugin) error {
+	description := dc.DescribePlugin(plugin)
+	program := filepath.Base(os.Args[0])
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s (%s)\n\n", description.Name, description.ID)
+	fmt.Fprintf(&sb, "Usage: %s -mode %s [flags] TARGET...\n", program, description.ID)
+
+	if urlHandlers := plugin.URLHandlers(); len(urlHandlers) > 0 {
+		sb.WriteString("\nSupported URLs:\n")
+		for _, urlHandler := range urlHandlers {
+			fmt.Fprintf(&sb, "  %s\n", urlHandler.BasePath)
+		}
+	}
+
+	if fileExtHandlers := plugin.FileExtHandlers(); len(fileExtHandlers) > 0 {
+		exts := make([]string, 0, len(fileExtHandlers))
+		for ext := range fileExtHandlers {
+			exts = append(exts, ext)
+		}
+		sort.Strings(exts)
+
+		fmt.Fprintf(&sb, "\nFile extensions (no mode needed):\n  %s\n", strings.Join(exts, ", "))
+	}
+
+	// Writing to a strings.Builder doesn't fail
+	if len(description.Options) > 0 {
+		rows := make([]string, 0, len(description.Options))
+		for _, option := range description.Options {
+			var defaultValue string
+			if option.DefaultValue != nil {
+				defaultValue = plugins.FormatOptionValue(option.DefaultValue)
+			} else if option.Required {
+				defaultValue = "required"
+			}
+			optionDescription := option.Description
+			if len(option.Requires) > 0 {
+				optionDescription += " (requires " + strings.Join(option.Requires, ", ") + ")"
+			}
+			values := option.Type
+			if len(option.AllowedValues) > 0 {
+				values = strings.Join(option.AllowedValues, ", ")
+			}
+			rows = append(rows, "  "+strings.Join([]string{option.Name, values, defaultValue, optionDescription}, "\t"))
+		}
+
+		sb.WriteString("\nOptions (-option KEY=VALUE):\n")
+		_ = writeTable(&sb, "  OPTION\tVALUES\tDEFAULT\tDESCRIPTION", rows)
+	}
+
+	if backs := plugin.AvailableBacks(); len(backs) > 0 {
+		keys := make([]string, 0, len(backs))
+		for key := range backs {
+			if key != plugins.DefaultBackKey {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		rows := make([]string, 0, len(backs))
+		if back, found := backs[plugins.DefaultBackKey]; found {
+			rows = append(rows, "  "+plugins.DefaultBackKey+" (default)\t"+back.Description)
+		}
+		for _, key := range keys {
+			rows = append(rows, "  "+key+"\t"+backs[key].Description)
+		}
+
+		sb.WriteString("\nCard backs (-back):\n")
+		_ = writeTable(&sb, "  BACK\tDESCRIPTION", rows)
+	}
+
+	sb.WriteString("\nDeck formats (-format, with stdin):\n")
+	writeDeckTypeExample(&sb, dc.GenericFormat+" (default)", plugin.GenericFileHandler())
+	for _, format := range description.Formats {
+		writeDeckTypeExample(&sb, format, plugin.DeckTypeHandlers()[format])
+	}
+
+	if exporter, ok := plugin.(plugins.Exporter); ok {
+		formats := make([]string, 0, len(exporter.ExportHandlers()))
+		for format := range exporter.ExportHandlers() {
+			formats = append(formats, format)
+		}
+		sort.Strings(formats)
+
+		fmt.Fprintf(&sb, "Export formats (%s export -format):\n  %s\n", program, strings.Join(formats, ", "))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// writeDeckTypeExample writes the name of a deck format followed by its
+// example, indented.
+func writeDeckTypeExample(sb *strings.Builder, name string, deckType plugins.DeckType) {
+	fmt.Fprintf(sb, "  %s\n", name)
+
+	if len(deckType.Example) == 0 {
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(deckType.Example, "\n"), "\n") {
+		sb.WriteString("\n")
+		if len(line) > 0 {
+			sb.WriteString("      ")
+			sb.WriteString(line)
+		}
+	}
+	sb.WriteString("\n\n")
+}
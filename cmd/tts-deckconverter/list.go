@@ -0,0 +1,220 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+// listKinds are the lists available with the list command.
+var listKinds = []string{"plugins", "options", "formats", "backs", "uploaders"}
+
+func runList(args []string) {
+	var jsonOutput bool
+
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(
+			flags.Output(),
+			"Usage: %s list [flags] %s [PLUGIN...]\n\n"+
+				"List the plugins, the options, deck formats and card backs of the plugins, or the template uploaders.\n"+
+				"The options, formats and backs are limited to the given plugins, if any.\n\n"+
+				"Flags:\n",
+			filepath.Base(os.Args[0]),
+			strings.Join(listKinds, "|"),
+		)
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&jsonOutput, "json", false, "write the list as JSON")
+
+	// Errors are handled by flag.ExitOnError
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Fprint(os.Stderr, "A list is required\n\n")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	// Accept the flags after the kind of list too (e.g. "list plugins --json")
+	kind := flags.Arg(0)
+	_ = flags.Parse(flags.Args()[1:])
+
+	descriptions := make([]dc.PluginInfo, 0, len(dc.Plugins))
+	if flags.NArg() == 0 {
+		descriptions = dc.DescribePlugins()
+	}
+	for _, pluginID := range flags.Args() {
+		plugin, found := dc.Plugins[pluginID]
+		if !found {
+			fmt.Fprintf(os.Stderr, "Invalid mode: %s\n\n", pluginID)
+			flags.Usage()
+			os.Exit(1)
+		}
+		descriptions = append(descriptions, dc.DescribePlugin(plugin))
+	}
+
+	var err error
+	switch kind {
+	case "plugins":
+		err = listPlugins(os.Stdout, descriptions, jsonOutput)
+	case "options":
+		err = listOptions(os.Stdout, descriptions, jsonOutput)
+	case "formats":
+		err = listFormats(os.Stdout, descriptions, jsonOutput)
+	case "backs":
+		err = listBacks(os.Stdout, descriptions, jsonOutput)
+	case "uploaders":
+		err = listUploaders(os.Stdout, jsonOutput)
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid list: %s\n\n", kind)
+		flags.Usage()
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, plugins.CapitalizeString(err.Error()))
+		os.Exit(1)
+	}
+}
+
+// writeTable writes rows of tab-separated columns, aligned.
+func writeTable(w io.Writer, header string, rows []string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(tw, header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(tw, row); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+func listPlugins(w io.Writer, descriptions []dc.PluginInfo, jsonOutput bool) error {
+	if jsonOutput {
+		return writeJSON(w, descriptions)
+	}
+
+	rows := make([]string, 0, len(descriptions))
+	for _, description := range descriptions {
+		rows = append(rows, description.ID+"\t"+description.Name)
+	}
+
+	return writeTable(w, "ID\tNAME", rows)
+}
+
+func listOptions(w io.Writer, descriptions []dc.PluginInfo, jsonOutput bool) error {
+	if jsonOutput {
+		options := make(map[string][]dc.OptionInfo, len(descriptions))
+		for _, description := range descriptions {
+			options[description.ID] = description.Options
+		}
+		return writeJSON(w, options)
+	}
+
+	rows := []string{}
+	for _, description := range descriptions {
+		for _, option := range description.Options {
+			var defaultValue string
+			if option.DefaultValue != nil {
+				defaultValue = plugins.FormatOptionValue(option.DefaultValue)
+			} else if option.Required {
+				defaultValue = "required"
+			}
+			optionDescription := option.Description
+			if len(option.Requires) > 0 {
+				optionDescription += " (requires " + strings.Join(option.Requires, ", ") + ")"
+			}
+			typeName := option.Type
+			if len(option.AllowedValues) > 0 {
+				typeName += " (" + strings.Join(option.AllowedValues, ", ") + ")"
+			}
+			rows = append(rows, strings.Join([]string{
+				description.ID,
+				option.Name,
+				typeName,
+				defaultValue,
+				optionDescription,
+			}, "\t"))
+		}
+	}
+
+	return writeTable(w, "PLUGIN\tOPTION\tTYPE\tDEFAULT\tDESCRIPTION", rows)
+}
+
+func listFormats(w io.Writer, descriptions []dc.PluginInfo, jsonOutput bool) error {
+	if jsonOutput {
+		formats := make(map[string][]string, len(descriptions))
+		for _, description := range descriptions {
+			formats[description.ID] = description.Formats
+		}
+		return writeJSON(w, formats)
+	}
+
+	rows := []string{}
+	for _, description := range descriptions {
+		for _, format := range description.Formats {
+			rows = append(rows, description.ID+"\t"+format)
+		}
+	}
+
+	return writeTable(w, "PLUGIN\tFORMAT", rows)
+}
+
+func listBacks(w io.Writer, descriptions []dc.PluginInfo, jsonOutput bool) error {
+	if jsonOutput {
+		backs := make(map[string]map[string]dc.BackInfo, len(descriptions))
+		for _, description := range descriptions {
+			backs[description.ID] = description.Backs
+		}
+		return writeJSON(w, backs)
+	}
+
+	rows := []string{}
+	for _, description := range descriptions {
+		keys := make([]string, 0, len(description.Backs))
+		for key := range description.Backs {
+			if key != plugins.DefaultBackKey {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		// Make sure "default" is first
+		if _, found := description.Backs[plugins.DefaultBackKey]; found {
+			keys = append([]string{plugins.DefaultBackKey}, keys...)
+		}
+
+		for _, key := range keys {
+			rows = append(rows, description.ID+"\t"+key+"\t"+description.Backs[key].Description)
+		}
+	}
+
+	return writeTable(w, "PLUGIN\tBACK\tDESCRIPTION", rows)
+}
+
+func listUploaders(w io.Writer, jsonOutput bool) error {
+	uploaders := dc.DescribeUploaders()
+
+	if jsonOutput {
+		return writeJSON(w, uploaders)
+	}
+
+	rows := make([]string, 0, len(uploaders))
+	for _, uploader := range uploaders {
+		rows = append(rows, uploader.ID+"\t"+uploader.Name+"\t"+uploader.Description)
+	}
+
+	return writeTable(w, "ID\tNAME\tDESCRIPTION", rows)
+}
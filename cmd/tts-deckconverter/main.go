@@ -12,114 +12,148 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	dc "github.com/jeandeaual/tts-deckconverter"
+	cfg "github.com/jeandeaual/tts-deckconverter/config"
 	"github.com/jeandeaual/tts-deckconverter/log"
 	"github.com/jeandeaual/tts-deckconverter/plugins"
 	"github.com/jeandeaual/tts-deckconverter/tts"
 	"github.com/jeandeaual/tts-deckconverter/tts/upload"
 )
 
-func handleFolder(config appConfig) []error {
-	log.Infof("Processing directory %s", config.target)
+// defaultStdinDeckName is the name of the deck read from stdin when -name
+// isn't set.
+const defaultStdinDeckName = "Deck"
 
-	files := []string{}
-	errs := []error{}
+// handleTarget converts a target. The errors are tagged with the stage of
+// the conversion at which they happened.
+func handleTarget(config appConfig) (result targetResult) {
+	result.target = config.target
 
-	err := filepath.Walk(config.target, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if path == config.target {
-			// The WalkFun is first called with the folder itself as argument
-			// Skip it
-			return nil
-		}
+	var (
+		decks []*plugins.Deck
+		err   error
+	)
 
-		if info.IsDir() {
-			log.Infof("Ignoring directory %s", path)
-			// Do not process the files in the subfolder
-			return filepath.SkipDir
-		}
+	if config.target != "-" {
+		log.Infof("Processing %s", config.target)
 
-		// Do not process the file inside the WalkFun, overwise if we
-		// generate files inside the target directory, these generated
-		// files will be picked up by filepath.Walk
-		files = append(files, path)
+		decks, err = dc.ParseFormat(config.target, config.mode, config.detectedFormat, config.options)
+	} else {
+		log.Info("Processing stdin")
 
-		return nil
-	})
+		decks, err = dc.ParseDecklist(os.Stdin, config.deckName, config.mode, config.deckFormat, config.options)
+	}
 	if err != nil {
-		log.Error(err)
-		errs = append(errs, err)
-		return errs
+		result.errs = append(result.errs, &stageError{
+			kind: failureParse,
+			err:  fmt.Errorf("couldn't parse target: %w", err),
+		})
+		return result
 	}
 
-	for _, file := range files {
-		fileConfig := config
-		fileConfig.target = file
-		targetErrs := handleTarget(fileConfig)
-		errs = append(errs, targetErrs...)
+	if config.dryRun {
+		// Only keep the decks, which are listed by processTargets
+		for _, deck := range decks {
+			if len(deck.Cards) > 0 {
+				result.decks = append(result.decks, tts.GeneratedDeck{Deck: deck})
+			}
+		}
+		return result
 	}
 
-	return errs
-}
+	options := append(generateOptions(config), tts.WithOnGenerated(func(generated tts.GeneratedDeck) {
+		result.decks = append(result.decks, generated)
+	}))
+
+	if config.uploader != nil {
+		templateErrs, ok := generateTemplates(decks, config.outputFolder, *config.uploader, options...)
+		for _, templateErr := range templateErrs {
+			result.errs = append(result.errs, &stageError{kind: failureTemplate, err: templateErr})
+		}
+		if !ok {
+			return result
+		}
+	}
 
-func handleTarget(config appConfig) []error {
-	errs := []error{}
+	if config.push {
+		err = tts.Push(decks, config.backURL, tts.DefaultEditorAddress, options...)
+		if err != nil {
+			result.errs = append(result.errs, &stageError{
+				kind: failureNetwork,
+				err:  fmt.Errorf("couldn't push the decks to the game: %w", err),
+			})
+		}
+		return result
+	}
 
-	var (
-		decks []*plugins.Deck
-		err   error
-	)
+	generateErrs := tts.Generate(decks, config.backURL, config.outputFolder, !config.compact, options...)
+	result.errs = append(result.errs, generateErrs...)
 
-	if config.target != "-" {
-		log.Infof("Processing %s", config.target)
+	return result
+}
 
-		decks, err = dc.Parse(config.target, config.mode, config.options)
-	} else {
-		plugin, found := dc.Plugins[config.mode]
-		if !found {
-			log.Fatalf("Invalid mode: %s", config.mode)
+// generateTemplates generates the templates of the decks.
+// ok is false if the decks can't be generated because of the errors.
+func generateTemplates(
+	decks []*plugins.Deck,
+	outputFolder string,
+	uploader upload.TemplateUploader,
+	options ...tts.GenerateOption,
+) (errs []error, ok bool) {
+	errs = tts.GenerateTemplates([][]*plugins.Deck{decks}, outputFolder, uploader, options...)
+
+	for _, err := range errs {
+		if !errors.Is(err, upload.ErrUploadSize) {
+			return errs, false
 		}
+	}
 
-		handler := plugin.GenericFileHandler().FileHandler
-		if deckTypeHandler, found := plugin.DeckTypeHandlers()[config.deckFormat]; found {
-			handler = deckTypeHandler.FileHandler
-		} else {
-			log.Fatalf("Invalid format: %s", config.deckFormat)
-		}
+	// If the only error we got was that the template was too big to be uploaded, continue
+	// The user will be able to upload the template manually later on
+	return errs, true
+}
 
-		log.Info("Processing stdin")
+// generateOptions returns the options used when generating the deck files.
+func generateOptions(config appConfig) []tts.GenerateOption {
+	var options []tts.GenerateOption
 
-		decks, err = handler(os.Stdin, config.deckName, config.options)
+	if config.reproducible {
+		options = append(options, tts.WithReproducible())
 	}
-	if err != nil {
-		errs = append(errs, fmt.Errorf("couldn't parse target: %w", err))
-		return errs
+	if len(config.thumbnail) > 0 {
+		options = append(options, tts.WithThumbnailCards(config.thumbnail...))
+	}
+	if config.banner {
+		options = append(options, tts.WithThumbnailBanner())
+	}
+	if config.modCache != nil {
+		options = append(options, tts.WithModCache(config.modCache))
+	}
+	if len(config.conflictPolicy) > 0 {
+		options = append(options, tts.WithConflictPolicy(config.conflictPolicy))
+	}
+	if len(config.filenameTemplate) > 0 {
+		options = append(options, tts.WithFilenameTemplate(config.filenameTemplate))
+	}
+	if config.sink != nil {
+		options = append(options, tts.WithSink(config.sink))
+	}
+	if config.zipImages {
+		options = append(options, tts.WithBundledImages())
 	}
 
-	if config.uploader != nil {
-		templateErrs := tts.GenerateTemplates([][]*plugins.Deck{decks}, config.outputFolder, *config.uploader)
-		if len(templateErrs) > 0 {
-			uploadSizeErrsOnly := true
-			for _, err := range templateErrs {
-				if !errors.Is(err, upload.ErrUploadSize) {
-					uploadSizeErrsOnly = false
-				}
-			}
-
-			// If the only error we got was that the template was too big to be uploaded, continue
-			// The user will be able to upload the template manually later on
-			if !uploadSizeErrsOnly {
-				return templateErrs
-			}
+	return options
+}
 
-			errs = append(errs, templateErrs...)
-		}
+// newModCache returns the cache of the Tabletop Simulator mod images.
+func newModCache() (*tts.ModCache, error) {
+	path, err := tts.FindModImagesPath()
+	if err != nil {
+		return nil, err
 	}
 
-	generateErrs := tts.Generate(decks, config.backURL, config.outputFolder, !config.compact)
-	return append(errs, generateErrs...)
+	log.Infof("Images will be cached in %s", path)
+
+	return tts.NewModCache(path), nil
 }
 
 func checkCreateDir(path string) error {
@@ -142,77 +176,162 @@ func checkCreateDir(path string) error {
 }
 
 func checkErrs(errs []error) {
-	if len(errs) > 0 {
-		for _, err := range errs {
-			log.Error(plugins.CapitalizeString(err.Error()))
-		}
+	if !logErrs(errs) {
 		os.Exit(1)
 	}
 }
 
+// logErrs logs the errors, and returns true if there are none.
+func logErrs(errs []error) bool {
+	for _, err := range errs {
+		log.Error(plugins.CapitalizeString(err.Error()))
+	}
+
+	return len(errs) == 0
+}
+
 type appConfig struct {
-	target       string
-	backURL      string
-	back         string
-	debug        bool
-	mode         string
-	deckName     string
-	deckFormat   string
-	outputFolder string
-	chest        string
-	templateMode string
-	uploader     *upload.TemplateUploader
-	compact      bool
-	options      options
+	target           string
+	targets          []string
+	manifest         string
+	jobs             int
+	recursive        bool
+	watch            bool
+	jsonOutput       bool
+	dryRun           bool
+	warnings         *warningRecorder
+	state            *conversionState
+	settings         cfg.Settings
+	backURL          string
+	back             string
+	debug            bool
+	mode             string
+	deckName         string
+	deckFormat       string
+	detectedFormat   string
+	outputFolder     string
+	zip              string
+	zipImages        bool
+	sink             tts.Sink
+	chest            string
+	templateMode     string
+	uploader         *upload.TemplateUploader
+	compact          bool
+	reproducible     bool
+	thumbnail        stringList
+	banner           bool
+	cache            bool
+	push             bool
+	onConflict       string
+	conflictPolicy   tts.ConflictPolicy
+	filenameTemplate string
+	modCache         *tts.ModCache
+	options          options
 }
 
-func parseFlags() appConfig {
-	var (
-		config      appConfig
-		showVersion bool
-	)
+// flagConflict is a flag which cannot be used with the one being checked.
+type flagConflict struct {
+	name string
+	set  bool
+}
+
+// mainFlags are the values of the flags of the conversion which aren't
+// kept in appConfig.
+type mainFlags struct {
+	showVersion bool
+	configPath  string
+	profile     string
+}
 
+// defineFlags defines the flags of the conversion on flags.
+func defineFlags(flags *flag.FlagSet, config *appConfig, values *mainFlags) {
 	availableModes := dc.AvailablePlugins()
 	availableOptions := getAvailableOptions(availableModes)
 	availableDeckFormats := getAvailableDeckFormats(availableModes)
 	availableBacks := getAvailableBacks(availableModes)
 	availableUploaders := getAvailableUploaders()
 
+	flags.StringVar(&config.back, "back", "", "card back (cannot be used with \"-backURL\"). Choose from:"+availableBacks)
+	flags.StringVar(&config.backURL, "backURL", "", "custom URL for the card backs (cannot be used with \"-back\")")
+	flags.StringVar(&config.mode, "mode", "", "available modes: "+strings.Join(availableModes, ", "))
+	flags.StringVar(&config.deckName, "name", "", "name of the deck (usually inferred from the input file name or URL, \""+defaultStdinDeckName+"\" by default with stdin)")
+	flags.StringVar(&config.deckFormat, "format", "", "format of the deck read from stdin (detected from its content by default, along with the mode)"+availableDeckFormats)
+	flags.StringVar(&config.outputFolder, "output", "", "destination folder (defaults to the current folder), or \"-\" to write the saved objects to stdout, without thumbnails (cannot be used with \"-chest\")")
+	flags.StringVar(&config.zip, "zip", "", "write the saved objects, thumbnails and templates of all the targets to a zip archive instead of a folder")
+	flags.BoolVar(&config.zipImages, "zip-images", false, "write the card images to the zip archive too, named like in the Tabletop Simulator mod cache, so that the decks can be shared offline")
+	flags.StringVar(&config.chest, "chest", "", "save to the Tabletop Simulator chest folder (use \"/\" for the root folder) (cannot be used with \"-output\")")
+	flags.StringVar(&config.templateMode, "template", "", "download each images and create a deck template instead of referring to each image individually. Choose from the following uploaders:"+availableUploaders)
+	flags.Var(&config.options, "option", "plugin specific option (can have multiple)"+availableOptions)
+	flags.BoolVar(&config.compact, "compact", false, "don't indent the resulting JSON file")
+	flags.StringVar(&config.onConflict, "on-conflict", string(tts.ConflictOverwrite), "what to do when the file of a deck already exists: "+getAvailableConflictPolicies())
+	flags.StringVar(&config.filenameTemplate, "filename", "", "template of the deck file names, relative to the output folder, where each \"/\" creates a subfolder (defaults to the deck name), e.g. \"{plugin}/{site}/{name} - {board}\". Available fields:"+getFilenameTemplateFields())
+	flags.Var(&config.thumbnail, "thumbnail", "name of a card to show in the deck thumbnail (can have multiple, the cards are fanned out)")
+	flags.BoolVar(&config.banner, "banner", false, "write the name of the deck at the bottom of its thumbnail")
+	flags.BoolVar(&config.reproducible, "reproducible", false, "leave the date out of the resulting JSON file, so that converting the same deck always gives the same output")
+	flags.BoolVar(&config.push, "push", false, "spawn the decks in the running Tabletop Simulator game instead of writing them to files")
+	flags.BoolVar(&config.cache, "cache", false, "write the card images to the Tabletop Simulator mod cache, so that the decks load without downloading them")
+	flags.BoolVar(&config.dryRun, "dry-run", false, "parse the targets and resolve their cards, then list the cards of each deck (board, count, name, printing and image) instead of generating anything")
+	flags.StringVar(&config.manifest, "manifest", "", "YAML or JSON file listing targets to convert, with their own mode, back, options and output folder")
+	flags.BoolVar(&config.recursive, "recursive", false, "convert the files of the subfolders of the folder targets too, recreating the folder tree in the output folder, and skip the files which haven't changed since the last run. The plugin of a folder is set by its name (e.g. \"mtg\") or by a "+cfg.FolderSettingsFile+" file")
+	flags.BoolVar(&config.watch, "watch", false, "keep running after the conversion, and convert the file targets again when they change")
+	flags.BoolVar(&config.jsonOutput, "json", false, "write the generated files, decks, warnings and errors as JSON to stdout. The exit code tells the failures apart: "+getExitCodes())
+	flags.IntVar(&config.jobs, "jobs", 4, "number of targets processed at the same time")
+	flags.StringVar(&values.configPath, "config", "", "configuration file containing the default settings and the profiles (defaults to "+defaultConfigPath()+")")
+	flags.StringVar(&values.profile, "profile", "", "profile of the configuration file to use")
+	if len(version) > 0 {
+		flags.BoolVar(&values.showVersion, "version", false, "display the version information")
+	}
+	flags.BoolVar(&config.debug, "debug", false, "enable debug logging")
+}
+
+func parseFlags() appConfig {
+	var (
+		config appConfig
+		values mainFlags
+	)
+
 	config.options = make(options)
 
 	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s TARGET\n\nFlags:\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] TARGET...\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s COMMAND [flags] [arguments]\n\nCommands:\n", filepath.Base(os.Args[0]))
+		printCommands(flag.CommandLine.Output())
+		fmt.Fprint(flag.CommandLine.Output(), "\nFlags:\n")
 		flag.PrintDefaults()
 	}
 
-	flag.StringVar(&config.back, "back", "", "card back (cannot be used with \"-backURL\"). Choose from:"+availableBacks)
-	flag.StringVar(&config.backURL, "backURL", "", "custom URL for the card backs (cannot be used with \"-back\")")
-	flag.StringVar(&config.mode, "mode", "", "available modes: "+strings.Join(availableModes, ", "))
-	flag.StringVar(&config.deckName, "name", "", "name of the deck (usually inferred from the input file name or URL, but required with stdin)")
-	flag.StringVar(&config.deckFormat, "format", "", "format of the deck (usually inferred from the input file name or URL, but required with stdin)"+availableDeckFormats)
-	flag.StringVar(&config.outputFolder, "output", "", "destination folder (defaults to the current folder) (cannot be used with \"-chest\")")
-	flag.StringVar(&config.chest, "chest", "", "save to the Tabletop Simulator chest folder (use \"/\" for the root folder) (cannot be used with \"-output\")")
-	flag.StringVar(&config.templateMode, "template", "", "download each images and create a deck template instead of referring to each image individually. Choose from the following uploaders:"+availableUploaders)
-	flag.Var(&config.options, "option", "plugin specific option (can have multiple)"+availableOptions)
-	flag.BoolVar(&config.compact, "compact", false, "don't indent the resulting JSON file")
-	if len(version) > 0 {
-		flag.BoolVar(&showVersion, "version", false, "display the version information")
-	}
-	flag.BoolVar(&config.debug, "debug", false, "enable debug logging")
+	defineFlags(flag.CommandLine, &config, &values)
 
 	flag.Parse()
 
-	if showVersion {
+	if values.showVersion {
 		displayBuildInformation()
 		os.Exit(0)
 	}
 
-	if flag.NArg() == 0 || flag.NArg() > 1 {
+	if flag.NArg() == 0 && len(config.manifest) == 0 {
 		fmt.Fprint(os.Stderr, "A target is required\n\n")
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	if config.jobs < 1 {
+		fmt.Fprint(os.Stderr, "\"-jobs\" must be at least 1\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// The flags take precedence over the configuration file
+	// The settings of the plugins are applied to each target
+	var err error
+	config.settings, err = loadSettings(values.configPath, values.profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n\n", plugins.CapitalizeString(err.Error()))
+		flag.Usage()
+		os.Exit(1)
+	}
+	applySettings(&config, config.settings, flag.CommandLine)
+
 	plugin, found := dc.Plugins[config.mode]
 	if len(config.mode) > 0 && !found {
 		fmt.Fprintf(os.Stderr, "Invalid mode: %s\n\n", config.mode)
@@ -226,6 +345,40 @@ func parseFlags() appConfig {
 		os.Exit(1)
 	}
 
+	if config.outputFolder == "-" {
+		for _, conflict := range []flagConflict{
+			{"-json", config.jsonOutput},
+			{"-recursive", config.recursive},
+			{"-zip", len(config.zip) > 0},
+		} {
+			if conflict.set {
+				fmt.Fprintf(os.Stderr, "\"-output -\" cannot be used with \"%s\"\n\n", conflict.name)
+				flag.Usage()
+				os.Exit(1)
+			}
+		}
+	}
+
+	if len(config.zip) > 0 {
+		for _, conflict := range []flagConflict{
+			{"-output", len(config.outputFolder) > 0},
+			{"-chest", len(config.chest) > 0},
+			{"-recursive", config.recursive},
+			{"-watch", config.watch},
+			{"-push", config.push},
+		} {
+			if conflict.set {
+				fmt.Fprintf(os.Stderr, "\"-zip\" cannot be used with \"%s\"\n\n", conflict.name)
+				flag.Usage()
+				os.Exit(1)
+			}
+		}
+	} else if config.zipImages {
+		fmt.Fprint(os.Stderr, "\"-zip-images\" requires \"-zip\"\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
 	if len(config.back) > 0 && len(config.backURL) > 0 {
 		fmt.Fprint(os.Stderr, "\"-back\" and \"-backURL\" cannot be used at the same time\n\n")
 		flag.Usage()
@@ -256,19 +409,52 @@ func parseFlags() appConfig {
 			flag.Usage()
 			os.Exit(1)
 		}
+
+		// The templates of the manual uploader are referred to by their
+		// location in the output folder, which must be loadable by the game
+		if config.templateMode == "manual" {
+			for _, conflict := range []flagConflict{
+				{"-output -", config.outputFolder == "-"},
+				{"-zip", len(config.zip) > 0},
+			} {
+				if conflict.set {
+					fmt.Fprintf(os.Stderr, "\"-template manual\" cannot be used with \"%s\"\n\n", conflict.name)
+					flag.Usage()
+					os.Exit(1)
+				}
+			}
+		}
 	}
 
-	config.target = flag.Args()[0]
+	config.conflictPolicy, err = tts.ParseConflictPolicy(config.onConflict)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n\n", plugins.CapitalizeString(err.Error()))
+		flag.Usage()
+		os.Exit(1)
+	}
 
-	if config.target == "-" {
-		if len(config.mode) == 0 {
-			fmt.Fprintln(os.Stderr, "-mode is required when parsing stdin")
+	if len(config.filenameTemplate) > 0 {
+		if err = tts.ValidateFilenameTemplate(config.filenameTemplate); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n\n", plugins.CapitalizeString(err.Error()))
+			flag.Usage()
+			os.Exit(1)
+		}
+	}
+
+	config.targets = flag.Args()
+
+	if len(config.targets) == 1 && config.targets[0] == "-" && len(config.manifest) == 0 {
+		if len(config.deckFormat) > 0 && len(config.mode) == 0 {
+			fmt.Fprintln(os.Stderr, "-mode is required to set the format")
 			flag.Usage()
 			os.Exit(1)
 		}
 
 		if len(config.deckName) == 0 {
-			fmt.Fprintln(os.Stderr, "-name is required when parsing stdin")
+			config.deckName = defaultStdinDeckName
+		}
+		if config.watch {
+			fmt.Fprintln(os.Stderr, "Stdin cannot be watched")
 			flag.Usage()
 			os.Exit(1)
 		}
@@ -276,17 +462,25 @@ func parseFlags() appConfig {
 		fmt.Fprintln(os.Stderr, "You can only set the deck name when parsing stdin")
 		flag.Usage()
 		os.Exit(1)
+	} else {
+		for _, target := range config.targets {
+			if target == "-" {
+				fmt.Fprintln(os.Stderr, "Stdin cannot be parsed with other targets")
+				flag.Usage()
+				os.Exit(1)
+			}
+		}
 	}
 
 	return config
 }
 
-func main() {
-	config := parseFlags()
-
+// setUpLogger creates the logger used by the application.
+// The returned logger should be synced before exiting.
+func setUpLogger(debug bool, options ...zap.Option) *zap.Logger {
 	var zapConf zap.Config
 
-	if config.debug {
+	if debug {
 		zapConf = zap.NewDevelopmentConfig()
 		zapConf.EncoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
 	} else {
@@ -298,24 +492,55 @@ func main() {
 	}
 
 	// Skip 1 caller, since all log calls will be done from deckconverter/log
-	logger, err := zapConf.Build(zap.AddCallerSkip(1))
+	logger, err := zapConf.Build(append([]zap.Option{zap.AddCallerSkip(1)}, options...)...)
 	if err != nil {
 		fmt.Fprint(os.Stderr, err.Error())
 		os.Exit(1)
 	}
-	defer func() {
-		// Don't check for errors since logger.Sync() can sometimes fail
-		// even if the logs were properly displayed
-		// See https://github.com/uber-go/zap/issues/328
-		_ = logger.Sync()
-	}()
 
 	log.SetLogger(logger.Sugar())
 
-	if len(config.outputFolder) > 0 {
-		err = checkCreateDir(config.outputFolder)
-		if err != nil {
-			log.Fatal(err)
+	return logger
+}
+
+// syncLogger flushes the logs.
+func syncLogger(logger *zap.Logger) {
+	// Don't check for errors since logger.Sync() can sometimes fail
+	// even if the logs were properly displayed
+	// See https://github.com/uber-go/zap/issues/328
+	_ = logger.Sync()
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		if command, found := commands[os.Args[1]]; found {
+			command.run(os.Args[2:])
+			return
+		}
+	}
+
+	config := parseFlags()
+
+	var loggerOptions []zap.Option
+	if config.jsonOutput {
+		config.warnings = &warningRecorder{}
+		loggerOptions = append(loggerOptions, zap.Hooks(config.warnings.record))
+	}
+
+	logger := setUpLogger(config.debug, loggerOptions...)
+	defer syncLogger(logger)
+
+	var err error
+
+	if config.outputFolder == "-" {
+		config.sink = tts.NewWriterSink(os.Stdout)
+	} else if len(config.outputFolder) > 0 {
+		// Nothing is written with "-dry-run"
+		if !config.dryRun {
+			err = checkCreateDir(config.outputFolder)
+			if err != nil {
+				log.Fatal(err)
+			}
 		}
 	} else if len(config.chest) > 0 {
 		var chestPath string
@@ -324,9 +549,11 @@ func main() {
 			log.Fatal(err)
 		}
 		config.outputFolder = filepath.Join(chestPath, config.chest)
-		err = checkCreateDir(config.outputFolder)
-		if err != nil {
-			log.Fatal(err)
+		if !config.dryRun {
+			err = checkCreateDir(config.outputFolder)
+			if err != nil {
+				log.Fatal(err)
+			}
 		}
 	} else {
 		// Set the output directory to the current working directory
@@ -336,14 +563,122 @@ func main() {
 		}
 	}
 
-	log.Infof("Generated files will go in %s", config.outputFolder)
+	var zipSink *tts.ZipSink
+	switch {
+	case config.outputFolder == "-":
+		log.Info("Generated files will be written to stdout")
+	case len(config.zip) > 0 && !config.dryRun:
+		zipSink = tts.NewZipSink(config.zip)
+		config.sink = zipSink
+		log.Infof("Generated files will go in %s", config.zip)
+	default:
+		log.Infof("Generated files will go in %s", config.outputFolder)
+	}
 
-	if info, err := os.Stat(config.target); err == nil && info.IsDir() {
-		errs := handleFolder(config)
-		checkErrs(errs)
-		os.Exit(0)
+	if config.cache {
+		config.modCache, err = newModCache()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if config.recursive {
+		config.state, err = loadConversionState(config.outputFolder)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	configs, err := targetConfigs(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code := exitOK
+	if len(configs) == 0 {
+		log.Info("Nothing to convert")
+	}
+	if len(configs) > 0 || config.jsonOutput {
+		code = processTargets(config, configs)
+	}
+
+	if zipSink != nil {
+		if err = zipSink.Close(); err != nil {
+			log.Error(err)
+			if code == exitOK {
+				code = exitFailure
+			}
+		}
+	}
+
+	if config.watch {
+		watchTargets(config)
 	}
 
-	errs := handleTarget(config)
-	checkErrs(errs)
+	if code != exitOK {
+		syncLogger(logger)
+		os.Exit(code)
+	}
+}
+
+// processTargets converts the targets, and reports their errors.
+// A single target reports its errors in the logs, several targets in a
+// final report. With "-json", the result is written to stdout instead of
+// the report. The returned exit code is exitOK if all the targets were
+// converted.
+func processTargets(config appConfig, configs []appConfig) (code int) {
+	var results []targetResult
+	if len(configs) == 1 {
+		results = []targetResult{handleTarget(configs[0])}
+		logErrs(results[0].errs)
+	} else {
+		results = handleTargets(configs, config.jobs)
+	}
+	if !config.dryRun {
+		saveState(config.state, results)
+	}
+
+	var err error
+	switch {
+	case config.jsonOutput:
+		err = writeJSON(os.Stdout, newConversionResult(results, config.warnings.take(), config.dryRun))
+	case config.dryRun:
+		err = writePreview(os.Stdout, results)
+		if err == nil && len(results) > 1 {
+			fmt.Println()
+			err = writeReport(os.Stdout, results)
+		}
+	case len(results) > 1 && config.outputFolder == "-":
+		// Keep stdout for the saved objects
+		err = writeReport(os.Stderr, results)
+	case len(results) > 1:
+		err = writeReport(os.Stdout, results)
+	}
+	if err != nil {
+		log.Error(err)
+		return exitFailure
+	}
+
+	return exitCode(results)
+}
+
+// saveState records the targets successfully converted in recursive mode.
+func saveState(state *conversionState, results []targetResult) {
+	if state == nil {
+		return
+	}
+
+	for _, result := range results {
+		if len(result.errs) == 0 {
+			var files []string
+			for _, deck := range result.decks {
+				files = append(files, deck.Files...)
+			}
+			state.converted(result.target, files)
+		}
+	}
+
+	if err := state.save(); err != nil {
+		log.Errorf("Couldn't save the conversion state: %v", err)
+	}
 }
@@ -10,6 +10,7 @@ import (
 
 	dc "github.com/jeandeaual/tts-deckconverter"
 	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts"
 	"github.com/jeandeaual/tts-deckconverter/tts/upload"
 )
 
@@ -26,7 +27,8 @@ func (o *options) String() string {
 }
 
 func (o *options) Set(value string) error {
-	kv := strings.Split(value, "=")
+	// The value of the option may contain "="
+	kv := strings.SplitN(value, "=", 2)
 
 	if len(kv) != 2 {
 		return errors.New("invalid option value: " + value)
@@ -40,6 +42,18 @@ func (o *options) Set(value string) error {
 	return nil
 }
 
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+
+	return nil
+}
+
 func getAvailableOptions(pluginNames []string) string {
 	var sb strings.Builder
 
@@ -78,9 +92,17 @@ func getAvailableOptions(pluginNames []string) string {
 			sb.WriteString("): ")
 			sb.WriteString(option.Description)
 
+			if option.Required {
+				sb.WriteString(" (required)")
+			}
 			if option.DefaultValue != nil {
 				sb.WriteString(" (default: ")
-				sb.WriteString(fmt.Sprintf("%v", option.DefaultValue))
+				sb.WriteString(plugins.FormatOptionValue(option.DefaultValue))
+				sb.WriteString(")")
+			}
+			if len(option.Requires) > 0 {
+				sb.WriteString(" (requires: ")
+				sb.WriteString(strings.Join(option.Requires, ", "))
 				sb.WriteString(")")
 			}
 		}
@@ -106,7 +128,7 @@ func getAvailableDeckFormats(pluginNames []string) string {
 
 		deckTypeHandlers := plugin.DeckTypeHandlers()
 
-		sb.WriteString("\tgeneric")
+		sb.WriteString("\t" + dc.GenericFormat)
 
 		if len(deckTypeHandlers) == 0 {
 			continue
@@ -198,3 +220,46 @@ func getAvailableUploaders() string {
 
 	return sb.String()
 }
+
+func getAvailableConflictPolicies() string {
+	policies := make([]string, 0, len(tts.ConflictPolicies))
+	for _, policy := range tts.ConflictPolicies {
+		policies = append(policies, string(policy))
+	}
+
+	return strings.Join(policies, ", ")
+}
+
+func getFilenameTemplateFields() string {
+	var sb strings.Builder
+
+	fields := make([]string, 0, len(tts.FilenameTemplateFields))
+	for field := range tts.FilenameTemplateFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		sb.WriteString("\n\t{")
+		sb.WriteString(field)
+		sb.WriteString("}: ")
+		sb.WriteString(tts.FilenameTemplateFields[field])
+	}
+
+	return sb.String()
+}
+
+func getExitCodes() string {
+	return fmt.Sprintf(
+		"\n\t%d: a target couldn't be parsed"+
+			"\n\t%d: a network request failed"+
+			"\n\t%d: a deck template couldn't be generated"+
+			"\n\t%d: a deck template couldn't be uploaded"+
+			"\n\t%d: any other failure",
+		exitParse,
+		exitNetwork,
+		exitTemplate,
+		exitUpload,
+		exitFailure,
+	)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+// cardReport describes a card resolved by a plugin, as shown by "-dry-run".
+type cardReport struct {
+	Count int    `json:"count"`
+	Name  string `json:"name"`
+	// Set and Number identify the printing chosen by the plugin, if it
+	// records it.
+	Set    string `json:"set,omitempty"`
+	Number string `json:"number,omitempty"`
+	// ID identifies the card in the source of the plugin, for the plugins
+	// which don't record its set.
+	ID       string `json:"id,omitempty"`
+	ImageURL string `json:"imageURL"`
+}
+
+// printing returns the set and collector number of the card, e.g.
+// "M10 #146", or its ID if it has no set.
+func (c cardReport) printing() string {
+	if len(c.Set) == 0 {
+		return c.ID
+	}
+	if len(c.Number) == 0 {
+		return c.Set
+	}
+
+	return c.Set + " #" + c.Number
+}
+
+// resolvedCards returns the cards of a deck, as resolved by its plugin.
+func resolvedCards(deck *plugins.Deck) []cardReport {
+	cards := make([]cardReport, 0, len(deck.Cards))
+
+	for _, card := range deck.Cards {
+		name := plugins.BaseCardName(card.Name)
+		if card.AlternativeState != nil {
+			name += " // " + plugins.BaseCardName(card.AlternativeState.Name)
+		}
+
+		cards = append(cards, cardReport{
+			Count:    card.Count,
+			Name:     name,
+			Set:      card.Metadata[plugins.MetadataSet],
+			Number:   card.Metadata[plugins.MetadataNumber],
+			ID:       card.Metadata[plugins.MetadataID],
+			ImageURL: card.ImageURL,
+		})
+	}
+
+	return cards
+}
+
+// writePreview writes the cards of the decks of each target, as resolved by
+// the plugins, without generating anything.
+func writePreview(w io.Writer, results []targetResult) error {
+	for i, result := range results {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s:\n", result.target); err != nil {
+			return err
+		}
+		if len(result.decks) == 0 {
+			continue
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		if _, err := fmt.Fprintln(tw, "BOARD\tCOUNT\tNAME\tPRINTING\tIMAGE"); err != nil {
+			return err
+		}
+		for _, generated := range result.decks {
+			_, board := plugins.SplitBoard(generated.Deck.Name)
+
+			for _, card := range resolvedCards(generated.Deck) {
+				_, err := fmt.Fprintf(
+					tw,
+					"%s\t%d\t%s\t%s\t%s\n",
+					board,
+					card.Count,
+					card.Name,
+					card.printing(),
+					card.ImageURL,
+				)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
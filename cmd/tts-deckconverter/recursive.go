@@ -0,0 +1,270 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	cfg "github.com/jeandeaual/tts-deckconverter/config"
+	"github.com/jeandeaual/tts-deckconverter/log"
+)
+
+// stateFile is the name of the file recording the sources converted in
+// recursive mode, written in the output folder.
+const stateFile = ".tts-deckconverter-state.json"
+
+// sourceInfo identifies the version of a source file, and the conversion
+// it went through.
+type sourceInfo struct {
+	ModTime time.Time `json:"modTime"`
+	Size    int64     `json:"size"`
+	// Settings is the hash of the settings the file was converted with
+	// (see settingsHash).
+	Settings string `json:"settings,omitempty"`
+	// Files are the files generated from the source.
+	Files []string `json:"files,omitempty"`
+}
+
+// conversionState records the source files successfully converted by the
+// previous runs, to skip the ones which haven't changed since then.
+type conversionState struct {
+	path    string
+	Sources map[string]sourceInfo `json:"sources"`
+	// pending are the sources found during the current run
+	pending map[string]sourceInfo
+}
+
+// loadConversionState reads the state file found in folder.
+// An empty state is returned if the file doesn't exist.
+func loadConversionState(folder string) (*conversionState, error) {
+	state := &conversionState{
+		path:    filepath.Join(folder, stateFile),
+		Sources: make(map[string]sourceInfo),
+		pending: make(map[string]sourceInfo),
+	}
+
+	data, err := ioutil.ReadFile(state.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	if state.Sources == nil {
+		state.Sources = make(map[string]sourceInfo)
+	}
+
+	return state, nil
+}
+
+// changed returns true if the source file found at path has changed since
+// it was last converted, if it is now converted with other settings, or if
+// the files generated from it have been removed.
+func (s *conversionState) changed(path string, info os.FileInfo, settings string) bool {
+	current := sourceInfo{
+		ModTime:  info.ModTime().UTC(),
+		Size:     info.Size(),
+		Settings: settings,
+	}
+	key := stateKey(path)
+	s.pending[key] = current
+
+	previous, found := s.Sources[key]
+	if !found || !previous.ModTime.Equal(current.ModTime) || previous.Size != current.Size ||
+		previous.Settings != current.Settings || len(previous.Files) == 0 {
+		return true
+	}
+
+	for _, file := range previous.Files {
+		if _, err := os.Stat(file); err != nil {
+			log.Debugf("%s has been removed, converting %s again", file, path)
+			return true
+		}
+	}
+
+	return false
+}
+
+// converted records that the source file found at path was successfully
+// converted to files.
+func (s *conversionState) converted(path string, files []string) {
+	key := stateKey(path)
+	if info, found := s.pending[key]; found {
+		info.Files = files
+		s.Sources[key] = info
+	}
+}
+
+// settingsHash returns a hash of the settings used to convert a target: its
+// mode, card back and plugin options.
+func settingsHash(config appConfig) string {
+	keys := make([]string, 0, len(config.options))
+	for key := range config.options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%s\x00", config.mode, config.backURL)
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s=%s\x00", key, config.options[key])
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// stateKey returns the key of the source file found at path in the state:
+// its absolute path, so that the state doesn't depend on the working
+// directory.
+func stateKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// save writes the state file.
+func (s *conversionState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.path, append(data, '\n'), 0o644)
+}
+
+// walkTree returns the configuration of each file found in the folder tree
+// under root, whose output folder mirrors the path of the file.
+// The files which haven't changed since the last run (and whose settings
+// and generated files haven't changed either) are skipped, as well as the
+// hidden files and folders.
+func walkTree(config appConfig, root string) ([]appConfig, error) {
+	log.Debugf("Processing directory tree %s", root)
+
+	// The output folder is skipped if it is inside the tree
+	outputFolder, err := filepath.Abs(config.outputFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := []appConfig{}
+	folderConfigs := make(map[string]appConfig)
+	outputFolders := make(map[string]bool)
+
+	// Do not process the files inside the WalkFunc, overwise the files
+	// generated inside the target tree would be picked up by filepath.Walk
+	err = filepath.Walk(root, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		if path != root && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			if absPath, absErr := filepath.Abs(path); absErr == nil && path != root && absPath == outputFolder {
+				log.Infof("Ignoring output directory %s", path)
+				return filepath.SkipDir
+			}
+
+			parentConfig := config
+			if path != root {
+				parentConfig = folderConfigs[filepath.Dir(path)]
+				parentConfig.outputFolder = filepath.Join(parentConfig.outputFolder, info.Name())
+			}
+
+			folderConfig, folderErr := newFolderConfig(parentConfig, path)
+			if folderErr != nil {
+				return folderErr
+			}
+			folderConfigs[path] = folderConfig
+
+			return nil
+		}
+
+		targetConfig := newTargetConfig(folderConfigs[filepath.Dir(path)], path)
+		if config.state != nil && !config.state.changed(path, info, settingsHash(targetConfig)) {
+			log.Debugf("Skipping %s, which hasn't changed since the last run", path)
+			return nil
+		}
+
+		if !targetConfig.dryRun && !outputFolders[targetConfig.outputFolder] {
+			if dirErr := checkCreateDir(targetConfig.outputFolder); dirErr != nil {
+				return dirErr
+			}
+			outputFolders[targetConfig.outputFolder] = true
+		}
+
+		configs = append(configs, targetConfig)
+
+		return nil
+	})
+
+	return configs, err
+}
+
+// newFolderConfig returns the configuration used for the files of folder.
+// The plugin is set by the marker file of the folder, or by its name if it
+// is the ID of a plugin (e.g. "mtg"). Otherwise, the configuration of the
+// parent folder is used.
+// The card back and the options are reset when the plugin changes.
+func newFolderConfig(config appConfig, folder string) (appConfig, error) {
+	folderSettings, err := cfg.LoadFolderSettings(folder)
+	if err != nil {
+		return config, err
+	}
+
+	mode := config.mode
+	if _, found := dc.Plugins[filepath.Base(folder)]; found {
+		mode = filepath.Base(folder)
+	}
+	if folderSettings != nil && len(folderSettings.Mode) > 0 {
+		mode = folderSettings.Mode
+	}
+
+	if mode != config.mode {
+		log.Debugf("Using mode %s for %s", mode, folder)
+		config.mode = mode
+		config.backURL = ""
+		config.options = make(options)
+	}
+
+	if folderSettings == nil {
+		return config, nil
+	}
+
+	if len(folderSettings.BackURL) > 0 {
+		config.backURL = folderSettings.BackURL
+	} else if len(folderSettings.Back) > 0 {
+		// The back has been validated with the mode of the marker file
+		config.backURL = dc.Plugins[config.mode].AvailableBacks()[folderSettings.Back].URL
+	}
+
+	if len(folderSettings.Options) > 0 {
+		folderOptions := make(options, len(config.options)+len(folderSettings.Options))
+		for key, value := range config.options {
+			folderOptions[key] = value
+		}
+		for key, value := range folderSettings.Options {
+			folderOptions[key] = value
+		}
+		config.options = folderOptions
+	}
+
+	return config, nil
+}
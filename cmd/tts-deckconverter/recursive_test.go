@@ -0,0 +1,232 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	cfg "github.com/jeandeaual/tts-deckconverter/config"
+	"github.com/jeandeaual/tts-deckconverter/log"
+)
+
+func init() {
+	logger := zap.NewExample()
+	log.SetLogger(logger.Sugar())
+}
+
+func TestConversionState(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	source := filepath.Join(tmpDir, "deck.txt")
+	output := filepath.Join(tmpDir, "deck.json")
+	for _, path := range []string{source, output} {
+		if !assert.Nil(t, ioutil.WriteFile(path, []byte("1 card.png\n"), 0o644)) {
+			t.FailNow()
+		}
+	}
+	info, err := os.Stat(source)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	config := appConfig{mode: "custom", options: options{"shape": "circle"}}
+	settings := settingsHash(config)
+
+	state, err := loadConversionState(tmpDir)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	assert.True(t, state.changed(source, info, settings))
+	state.converted(source, []string{output})
+	if !assert.Nil(t, state.save()) {
+		t.FailNow()
+	}
+
+	state, err = loadConversionState(tmpDir)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	assert.False(t, state.changed(source, info, settings))
+
+	// The settings have changed
+	config.options = options{"shape": "hex"}
+	assert.NotEqual(t, settings, settingsHash(config))
+	assert.True(t, state.changed(source, info, settingsHash(config)))
+	config.options = options{}
+	config.backURL = "https://example.com/back.png"
+	assert.True(t, state.changed(source, info, settingsHash(config)))
+
+	// The generated file has been removed
+	assert.Nil(t, os.Remove(output))
+	assert.True(t, state.changed(source, info, settings))
+}
+
+// writeTestFiles creates the files under root, with their content.
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		if !assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0o755)) {
+			t.FailNow()
+		}
+		if !assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0o644)) {
+			t.FailNow()
+		}
+	}
+}
+
+func TestWalkTree(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	root := filepath.Join(tmpDir, "decks")
+	output := filepath.Join(root, "output")
+	writeTestFiles(t, root, map[string]string{
+		"top.txt":                        "1 card.png\n",
+		"mtg/burn.txt":                   "4 Lightning Bolt\n",
+		"mtg/sideboard/burn.txt":         "2 Negate\n",
+		"misc/" + cfg.FolderSettingsFile: "mode: custom\noptions:\n  shape: circle\n",
+		"misc/sub/list.txt":              "1 card.png\n",
+		"misc/mtg/list.txt":              "1 card.png\n",
+		".hidden/deck.txt":               "1 card.png\n",
+		"mtg/.draft.txt":                 "1 Opt\n",
+		"output/mtg/burn.json":           "{}",
+		"output/" + stateFile:            "{}",
+	})
+
+	config := appConfig{
+		mode:         "custom",
+		outputFolder: output,
+		options:      options{"size": "tarot"},
+	}
+
+	configs, err := walkTree(config, root)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	type expectedConfig struct {
+		mode         string
+		outputFolder string
+		options      options
+	}
+	actual := make(map[string]expectedConfig)
+	for _, targetConfig := range configs {
+		target, err := filepath.Rel(root, targetConfig.target)
+		if !assert.Nil(t, err) {
+			continue
+		}
+		actual[filepath.ToSlash(target)] = expectedConfig{
+			mode:         targetConfig.mode,
+			outputFolder: targetConfig.outputFolder,
+			options:      targetConfig.options,
+		}
+	}
+
+	assert.Equal(t, map[string]expectedConfig{
+		// The options of the flags are kept for the mode of the flags
+		"top.txt": {mode: "custom", outputFolder: output, options: options{"size": "tarot"}},
+		// The folders named after a plugin set the mode, and reset the
+		// options
+		"mtg/burn.txt":           {mode: "mtg", outputFolder: filepath.Join(output, "mtg"), options: options{}},
+		"mtg/sideboard/burn.txt": {mode: "mtg", outputFolder: filepath.Join(output, "mtg", "sideboard"), options: options{}},
+		// The marker files set the mode and options of the subfolders
+		"misc/sub/list.txt": {mode: "custom", outputFolder: filepath.Join(output, "misc", "sub"), options: options{"size": "tarot", "shape": "circle"}},
+		"misc/mtg/list.txt": {mode: "mtg", outputFolder: filepath.Join(output, "misc", "mtg"), options: options{}},
+	}, actual)
+
+	// The output folders are created
+	for _, folder := range []string{"mtg/sideboard", "misc/sub", "misc/mtg"} {
+		info, err := os.Stat(filepath.Join(output, filepath.FromSlash(folder)))
+		if assert.Nil(t, err, folder) {
+			assert.True(t, info.IsDir(), folder)
+		}
+	}
+}
+
+func TestWalkTreeState(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	root := filepath.Join(tmpDir, "decks")
+	output := filepath.Join(tmpDir, "output")
+	writeTestFiles(t, root, map[string]string{
+		"first.txt":  "1 card.png\n",
+		"second.txt": "1 card.png\n",
+	})
+	generated := filepath.Join(output, "first.json")
+	writeTestFiles(t, output, map[string]string{"first.json": "{}"})
+
+	walk := func(config appConfig) []string {
+		state, err := loadConversionState(output)
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		config.state = state
+		config.outputFolder = output
+
+		configs, err := walkTree(config, root)
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+
+		targets := []string{}
+		for _, targetConfig := range configs {
+			targets = append(targets, filepath.Base(targetConfig.target))
+			// Only the first file is converted successfully
+			if filepath.Base(targetConfig.target) == "first.txt" {
+				state.converted(targetConfig.target, []string{generated})
+			}
+		}
+		if !assert.Nil(t, state.save()) {
+			t.FailNow()
+		}
+
+		return targets
+	}
+
+	config := appConfig{mode: "custom", options: options{}}
+
+	testCases := []struct {
+		name     string
+		setUp    func()
+		config   appConfig
+		expected []string
+	}{
+		{name: "first run", config: config, expected: []string{"first.txt", "second.txt"}},
+		{name: "unchanged", config: config, expected: []string{"second.txt"}},
+		{
+			name:     "other options",
+			config:   appConfig{mode: "custom", options: options{"shape": "circle"}},
+			expected: []string{"first.txt", "second.txt"},
+		},
+		{name: "previous options", config: config, expected: []string{"first.txt", "second.txt"}},
+		{name: "unchanged again", config: config, expected: []string{"second.txt"}},
+		{
+			name:     "output removed",
+			setUp:    func() { assert.Nil(t, os.Remove(generated)) },
+			config:   config,
+			expected: []string{"first.txt", "second.txt"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		if testCase.setUp != nil {
+			testCase.setUp()
+		}
+		assert.Equal(t, testCase.expected, walk(testCase.config), testCase.name)
+	}
+}
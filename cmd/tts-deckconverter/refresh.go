@@ -0,0 +1,194 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts"
+	"github.com/jeandeaual/tts-deckconverter/tts/upload"
+)
+
+func runRefresh(args []string) {
+	var (
+		config     appConfig
+		backup     bool
+		configPath string
+		profile    string
+	)
+
+	availableModes := dc.AvailablePlugins()
+	config.options = make(options)
+
+	flags := flag.NewFlagSet("refresh", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(
+			flags.Output(),
+			"Usage: %s refresh -mode MODE [flags] SAVED_OBJECT|FOLDER...\n\n"+
+				"Retrieve the cards of Tabletop Simulator saved objects again, and rewrite them in place.\n"+
+				"The position, scripts and GUIDs of the objects are kept.\n\n"+
+				"Flags:\n",
+			filepath.Base(os.Args[0]),
+		)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&config.mode, "mode", "", "mode used to generate the saved objects: "+strings.Join(availableModes, ", "))
+	flags.StringVar(&config.back, "back", "", "card back (cannot be used with \"-backURL\"). Choose from:"+getAvailableBacks(availableModes))
+	flags.StringVar(&config.backURL, "backURL", "", "custom URL for the card backs (cannot be used with \"-back\")")
+	flags.StringVar(&config.templateMode, "template", "", "download each images and create a deck template instead of referring to each image individually. Choose from the following uploaders:"+getAvailableUploaders())
+	flags.Var(&config.options, "option", "plugin specific option (can have multiple)"+getAvailableOptions(availableModes))
+	flags.BoolVar(&config.compact, "compact", false, "don't indent the resulting JSON file")
+	flags.BoolVar(&backup, "backup", false, "keep a timestamped backup of the saved objects before rewriting them")
+	flags.Var(&config.thumbnail, "thumbnail", "name of a card to show in the deck thumbnail (can have multiple, the cards are fanned out)")
+	flags.BoolVar(&config.banner, "banner", false, "write the name of the deck at the bottom of its thumbnail")
+	flags.BoolVar(&config.reproducible, "reproducible", false, "leave the date out of the resulting JSON file")
+	flags.BoolVar(&config.cache, "cache", false, "write the card images to the Tabletop Simulator mod cache")
+	flags.StringVar(&configPath, "config", "", "configuration file containing the default settings and the profiles (defaults to "+defaultConfigPath()+")")
+	flags.StringVar(&profile, "profile", "", "profile of the configuration file to use")
+	flags.BoolVar(&config.debug, "debug", false, "enable debug logging")
+
+	// Errors are handled by flag.ExitOnError
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Fprint(os.Stderr, "A saved object is required\n\n")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	plugin, found := dc.Plugins[config.mode]
+	if !found {
+		fmt.Fprintf(os.Stderr, "Invalid mode: %s\n\n", config.mode)
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	if _, ok := plugin.(plugins.Exporter); !ok {
+		fmt.Fprintf(os.Stderr, "Saved objects can't be refreshed in mode %s\n\n", config.mode)
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	// The flags take precedence over the configuration file
+	settings, err := loadSettings(configPath, profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n\n", plugins.CapitalizeString(err.Error()))
+		flags.Usage()
+		os.Exit(1)
+	}
+	applySettings(&config, settings, flags)
+
+	if len(config.back) > 0 && len(config.backURL) > 0 {
+		fmt.Fprint(os.Stderr, "\"-back\" and \"-backURL\" cannot be used at the same time\n\n")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	if len(config.back) > 0 {
+		chosenBack, found := plugin.AvailableBacks()[config.back]
+		if !found {
+			fmt.Fprintf(os.Stderr, "Invalid back for %s: %s\n\n", config.mode, config.back)
+			flags.Usage()
+			os.Exit(1)
+		}
+		config.backURL = chosenBack.URL
+	}
+
+	applyPluginSettings(&config, settings, config.mode)
+
+	if len(config.templateMode) > 0 {
+		config.uploader, found = upload.TemplateUploaders[config.templateMode]
+		if !found {
+			fmt.Fprintf(os.Stderr, "Invalid template uploader: %s\n\n", config.templateMode)
+			flags.Usage()
+			os.Exit(1)
+		}
+	}
+
+	if backup {
+		config.conflictPolicy = tts.ConflictBackup
+	}
+
+	logger := setUpLogger(config.debug)
+	defer syncLogger(logger)
+
+	if config.cache {
+		config.modCache, err = newModCache()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	errs := []error{}
+
+	for _, target := range flags.Args() {
+		paths, err := savedObjectPaths(target)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, path := range paths {
+			errs = append(errs, refresh(path, config)...)
+		}
+	}
+
+	checkErrs(errs)
+}
+
+// savedObjectPaths returns the saved objects found at target, which can be a
+// saved object file or a folder containing saved objects.
+// Subfolders are not processed.
+func savedObjectPaths(target string) ([]string, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{target}, nil
+	}
+
+	return filepath.Glob(filepath.Join(target, "*.json"))
+}
+
+// refresh retrieves the cards of the saved object found at path again, using
+// the options of config, and overwrites it.
+func refresh(path string, config appConfig) []error {
+	log.Infof("Processing %s", path)
+
+	deck, err := dc.Reload(path, config.mode, config.options)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+
+	if config.uploader != nil {
+		// The templates are named after the deck, and overwritten like the
+		// saved object
+		templateOptions := append(
+			generateOptions(config),
+			tts.WithFilenameTemplate(""),
+			tts.WithConflictPolicy(tts.ConflictOverwrite),
+		)
+		templateErrs, ok := generateTemplates([]*plugins.Deck{deck}, filepath.Dir(path), *config.uploader, templateOptions...)
+		if !ok {
+			return templateErrs
+		}
+		errs = append(errs, templateErrs...)
+	}
+
+	// The previous card back is kept, unless a new one has been chosen
+	err = tts.Refresh(path, deck, config.backURL, !config.compact, generateOptions(config)...)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("couldn't refresh %s: %w", path, err))
+	}
+
+	return errs
+}
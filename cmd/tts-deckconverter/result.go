@@ -0,0 +1,232 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/jeandeaual/tts-deckconverter/tts"
+)
+
+// failureKind tells the failures of a conversion apart, so that the scripts
+// calling the CLI can handle them differently.
+type failureKind int
+
+const (
+	failureOther failureKind = iota
+	failureParse
+	failureNetwork
+	failureTemplate
+	failureUpload
+)
+
+// Exit codes of the conversion. 2 is used by the flag package for the
+// invalid flags.
+const (
+	exitOK       = 0
+	exitFailure  = 1
+	exitParse    = 3
+	exitNetwork  = 4
+	exitTemplate = 5
+	exitUpload   = 6
+)
+
+func (k failureKind) String() string {
+	switch k {
+	case failureParse:
+		return "parse"
+	case failureNetwork:
+		return "network"
+	case failureTemplate:
+		return "template"
+	case failureUpload:
+		return "upload"
+	default:
+		return "other"
+	}
+}
+
+func (k failureKind) exitCode() int {
+	switch k {
+	case failureParse:
+		return exitParse
+	case failureNetwork:
+		return exitNetwork
+	case failureTemplate:
+		return exitTemplate
+	case failureUpload:
+		return exitUpload
+	default:
+		return exitFailure
+	}
+}
+
+// stageError is an error which happened at a given stage of the conversion
+// of a target (e.g. while parsing it).
+type stageError struct {
+	kind failureKind
+	err  error
+}
+
+func (e *stageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stageError) Unwrap() error {
+	return e.err
+}
+
+// failureOf returns the kind of failure of err.
+// The upload and network errors take precedence over the stage at which
+// they happened.
+func failureOf(err error) failureKind {
+	var (
+		uploadErr *tts.UploadError
+		urlErr    *url.Error
+		opErr     *net.OpError
+		stageErr  *stageError
+	)
+
+	switch {
+	case errors.As(err, &uploadErr):
+		return failureUpload
+	case errors.As(err, &urlErr), errors.As(err, &opErr):
+		return failureNetwork
+	case errors.As(err, &stageErr):
+		return stageErr.kind
+	default:
+		return failureOther
+	}
+}
+
+// exitCode returns the exit code matching the first failure of the
+// targets, or exitOK if they were all converted.
+func exitCode(results []targetResult) int {
+	for _, result := range results {
+		if len(result.errs) > 0 {
+			return failureOf(result.errs[0]).exitCode()
+		}
+	}
+
+	return exitOK
+}
+
+// warningRecorder keeps the warnings logged during the conversion, to
+// report them with "-json".
+type warningRecorder struct {
+	mutex    sync.Mutex
+	warnings []string
+}
+
+// record is a zap hook recording the warnings.
+func (r *warningRecorder) record(entry zapcore.Entry) error {
+	if entry.Level != zapcore.WarnLevel {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.warnings = append(r.warnings, entry.Message)
+
+	return nil
+}
+
+// take returns the recorded warnings, and forgets them.
+func (r *warningRecorder) take() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	warnings := r.warnings
+	r.warnings = nil
+
+	return warnings
+}
+
+// conversionResult is the result written to stdout with "-json".
+type conversionResult struct {
+	Targets []targetReport `json:"targets"`
+	// Warnings are the warnings logged while converting the targets.
+	Warnings []string `json:"warnings"`
+	ExitCode int      `json:"exitCode"`
+}
+
+type targetReport struct {
+	Target string        `json:"target"`
+	Status string        `json:"status"`
+	Decks  []deckReport  `json:"decks"`
+	Errors []errorReport `json:"errors"`
+}
+
+type deckReport struct {
+	Name   string   `json:"name"`
+	Plugin string   `json:"plugin,omitempty"`
+	Cards  int      `json:"cards"`
+	Files  []string `json:"files"`
+	// Contents are the cards of the deck, listed with "-dry-run".
+	Contents []cardReport `json:"contents,omitempty"`
+}
+
+type errorReport struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// newConversionResult returns the result of the conversion of the targets.
+// The cards of the decks are listed if dryRun is set.
+func newConversionResult(results []targetResult, warnings []string, dryRun bool) conversionResult {
+	conversion := conversionResult{
+		Targets:  make([]targetReport, 0, len(results)),
+		Warnings: warnings,
+		ExitCode: exitCode(results),
+	}
+	if conversion.Warnings == nil {
+		conversion.Warnings = []string{}
+	}
+
+	for _, result := range results {
+		report := targetReport{
+			Target: result.target,
+			Status: "ok",
+			Decks:  make([]deckReport, 0, len(result.decks)),
+			Errors: make([]errorReport, 0, len(result.errs)),
+		}
+		if len(result.errs) > 0 {
+			report.Status = "failed"
+		}
+
+		for _, generated := range result.decks {
+			cards := 0
+			for _, card := range generated.Deck.Cards {
+				cards += card.Count
+			}
+			deck := deckReport{
+				Name:   generated.Deck.Name,
+				Plugin: generated.Deck.Plugin,
+				Cards:  cards,
+				Files:  generated.Files,
+			}
+			if deck.Files == nil {
+				deck.Files = []string{}
+			}
+			if dryRun {
+				deck.Contents = resolvedCards(generated.Deck)
+			}
+			report.Decks = append(report.Decks, deck)
+		}
+
+		for _, err := range result.errs {
+			report.Errors = append(report.Errors, errorReport{
+				Kind:    failureOf(err).String(),
+				Message: err.Error(),
+			})
+		}
+
+		conversion.Targets = append(conversion.Targets, report)
+	}
+
+	return conversion
+}
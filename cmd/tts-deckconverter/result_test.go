@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/tts"
+)
+
+func TestFailureOf(t *testing.T) {
+	networkErr := &url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("timeout")}
+	uploadErr := &tts.UploadError{Path: "Test - Template.jpg", Err: networkErr}
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected failureKind
+		exitCode int
+	}{
+		{"other", errors.New("failed"), failureOther, exitFailure},
+		{"parse", &stageError{kind: failureParse, err: errors.New("invalid line")}, failureParse, exitParse},
+		{"template", &stageError{kind: failureTemplate, err: errors.New("invalid image")}, failureTemplate, exitTemplate},
+		{"network", networkErr, failureNetwork, exitNetwork},
+		{"dial", &net.OpError{Op: "dial", Err: errors.New("refused")}, failureNetwork, exitNetwork},
+		{"upload", uploadErr, failureUpload, exitUpload},
+		// The network and upload errors take precedence over the stage
+		{"network while parsing", &stageError{kind: failureParse, err: fmt.Errorf("couldn't download: %w", networkErr)}, failureNetwork, exitNetwork},
+		{"upload of template", &stageError{kind: failureTemplate, err: uploadErr}, failureUpload, exitUpload},
+	}
+
+	exitCodes := make(map[int]failureKind)
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			kind := failureOf(testCase.err)
+			assert.Equal(t, testCase.expected, kind)
+			assert.Equal(t, testCase.exitCode, kind.exitCode())
+		})
+		exitCodes[testCase.exitCode] = testCase.expected
+	}
+
+	// Each kind of failure has its own exit code
+	assert.Len(t, exitCodes, 5)
+	assert.NotContains(t, exitCodes, exitOK)
+	// 2 is used for the invalid flags
+	assert.NotContains(t, exitCodes, 2)
+}
+
+func TestExitCode(t *testing.T) {
+	parseErr := &stageError{kind: failureParse, err: errors.New("invalid line")}
+	templateErr := &stageError{kind: failureTemplate, err: errors.New("invalid image")}
+
+	testCases := []struct {
+		name     string
+		results  []targetResult
+		expected int
+	}{
+		{"no target", nil, exitOK},
+		{"success", []targetResult{{target: "a.txt"}, {target: "b.txt"}}, exitOK},
+		{"failure", []targetResult{{target: "a.txt"}, {target: "b.txt", errs: []error{parseErr}}}, exitParse},
+		// The first failure is reported
+		{
+			"failures",
+			[]targetResult{{target: "a.txt", errs: []error{templateErr, parseErr}}, {target: "b.txt", errs: []error{parseErr}}},
+			exitTemplate,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, exitCode(testCase.results))
+		})
+	}
+}
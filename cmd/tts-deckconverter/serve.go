@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/server"
+)
+
+func runServe(args []string) {
+	var (
+		address      string
+		folder       string
+		concurrency  int
+		queueSize    int
+		ttl          time.Duration
+		privateHosts bool
+		debug        bool
+	)
+
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(
+			flags.Output(),
+			"Usage: %s serve [flags]\n\n"+
+				"Convert decks through a REST API.\n\n"+
+				"Endpoints:\n"+
+				"  GET  /api/plugins                    list the plugins, with their options, backs and formats\n"+
+				"  GET  /api/plugins/{id}               describe a plugin\n"+
+				"  GET  /api/uploaders                  list the template uploaders\n"+
+				"  POST /api/jobs                       submit a deck URL or decklist to convert\n"+
+				"  GET  /api/jobs/{id}                  get the state, progress and files of a job\n"+
+				"  GET  /api/jobs/{id}/files/{path}     download a file generated by a job\n\n"+
+				"Flags:\n",
+			filepath.Base(os.Args[0]),
+		)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&address, "address", "localhost:8080", "address the server listens on")
+	flags.StringVar(&folder, "folder", "", "folder where the files of the jobs are written (defaults to a temporary folder)")
+	flags.IntVar(&concurrency, "concurrency", 2, "number of jobs processed at the same time")
+	flags.IntVar(&queueSize, "queue", 100, "number of jobs which can wait to be processed")
+	flags.DurationVar(&ttl, "ttl", time.Hour, "duration the jobs and their files are kept after they finish")
+	flags.BoolVar(&privateHosts, "private-hosts", false, "allow the decks and images hosted on the loopback interface or the private networks")
+	flags.BoolVar(&debug, "debug", false, "enable debug logging")
+
+	// Errors are handled by flag.ExitOnError
+	_ = flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	logger := setUpLogger(debug)
+	defer syncLogger(logger)
+
+	if len(folder) == 0 {
+		var err error
+		folder, err = ioutil.TempDir("", "tts-deckconverter")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer os.RemoveAll(folder)
+	} else if err := checkCreateDir(folder); err != nil {
+		log.Fatal(err)
+	}
+
+	options := []server.Option{
+		server.WithConcurrency(concurrency),
+		server.WithQueueSize(queueSize),
+		server.WithTTL(ttl),
+	}
+	if privateHosts {
+		options = append(options, server.WithPrivateHosts())
+	}
+
+	s := server.New(folder, options...)
+	defer s.Close()
+
+	httpServer := &http.Server{
+		Addr:    address,
+		Handler: s,
+	}
+
+	go func() {
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		<-interrupt
+		log.Info("Shutting down")
+		_ = httpServer.Close()
+	}()
+
+	log.Infof("Listening on http://%s/api/", address)
+
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Error(err)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+)
+
+const (
+	// watchDebounce is the time waited after the last change of a file
+	// before converting it, since editors usually write files in several
+	// steps.
+	watchDebounce = 200 * time.Millisecond
+	// watchPollInterval is the interval at which the targets are checked
+	// when the file system notifications aren't available.
+	watchPollInterval = time.Second
+)
+
+// watchTargets converts the file targets again each time they change, until
+// the program is interrupted. Only the files which changed are converted.
+// The file system notifications are used if possible, otherwise the targets
+// are polled.
+func watchTargets(config appConfig) {
+	configs, err := watchedConfigs(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	snapshot := watchSnapshot(configs)
+
+	var (
+		events   <-chan fsnotify.Event
+		errs     <-chan error
+		poll     <-chan time.Time
+		debounce <-chan time.Time
+	)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		defer watcher.Close()
+		err = addWatches(watcher, config)
+	}
+	if err != nil {
+		log.Warnf("Couldn't watch the file system, polling the targets every %s instead: %v", watchPollInterval, err)
+		ticker := time.NewTicker(watchPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	} else {
+		events = watcher.Events
+		errs = watcher.Errors
+	}
+
+	log.Info("Watching the targets for changes, press Ctrl+C to stop")
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			log.Debugf("File system event: %s", event)
+			// Wait for the last event of a series
+			debounce = time.After(watchDebounce)
+		case watchErr, ok := <-errs:
+			if !ok {
+				return
+			}
+			log.Errorf("Error while watching the targets: %v", watchErr)
+		case <-debounce:
+			debounce = nil
+			snapshot = convertChanged(config, snapshot)
+			// Watch the folders created in the meantime
+			if addErr := addWatches(watcher, config); addErr != nil {
+				log.Errorf("Couldn't watch the new folders: %v", addErr)
+			}
+		case <-poll:
+			snapshot = convertChanged(config, snapshot)
+		}
+	}
+}
+
+// watchSnapshot returns the version of each file target.
+// The targets which aren't files (e.g. URLs) and the saved objects which
+// may have been generated in the watched folders are ignored.
+func watchSnapshot(configs []appConfig) map[string]sourceInfo {
+	snapshot := make(map[string]sourceInfo)
+
+	for _, targetConfig := range configs {
+		info, err := os.Stat(targetConfig.target)
+		if err != nil || info.IsDir() || isSavedObjectFile(targetConfig.target) {
+			continue
+		}
+		snapshot[stateKey(targetConfig.target)] = sourceInfo{
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		}
+	}
+
+	return snapshot
+}
+
+// convertChanged converts the file targets which changed since previous
+// was taken, and returns the new snapshot.
+func convertChanged(config appConfig, previous map[string]sourceInfo) map[string]sourceInfo {
+	configs, err := watchedConfigs(config)
+	if err != nil {
+		log.Error(err)
+		return previous
+	}
+
+	snapshot := watchSnapshot(configs)
+
+	changed := []appConfig{}
+	for _, targetConfig := range configs {
+		key := stateKey(targetConfig.target)
+		current, found := snapshot[key]
+		if !found {
+			continue
+		}
+		if last, found := previous[key]; found && last.ModTime.Equal(current.ModTime) && last.Size == current.Size {
+			continue
+		}
+		changed = append(changed, targetConfig)
+	}
+
+	if len(changed) == 0 {
+		return snapshot
+	}
+
+	start := time.Now()
+	if processTargets(config, changed) == exitOK {
+		log.Infof("Converted %d changed files in %s", len(changed), time.Since(start))
+	}
+
+	return snapshot
+}
+
+// watchedConfigs returns the configuration of the current targets.
+// The state of the recursive mode isn't used, since all the files are
+// compared to the previous snapshot.
+func watchedConfigs(config appConfig) ([]appConfig, error) {
+	config.state = nil
+
+	return targetConfigs(config)
+}
+
+// addWatches watches the folders of the file targets, and the folder
+// targets (and their subfolders in recursive mode), so that the files
+// replaced by editors and the new files are detected.
+func addWatches(watcher *fsnotify.Watcher, config appConfig) error {
+	configs, err := watchedConfigs(config)
+	if err != nil {
+		return err
+	}
+
+	folders := make(map[string]bool)
+	for _, targetConfig := range configs {
+		if _, statErr := os.Stat(targetConfig.target); statErr == nil {
+			folders[filepath.Dir(targetConfig.target)] = true
+		}
+	}
+	for _, target := range config.targets {
+		if info, statErr := os.Stat(target); statErr == nil && info.IsDir() {
+			folders[target] = true
+		}
+		if config.recursive {
+			_ = filepath.Walk(target, func(path string, info os.FileInfo, walkErr error) error {
+				if walkErr != nil || !info.IsDir() {
+					return nil
+				}
+				if path != target && strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				folders[path] = true
+				return nil
+			})
+		}
+	}
+
+	for folder := range folders {
+		if err = watcher.Add(folder); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isSavedObjectFile returns true if path is a saved object or a thumbnail,
+// which are generated next to the sources if the output folder is a watched
+// folder.
+func isSavedObjectFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	return ext == ".json" || ext == ".png"
+}
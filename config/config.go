@@ -0,0 +1,295 @@
+// Package config reads and writes the configuration file shared by the CLI
+// and the GUI, containing the default settings and the named profiles.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts"
+	"github.com/jeandeaual/tts-deckconverter/tts/upload"
+)
+
+// appFolder is the folder of the application in the user configuration
+// folder.
+const appFolder = "tts-deckconverter"
+
+// fileName is the name of the configuration file.
+const fileName = "config.json"
+
+// Settings are default values for the settings of the application.
+// Empty values are ignored.
+type Settings struct {
+	// Output is the destination folder.
+	Output string `json:"output,omitempty"`
+	// Chest is the destination folder, relative to the TTS chest.
+	Chest string `json:"chest,omitempty"`
+	// Template is the uploader used to create deck templates.
+	Template string `json:"template,omitempty"`
+	// Compact disables the indentation of the generated files.
+	Compact *bool `json:"compact,omitempty"`
+	// Reproducible leaves the date out of the generated files.
+	Reproducible *bool `json:"reproducible,omitempty"`
+	// Banner writes the name of the decks in their thumbnails.
+	Banner *bool `json:"banner,omitempty"`
+	// Cache writes the images of the decks to the TTS mod cache.
+	Cache *bool `json:"cache,omitempty"`
+	// OnConflict is the conflict policy used when the files of a deck
+	// already exist.
+	OnConflict string `json:"onConflict,omitempty"`
+	// Filename is the template of the names of the deck files.
+	Filename string `json:"filename,omitempty"`
+	// Plugins are the settings of each plugin, by plugin ID.
+	Plugins map[string]PluginSettings `json:"plugins,omitempty"`
+}
+
+// PluginSettings are default values for the settings of a plugin.
+type PluginSettings struct {
+	// Back is the key of one of the backs of the plugin.
+	Back string `json:"back,omitempty"`
+	// BackURL is a custom card back (cannot be used with Back).
+	BackURL string `json:"backURL,omitempty"`
+	// Options are the plugin options.
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// Config is the content of the configuration file: the default settings,
+// and named profiles overriding them.
+type Config struct {
+	Settings
+	// DataDir is the TTS data folder, if it can't be found automatically.
+	DataDir string `json:"dataDir,omitempty"`
+	// Profiles are the named profiles.
+	Profiles map[string]Settings `json:"profiles,omitempty"`
+}
+
+// Bool returns a pointer to b, to set the boolean settings.
+func Bool(b bool) *bool {
+	return &b
+}
+
+// DefaultPath returns the path of the configuration file in the user
+// configuration folder.
+func DefaultPath() (string, error) {
+	folder, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(folder, appFolder, fileName), nil
+}
+
+// Load reads and validates the configuration file found at path.
+// An empty configuration is returned if the file doesn't exist.
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var config Config
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Report the typos
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	if err = config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	return &config, nil
+}
+
+// Save validates the configuration and writes it to path.
+func (c *Config) Save(path string) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Validate checks the settings of the configuration and of its profiles.
+func (c *Config) Validate() error {
+	if err := c.Settings.Validate(); err != nil {
+		return err
+	}
+
+	// The profiles override the default settings, so they are checked
+	// once merged with them
+	for _, name := range c.ProfileNames() {
+		settings, err := c.Profile(name)
+		if err == nil {
+			err = settings.Validate()
+		}
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// ProfileNames returns the names of the profiles, in alphabetical order.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Profile returns the default settings overridden by the profile called
+// name. The default settings are returned if name is empty.
+func (c *Config) Profile(name string) (Settings, error) {
+	if len(name) == 0 {
+		return c.Settings, nil
+	}
+
+	profile, found := c.Profiles[name]
+	if !found {
+		return Settings{}, fmt.Errorf("profile %s not found", name)
+	}
+
+	return c.Settings.Merge(profile), nil
+}
+
+// Validate checks that the settings refer to existing plugins, backs,
+// uploaders and options. The required options and the dependencies between
+// options aren't checked, since the settings can be completed by the command
+// line.
+func (s Settings) Validate() error {
+	if len(s.Output) > 0 && len(s.Chest) > 0 {
+		return fmt.Errorf("output and chest cannot be used at the same time")
+	}
+	if len(s.Template) > 0 {
+		if _, found := upload.TemplateUploaders[s.Template]; !found {
+			return fmt.Errorf("invalid template uploader: %s", s.Template)
+		}
+	}
+	if len(s.OnConflict) > 0 {
+		if _, err := tts.ParseConflictPolicy(s.OnConflict); err != nil {
+			return err
+		}
+	}
+	if len(s.Filename) > 0 {
+		if err := tts.ValidateFilenameTemplate(s.Filename); err != nil {
+			return err
+		}
+	}
+
+	pluginIDs := make([]string, 0, len(s.Plugins))
+	for pluginID := range s.Plugins {
+		pluginIDs = append(pluginIDs, pluginID)
+	}
+	sort.Strings(pluginIDs)
+
+	for _, pluginID := range pluginIDs {
+		plugin, found := dc.Plugins[pluginID]
+		if !found {
+			return fmt.Errorf("plugin %s not found", pluginID)
+		}
+		if err := s.Plugins[pluginID].validate(plugin); err != nil {
+			return fmt.Errorf("%s: %w", pluginID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s PluginSettings) validate(plugin plugins.Plugin) error {
+	if len(s.Back) > 0 && len(s.BackURL) > 0 {
+		return fmt.Errorf("back and backURL cannot be used at the same time")
+	}
+	if len(s.Back) > 0 {
+		if _, found := plugin.AvailableBacks()[s.Back]; !found {
+			return fmt.Errorf("invalid back: %s", s.Back)
+		}
+	}
+
+	return plugin.AvailableOptions().Validate(s.Options)
+}
+
+// Merge returns the settings overridden by the values set in override.
+// The plugin options are merged one by one.
+func (s Settings) Merge(override Settings) Settings {
+	merged := s
+
+	mergeString := func(value *string, overrideValue string) {
+		if len(overrideValue) > 0 {
+			*value = overrideValue
+		}
+	}
+	mergeBool := func(value **bool, overrideValue *bool) {
+		if overrideValue != nil {
+			*value = overrideValue
+		}
+	}
+
+	if len(override.Output) > 0 || len(override.Chest) > 0 {
+		merged.Output = override.Output
+		merged.Chest = override.Chest
+	}
+	mergeString(&merged.Template, override.Template)
+	mergeBool(&merged.Compact, override.Compact)
+	mergeBool(&merged.Reproducible, override.Reproducible)
+	mergeBool(&merged.Banner, override.Banner)
+	mergeBool(&merged.Cache, override.Cache)
+	mergeString(&merged.OnConflict, override.OnConflict)
+	mergeString(&merged.Filename, override.Filename)
+
+	merged.Plugins = make(map[string]PluginSettings, len(s.Plugins)+len(override.Plugins))
+	for pluginID, pluginSettings := range s.Plugins {
+		merged.Plugins[pluginID] = pluginSettings
+	}
+	for pluginID, overridePluginSettings := range override.Plugins {
+		pluginSettings := merged.Plugins[pluginID]
+		if len(overridePluginSettings.Back) > 0 || len(overridePluginSettings.BackURL) > 0 {
+			pluginSettings.Back = overridePluginSettings.Back
+			pluginSettings.BackURL = overridePluginSettings.BackURL
+		}
+		options := make(map[string]string, len(pluginSettings.Options)+len(overridePluginSettings.Options))
+		for key, value := range pluginSettings.Options {
+			options[key] = value
+		}
+		for key, value := range overridePluginSettings.Options {
+			options[key] = value
+		}
+		pluginSettings.Options = options
+		merged.Plugins[pluginID] = pluginSettings
+	}
+
+	return merged
+}
+
+// CardBackURL returns the URL of the card back set for a plugin, if any.
+func (s PluginSettings) CardBackURL(plugin plugins.Plugin) string {
+	if len(s.Back) > 0 {
+		return plugin.AvailableBacks()[s.Back].URL
+	}
+
+	return s.BackURL
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "config.json")
+
+	// Missing file
+	config, err := Load(path)
+	assert.Nil(t, err)
+	assert.Equal(t, &Config{}, config)
+
+	err = ioutil.WriteFile(path, []byte(`{
+  "compact": true,
+  "template": "manual",
+  "plugins": {
+    "mtg": {"options": {"quality": "png", "rulings": "true"}},
+    "ygo": {"back": "ocg"}
+  },
+  "profiles": {
+    "league": {
+      "chest": "League",
+      "compact": false,
+      "plugins": {
+        "mtg": {"backURL": "https://example.com/back.png", "options": {"quality": "large"}}
+      }
+    }
+  }
+}`), 0644)
+	assert.Nil(t, err)
+
+	config, err = Load(path)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"league"}, config.ProfileNames())
+
+	settings, err := config.Profile("")
+	assert.Nil(t, err)
+	assert.Equal(t, Bool(true), settings.Compact)
+	assert.Equal(t, "png", settings.Plugins["mtg"].Options["quality"])
+
+	settings, err = config.Profile("league")
+	assert.Nil(t, err)
+	assert.Equal(t, "League", settings.Chest)
+	assert.Equal(t, Bool(false), settings.Compact)
+	assert.Equal(t, "manual", settings.Template)
+	assert.Equal(t, map[string]string{"quality": "large", "rulings": "true"}, settings.Plugins["mtg"].Options)
+	assert.Equal(t, "https://example.com/back.png", settings.Plugins["mtg"].BackURL)
+	assert.Equal(t, "ocg", settings.Plugins["ygo"].Back)
+	// The default settings aren't modified
+	assert.Equal(t, "png", config.Plugins["mtg"].Options["quality"])
+
+	_, err = config.Profile("casual")
+	assert.NotNil(t, err)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "config.json")
+
+	for _, content := range []string{
+		`{"compcat": true}`,
+		`{"template": "dropbox"}`,
+		`{"output": "decks", "chest": "/"}`,
+		`{"onConflict": "merge"}`,
+		`{"filename": "{format}/{name}"}`,
+		`{"plugins": {"hearthstone": {}}}`,
+		`{"plugins": {"mtg": {"options": {"quality": "huge"}}}}`,
+		`{"plugins": {"mtg": {"options": {"foil": "true"}}}}`,
+		`{"plugins": {"ygo": {"back": "ocg", "backURL": "https://example.com/back.png"}}}`,
+		`{"plugins": {"ygo": {"back": "rush"}}}`,
+		`{"profiles": {"league": {"plugins": {"mtg": {"options": {"quality": "huge"}}}}}}`,
+	} {
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+		_, err = Load(path)
+		assert.NotNil(t, err, content)
+	}
+
+	// The dependencies of the options can be set by the default settings or
+	// by the command line
+	for _, content := range []string{
+		`{"plugins": {"mtg": {"options": {"rulings": "true"}}}, "profiles": {"league": {"plugins": {"mtg": {"options": {"ruling_sources": "wotc"}}}}}}`,
+		`{"plugins": {"mtg": {"options": {"ruling_sources": "wotc"}}}}`,
+	} {
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+		_, err = Load(path)
+		assert.Nil(t, err, content)
+	}
+}
+
+func TestSave(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "tts-deckconverter", "config.json")
+
+	config := &Config{
+		Settings: Settings{
+			Cache: Bool(true),
+			Plugins: map[string]PluginSettings{
+				"pkm": {Options: map[string]string{"quality": "normal"}},
+			},
+		},
+		Profiles: map[string]Settings{
+			"tournament": {Filename: "{plugin}/{name} - {board}"},
+		},
+	}
+	assert.Nil(t, config.Save(path))
+
+	loaded, err := Load(path)
+	assert.Nil(t, err)
+	assert.Equal(t, config, loaded)
+
+	config.Template = "dropbox"
+	assert.NotNil(t, config.Save(path))
+}
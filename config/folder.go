@@ -0,0 +1,85 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+)
+
+// FolderSettingsFile is the name of the marker file setting the plugin used
+// to parse the files of a folder and of its subfolders.
+const FolderSettingsFile = ".tts-deckconverter"
+
+// FolderSettings are the content of a marker file.
+type FolderSettings struct {
+	// Mode is the ID of the plugin used to parse the files.
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Back is the key of one of the backs of the plugin.
+	Back string `json:"back,omitempty" yaml:"back,omitempty"`
+	// BackURL is a custom card back (cannot be used with Back).
+	BackURL string `json:"backURL,omitempty" yaml:"backURL,omitempty"`
+	// Options are the plugin options.
+	Options map[string]string `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// LoadFolderSettings reads and validates the marker file of folder, written
+// in YAML or JSON.
+// nil is returned if the folder doesn't have a marker file.
+func LoadFolderSettings(folder string) (*FolderSettings, error) {
+	path := filepath.Join(folder, FolderSettingsFile)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var settings FolderSettings
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	// An empty marker file is valid
+	if err = decoder.Decode(&settings); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid marker file %s: %w", path, err)
+	}
+
+	if err = settings.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid marker file %s: %w", path, err)
+	}
+
+	return &settings, nil
+}
+
+// Validate checks that the settings refer to an existing plugin, back and
+// options. The back and the options can only be checked if the mode is set.
+func (s FolderSettings) Validate() error {
+	if len(s.Back) > 0 && len(s.BackURL) > 0 {
+		return fmt.Errorf("back and backURL cannot be used at the same time")
+	}
+
+	if len(s.Mode) == 0 {
+		if len(s.Back) > 0 || len(s.Options) > 0 {
+			return fmt.Errorf("the mode is required to set the back or the options")
+		}
+		return nil
+	}
+
+	plugin, found := dc.Plugins[s.Mode]
+	if !found {
+		return fmt.Errorf("invalid mode: %s", s.Mode)
+	}
+
+	return PluginSettings{
+		Back:    s.Back,
+		Options: s.Options,
+	}.validate(plugin)
+}
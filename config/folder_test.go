@@ -0,0 +1,59 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFolderSettings(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, FolderSettingsFile)
+
+	// No marker file
+	settings, err := LoadFolderSettings(tmpDir)
+	assert.Nil(t, err)
+	assert.Nil(t, settings)
+
+	assert.Nil(t, ioutil.WriteFile(path, nil, 0644))
+	settings, err = LoadFolderSettings(tmpDir)
+	assert.Nil(t, err)
+	assert.Equal(t, &FolderSettings{}, settings)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("mode: ygo\nback: ocg\noptions:\n  format: Rush Duel\n"), 0644))
+	settings, err = LoadFolderSettings(tmpDir)
+	assert.Nil(t, err)
+	assert.Equal(t, &FolderSettings{
+		Mode:    "ygo",
+		Back:    "ocg",
+		Options: map[string]string{"format": "Rush Duel"},
+	}, settings)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`{"mode": "mtg", "backURL": "https://example.com/back.png"}`), 0644))
+	settings, err = LoadFolderSettings(tmpDir)
+	assert.Nil(t, err)
+	assert.Equal(t, &FolderSettings{
+		Mode:    "mtg",
+		BackURL: "https://example.com/back.png",
+	}, settings)
+
+	for _, content := range []string{
+		`mdoe: mtg`,
+		`mode: hearthstone`,
+		`back: ocg`,
+		`{mode: ygo, back: rush}`,
+		`{mode: mtg, options: {quality: huge}}`,
+	} {
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+		_, err = LoadFolderSettings(tmpDir)
+		assert.NotNil(t, err, content)
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+)
+
+// Manifest is a list of targets to convert in one run, each with its own
+// settings.
+type Manifest struct {
+	// Targets are the URLs and files to convert.
+	Targets []Target `json:"targets" yaml:"targets"`
+}
+
+// Target is an entry of a manifest.
+// Empty values use the settings of the command line.
+type Target struct {
+	// Target is the URL or the path of the file or folder to convert.
+	Target string `json:"target" yaml:"target"`
+	// Mode is the ID of the plugin used to parse the target.
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Back is the key of one of the backs of the plugin.
+	Back string `json:"back,omitempty" yaml:"back,omitempty"`
+	// BackURL is a custom card back (cannot be used with Back).
+	BackURL string `json:"backURL,omitempty" yaml:"backURL,omitempty"`
+	// Options are the plugin options.
+	Options map[string]string `json:"options,omitempty" yaml:"options,omitempty"`
+	// Output is the destination folder.
+	Output string `json:"output,omitempty" yaml:"output,omitempty"`
+}
+
+// LoadManifest reads and validates the manifest found at path.
+// Files with a .json extension are read as JSON, the others as YAML.
+// The relative paths of the targets and output folders are resolved from
+// the folder of the manifest.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&manifest)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&manifest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+
+	if len(manifest.Targets) == 0 {
+		return nil, fmt.Errorf("invalid manifest %s: no targets", path)
+	}
+
+	folder := filepath.Dir(path)
+
+	for i := range manifest.Targets {
+		target := &manifest.Targets[i]
+		if err = target.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid manifest %s: target %d: %w", path, i+1, err)
+		}
+		if !isURL(target.Target) && !filepath.IsAbs(target.Target) {
+			target.Target = filepath.Join(folder, target.Target)
+		}
+		if len(target.Output) > 0 && !filepath.IsAbs(target.Output) {
+			target.Output = filepath.Join(folder, target.Output)
+		}
+	}
+
+	return &manifest, nil
+}
+
+// Validate checks that the target refers to an existing plugin, back and
+// options. The required options and the dependencies between options are
+// checked once the options are merged with the ones of the command line.
+func (t Target) Validate() error {
+	if len(t.Target) == 0 {
+		return fmt.Errorf("missing target")
+	}
+	if len(t.Back) > 0 && len(t.BackURL) > 0 {
+		return fmt.Errorf("back and backURL cannot be used at the same time")
+	}
+
+	pluginID := t.Mode
+	if len(pluginID) == 0 {
+		pluginID, _ = dc.FindPlugin(t.Target)
+	}
+
+	plugin, found := dc.Plugins[pluginID]
+	if !found {
+		if len(t.Mode) > 0 {
+			return fmt.Errorf("invalid mode: %s", t.Mode)
+		}
+		if len(t.Back) > 0 || len(t.Options) > 0 {
+			return fmt.Errorf("the mode is required to set the back or the options of %s", t.Target)
+		}
+		return nil
+	}
+
+	return PluginSettings{
+		Back:    t.Back,
+		Options: t.Options,
+	}.validate(plugin)
+}
+
+// isURL returns true if target is an HTTP URL.
+func isURL(target string) bool {
+	u, err := url.Parse(target)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
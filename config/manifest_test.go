@@ -0,0 +1,103 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadManifest(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	expected := &Manifest{
+		Targets: []Target{
+			{
+				Target:  "https://www.mtggoldfish.com/deck/2062036",
+				Options: map[string]string{"quality": "large"},
+				Output:  filepath.Join(tmpDir, "mtg"),
+			},
+			{
+				Target: filepath.Join(tmpDir, "decks", "burn.txt"),
+				Mode:   "mtg",
+				Back:   "planechase",
+			},
+			{
+				Target:  filepath.Join(tmpDir, "decks", "ygo"),
+				Mode:    "ygo",
+				BackURL: "https://example.com/back.png",
+			},
+		},
+	}
+
+	yamlPath := filepath.Join(tmpDir, "manifest.yaml")
+	err = ioutil.WriteFile(yamlPath, []byte(`targets:
+  - target: https://www.mtggoldfish.com/deck/2062036
+    options:
+      quality: large
+    output: mtg
+  - target: decks/burn.txt
+    mode: mtg
+    back: planechase
+  - target: decks/ygo
+    mode: ygo
+    backURL: https://example.com/back.png
+`), 0644)
+	assert.Nil(t, err)
+
+	manifest, err := LoadManifest(yamlPath)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, manifest)
+
+	jsonPath := filepath.Join(tmpDir, "manifest.json")
+	err = ioutil.WriteFile(jsonPath, []byte(`{"targets": [
+  {"target": "https://www.mtggoldfish.com/deck/2062036", "options": {"quality": "large"}, "output": "mtg"},
+  {"target": "decks/burn.txt", "mode": "mtg", "back": "planechase"},
+  {"target": "decks/ygo", "mode": "ygo", "backURL": "https://example.com/back.png"}
+]}`), 0644)
+	assert.Nil(t, err)
+
+	manifest, err = LoadManifest(jsonPath)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, manifest)
+}
+
+func TestLoadManifestInvalid(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "manifest.yml")
+
+	_, err = LoadManifest(path)
+	assert.NotNil(t, err)
+
+	for _, content := range []string{
+		`targets: []`,
+		`targets: [{mode: mtg}]`,
+		`targets: [{target: deck.txt, mdoe: mtg}]`,
+		`targets: [{target: deck.txt, mode: hearthstone}]`,
+		`targets: [{target: deck.txt, back: planechase}]`,
+		`targets: [{target: deck.txt, mode: ygo, back: rush}]`,
+		`targets: [{target: deck.txt, mode: mtg, options: {quality: huge}}]`,
+		`targets: [{target: deck.txt, mode: ygo, back: ocg, backURL: "https://example.com/back.png"}]`,
+	} {
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+		_, err = LoadManifest(path)
+		assert.NotNil(t, err, content)
+	}
+
+	// The dependencies of the options can be set by the command line
+	content := `targets: [{target: deck.txt, mode: mtg, options: {ruling_sources: wotc}}]`
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+	_, err = LoadManifest(path)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,113 @@
+package deckconverter
+
+import (
+	"sort"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts/upload"
+)
+
+// PluginInfo describes a plugin, for the tools integrating the converter.
+type PluginInfo struct {
+	ID      string              `json:"id"`
+	Name    string              `json:"name"`
+	Options []OptionInfo        `json:"options"`
+	Backs   map[string]BackInfo `json:"backs"`
+	Formats []string            `json:"formats"`
+}
+
+// OptionInfo describes a plugin option.
+type OptionInfo struct {
+	Name          string      `json:"name"`
+	Type          string      `json:"type"`
+	Description   string      `json:"description"`
+	DefaultValue  interface{} `json:"default,omitempty"`
+	AllowedValues []string    `json:"allowedValues,omitempty"`
+	Required      bool        `json:"required,omitempty"`
+	Requires      []string    `json:"requires,omitempty"`
+}
+
+// BackInfo describes a card back.
+type BackInfo struct {
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+// UploaderInfo describes a template uploader.
+type UploaderInfo struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// DescribePlugin returns the description of a plugin, with its options
+// and formats sorted.
+func DescribePlugin(plugin plugins.Plugin) PluginInfo {
+	description := PluginInfo{
+		ID:      plugin.PluginID(),
+		Name:    plugin.PluginName(),
+		Options: []OptionInfo{},
+		Backs:   make(map[string]BackInfo),
+		Formats: []string{},
+	}
+
+	for name, option := range plugin.AvailableOptions() {
+		description.Options = append(description.Options, OptionInfo{
+			Name:          name,
+			Type:          option.Type.String(),
+			Description:   option.Description,
+			DefaultValue:  option.DefaultValue,
+			AllowedValues: option.AllowedValues,
+			Required:      option.Required,
+			Requires:      option.Requires,
+		})
+	}
+	sort.Slice(description.Options, func(i, j int) bool {
+		return description.Options[i].Name < description.Options[j].Name
+	})
+
+	for key, back := range plugin.AvailableBacks() {
+		description.Backs[key] = BackInfo{
+			URL:         back.URL,
+			Description: back.Description,
+		}
+	}
+
+	for format := range plugin.DeckTypeHandlers() {
+		description.Formats = append(description.Formats, format)
+	}
+	sort.Strings(description.Formats)
+
+	return description
+}
+
+// DescribePlugins returns the description of the registered plugins, in the
+// order of AvailablePlugins.
+func DescribePlugins() []PluginInfo {
+	descriptions := make([]PluginInfo, 0, len(pluginIDs))
+
+	for _, pluginID := range pluginIDs {
+		descriptions = append(descriptions, DescribePlugin(Plugins[pluginID]))
+	}
+
+	return descriptions
+}
+
+// DescribeUploaders returns the description of the template uploaders,
+// sorted by ID.
+func DescribeUploaders() []UploaderInfo {
+	uploaders := make([]UploaderInfo, 0, len(upload.TemplateUploaders))
+
+	for _, uploader := range upload.TemplateUploaders {
+		uploaders = append(uploaders, UploaderInfo{
+			ID:          (*uploader).UploaderID(),
+			Name:        (*uploader).UploaderName(),
+			Description: (*uploader).UploaderDescription(),
+		})
+	}
+	sort.Slice(uploaders, func(i, j int) bool {
+		return uploaders[i].ID < uploaders[j].ID
+	})
+
+	return uploaders
+}
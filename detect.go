@@ -0,0 +1,161 @@
+package deckconverter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"unicode/utf8"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+// GenericFormat is the name of the generic deck format of each plugin (see
+// plugins.Plugin.GenericFileHandler).
+const GenericFormat = "generic"
+
+// minDetectionConfidence is the confidence below which a decklist isn't
+// considered recognized.
+const minDetectionConfidence = 0.2
+
+// Detection is the plugin and deck format recognized from the content of a
+// decklist.
+type Detection struct {
+	// PluginID is the ID of the plugin which can parse the decklist.
+	PluginID string
+	// Format is the key of the deck type in the DeckTypeHandlers of the
+	// plugin, or an empty string for its generic format.
+	Format string
+	// Confidence is how likely the decklist is to be in this format, from
+	// 0 to 1.
+	Confidence float64
+}
+
+// String returns a description of the detection result, e.g.
+// "ygo (YGOPRODeck, 100% confidence)".
+func (d Detection) String() string {
+	format := d.Format
+	if len(format) == 0 {
+		format = GenericFormat
+	}
+
+	return fmt.Sprintf("%s (%s, %.0f%% confidence)", d.PluginID, format, d.Confidence*100)
+}
+
+// Detect recognizes the plugin and the format of a decklist from its
+// content, by parsing it with each plugin implementing plugins.Detector,
+// without retrieving the cards.
+// Only the formats of the plugin of mode are considered if it is set.
+// The plugins registered first win the ties.
+func Detect(content []byte, mode string) (Detection, error) {
+	var best Detection
+
+	if !utf8.Valid(content) {
+		return best, fmt.Errorf("couldn't recognize the decklist, it isn't a text file")
+	}
+
+	for _, pluginID := range pluginIDs {
+		if len(mode) > 0 && pluginID != mode {
+			continue
+		}
+
+		detector, ok := Plugins[pluginID].(plugins.Detector)
+		if !ok {
+			continue
+		}
+
+		format, confidence := detector.DetectDeckType(content)
+		log.Debugf("Detection score of %s: %.2f (format %q)", pluginID, confidence, format)
+
+		if confidence > best.Confidence {
+			best = Detection{
+				PluginID:   pluginID,
+				Format:     format,
+				Confidence: confidence,
+			}
+		}
+	}
+
+	if best.Confidence < minDetectionConfidence {
+		if len(mode) > 0 {
+			return best, fmt.Errorf("couldn't recognize the format of the %s decklist", mode)
+		}
+		return best, fmt.Errorf("couldn't recognize the decklist, set the mode")
+	}
+
+	return best, nil
+}
+
+// deckTypeHandler returns the file handler of format for plugin, which is
+// the generic one if format is empty or GenericFormat.
+func deckTypeHandler(plugin plugins.Plugin, format string) (plugins.FileHandler, error) {
+	if len(format) == 0 || format == GenericFormat {
+		return plugin.GenericFileHandler().FileHandler, nil
+	}
+
+	deckType, found := plugin.DeckTypeHandlers()[format]
+	if !found {
+		return nil, fmt.Errorf("invalid format for %s: %s", plugin.PluginID(), format)
+	}
+
+	return deckType.FileHandler, nil
+}
+
+// ParseDecklist parses the content of a decklist (e.g. read from stdin) and
+// generates a list of decks from it.
+// If mode is empty, the plugin and format are detected from the content.
+// If format is empty, it is detected among the formats of the plugin, and
+// falls back to the generic format.
+// The plugin of the decks is set.
+func ParseDecklist(r io.Reader, name, mode, format string, options map[string]string) ([]*plugins.Deck, error) {
+	decks, pluginID, err := parseDecklist(r, name, mode, format, options)
+
+	for _, deck := range decks {
+		if len(deck.Plugin) == 0 {
+			deck.Plugin = pluginID
+		}
+	}
+
+	return decks, err
+}
+
+// parseDecklist parses the content of a decklist, and returns the resulting
+// decks as well as the ID of the plugin used.
+func parseDecklist(r io.Reader, name, mode, format string, options map[string]string) ([]*plugins.Deck, string, error) {
+	if len(mode) == 0 && len(format) > 0 {
+		return nil, "", fmt.Errorf("the mode is required to set the format")
+	}
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(format) == 0 {
+		detection, detectErr := Detect(content, mode)
+		switch {
+		case detectErr == nil:
+			log.Infof("Detected %s", detection)
+			mode, format = detection.PluginID, detection.Format
+		case len(mode) == 0:
+			return nil, "", detectErr
+		default:
+			log.Debugf("%v, using the generic format", detectErr)
+		}
+	}
+
+	plugin, found := Plugins[mode]
+	if !found {
+		return nil, "", fmt.Errorf("plugin %s not found", mode)
+	}
+
+	handler, err := deckTypeHandler(plugin, format)
+	if err != nil {
+		return nil, "", err
+	}
+
+	decks, err := handler(bytes.NewReader(content), name, options)
+
+	return decks, mode, err
+}
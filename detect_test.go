@@ -0,0 +1,174 @@
+package deckconverter
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+// detectorPlugin is a plugin recognizing the decklists containing its
+// keywords, with a fixed confidence.
+type detectorPlugin struct {
+	plugins.Plugin
+	id         string
+	keyword    string
+	format     string
+	confidence float64
+}
+
+func (p detectorPlugin) PluginID() string {
+	return p.id
+}
+
+func (p detectorPlugin) DetectDeckType(content []byte) (string, float64) {
+	if !bytes.Contains(content, []byte(p.keyword)) {
+		return "", 0
+	}
+	return p.format, p.confidence
+}
+
+// fileHandler returns a handler generating a deck named after the plugin
+// and format.
+func (p detectorPlugin) fileHandler(format string) plugins.FileHandler {
+	return func(r io.Reader, name string, options map[string]string) ([]*plugins.Deck, error) {
+		return []*plugins.Deck{{Name: name + " " + p.id + " " + format}}, nil
+	}
+}
+
+func (p detectorPlugin) GenericFileHandler() plugins.DeckType {
+	return plugins.DeckType{FileHandler: p.fileHandler(GenericFormat)}
+}
+
+func (p detectorPlugin) DeckTypeHandlers() map[string]plugins.DeckType {
+	return map[string]plugins.DeckType{
+		"special": {FileHandler: p.fileHandler("special")},
+	}
+}
+
+// useTestPlugins replaces the registered plugins during a test.
+func useTestPlugins(t *testing.T, testPlugins ...plugins.Plugin) {
+	previousPlugins, previousIDs := Plugins, pluginIDs
+	t.Cleanup(func() {
+		Plugins, pluginIDs = previousPlugins, previousIDs
+	})
+
+	Plugins = make(map[string]plugins.Plugin)
+	pluginIDs = nil
+	registerPlugins(testPlugins...)
+}
+
+func TestDetect(t *testing.T) {
+	useTestPlugins(
+		t,
+		detectorPlugin{id: "first", keyword: "deck", confidence: 0.5},
+		detectorPlugin{id: "second", keyword: "deck", format: "special", confidence: 0.5},
+		detectorPlugin{id: "third", keyword: "third", format: "special", confidence: 0.8},
+		detectorPlugin{id: "weak", keyword: "weak", confidence: minDetectionConfidence / 2},
+	)
+
+	testCases := []struct {
+		name     string
+		content  string
+		mode     string
+		expected Detection
+		err      string
+	}{
+		{
+			name:     "best confidence",
+			content:  "third deck",
+			expected: Detection{PluginID: "third", Format: "special", Confidence: 0.8},
+		},
+		{
+			name:     "tie",
+			content:  "deck",
+			expected: Detection{PluginID: "first", Confidence: 0.5},
+		},
+		{
+			name:     "mode",
+			content:  "third deck",
+			mode:     "second",
+			expected: Detection{PluginID: "second", Format: "special", Confidence: 0.5},
+		},
+		{
+			name:    "mode not recognizing the decklist",
+			content: "third",
+			mode:    "first",
+			err:     "couldn't recognize the format of the first decklist",
+		},
+		{
+			name:    "below the minimum confidence",
+			content: "weak",
+			err:     "couldn't recognize the decklist, set the mode",
+		},
+		{
+			name:    "unknown",
+			content: "nothing",
+			err:     "couldn't recognize the decklist, set the mode",
+		},
+		{
+			name:    "binary",
+			content: "deck \xff\xfe",
+			err:     "couldn't recognize the decklist, it isn't a text file",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			detection, err := Detect([]byte(testCase.content), testCase.mode)
+			if len(testCase.err) > 0 {
+				assert.EqualError(t, err, testCase.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, testCase.expected, detection)
+		})
+	}
+}
+
+func TestParseDecklist(t *testing.T) {
+	useTestPlugins(
+		t,
+		detectorPlugin{id: "first", keyword: "first", confidence: 0.5},
+		detectorPlugin{id: "second", keyword: "second", format: "special", confidence: 0.9},
+	)
+
+	testCases := []struct {
+		name     string
+		content  string
+		mode     string
+		format   string
+		expected string
+		plugin   string
+		err      string
+	}{
+		{name: "detected", content: "second", expected: "Test second special", plugin: "second"},
+		{name: "detected generic", content: "first", expected: "Test first generic", plugin: "first"},
+		// The format is detected among the formats of the plugin of mode
+		{name: "mode", content: "first second", mode: "first", expected: "Test first generic", plugin: "first"},
+		{name: "generic fallback", content: "nothing", mode: "second", expected: "Test second generic", plugin: "second"},
+		{name: "format", content: "nothing", mode: "first", format: "special", expected: "Test first special", plugin: "first"},
+		{name: "unknown", content: "nothing", err: "couldn't recognize the decklist, set the mode"},
+		{name: "format without mode", content: "first", format: "special", err: "the mode is required to set the format"},
+		{name: "invalid mode", content: "first", mode: "third", format: "special", err: "plugin third not found"},
+		{name: "invalid format", content: "first", mode: "first", format: "other", err: "invalid format for first: other"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			decks, err := ParseDecklist(strings.NewReader(testCase.content), "Test", testCase.mode, testCase.format, nil)
+			if len(testCase.err) > 0 {
+				assert.EqualError(t, err, testCase.err)
+				return
+			}
+			if assert.Nil(t, err) && assert.Len(t, decks, 1) {
+				assert.Equal(t, testCase.expected, decks[0].Name)
+				assert.Equal(t, testCase.plugin, decks[0].Plugin)
+			}
+		})
+	}
+}
@@ -9,8 +9,11 @@ require (
 	github.com/antchfx/htmlquery v1.2.4
 	github.com/antchfx/xpath v1.2.0
 	github.com/disintegration/imaging v1.6.2
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/koffeinsource/go-imgur v0.3.0
 	github.com/stretchr/testify v1.7.0
 	go.uber.org/zap v1.19.1
+	golang.org/x/image v0.0.0-20200430140353-33d19683fad8
 	golang.org/x/net v0.0.0-20211215060638-4ddde0e984e9
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -1,7 +1,9 @@
 package deckconverter
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -9,9 +11,13 @@ import (
 
 	"github.com/jeandeaual/tts-deckconverter/log"
 	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts"
 )
 
-func parseFileWithPlugin(target string, plugin plugins.Plugin, options map[string]string) ([]*plugins.Deck, error) {
+// parseFileWithPlugin parses a file with plugin, using the handler of
+// format if it is set (see deckTypeHandler), or the handler of the file
+// extension.
+func parseFileWithPlugin(target string, plugin plugins.Plugin, format string, options map[string]string) ([]*plugins.Deck, error) {
 	log.Infof("Parsing file %s", target)
 
 	var decks []*plugins.Deck
@@ -36,6 +42,14 @@ func parseFileWithPlugin(target string, plugin plugins.Plugin, options map[strin
 
 	log.Debugf("Base file name: %s", name)
 
+	if len(format) > 0 {
+		handler, err := deckTypeHandler(plugin, format)
+		if err != nil {
+			return nil, err
+		}
+		return handler(file, name, options)
+	}
+
 	if handler, ok := plugin.FileExtHandlers()[ext]; ok {
 		decks, err = handler(file, name, options)
 		return decks, err
@@ -45,22 +59,17 @@ func parseFileWithPlugin(target string, plugin plugins.Plugin, options map[strin
 	return decks, err
 }
 
-func parseFile(target string, options map[string]string) ([]*plugins.Deck, error) {
+func parseFile(target string, options map[string]string) ([]*plugins.Deck, string, error) {
 	if _, err := os.Stat(target); os.IsNotExist(err) {
-		return nil, err
+		return nil, "", err
 	}
 
 	// No mode selected, check the file extension handlers
 	ext := filepath.Ext(target)
 
-	fileExtHandler, found := FileExtHandlers[ext]
-	if !found {
-		return nil, fmt.Errorf("no handler found for %s files", ext)
-	}
-
 	file, err := os.Open(target)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer func() {
 		cerr := file.Close()
@@ -74,48 +83,178 @@ func parseFile(target string, options map[string]string) ([]*plugins.Deck, error
 
 	log.Debugf("Base file name: %s", name)
 
+	fileExtHandler, found := FileExtHandlers[ext]
+	if !found {
+		// Generic text file, recognize the plugin from its content
+		return parseDecklist(file, name, "", "", options)
+	}
+
 	decks, err := fileExtHandler(file, name, options)
 
-	return decks, err
+	return decks, fileExtPlugins[ext], err
+}
+
+// FindPlugin returns the ID of the plugin which handles target without a
+// mode being set: the plugin of a supported URL, the plugin registering the
+// extension of a file, or the plugin recognizing the content of the other
+// files.
+func FindPlugin(target string) (string, bool) {
+	detection, found := DetectTarget(target)
+	return detection.PluginID, found
+}
+
+// DetectTarget is like FindPlugin, but also returns the format of the files
+// recognized from their content. Use ParseFormat with the result to parse
+// target without reading it twice.
+func DetectTarget(target string) (Detection, bool) {
+	if u, err := url.Parse(target); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		for _, pluginID := range pluginIDs {
+			for _, handler := range Plugins[pluginID].URLHandlers() {
+				if handler.Regex.MatchString(target) {
+					return Detection{PluginID: pluginID, Confidence: 1}, true
+				}
+			}
+		}
+
+		return Detection{}, false
+	}
+
+	if pluginID, found := fileExtPlugins[filepath.Ext(target)]; found {
+		return Detection{PluginID: pluginID, Confidence: 1}, true
+	}
+
+	content, err := ioutil.ReadFile(target)
+	if err != nil {
+		return Detection{}, false
+	}
+	detection, err := Detect(content, "")
+	if err != nil {
+		return Detection{}, false
+	}
+
+	log.Debugf("Detected %s for %s", detection, target)
+
+	return detection, true
 }
 
 // Parse a URL or file and generate a list of decks from it.
+// The plugin and source of the decks are set.
 func Parse(target, mode string, options map[string]string) ([]*plugins.Deck, error) {
+	return ParseFormat(target, mode, "", options)
+}
+
+// ParseFormat is like Parse, but parses the files with the handler of
+// format (see Detection.Format) if it is set along with mode. The format is
+// ignored for the URLs.
+func ParseFormat(target, mode, format string, options map[string]string) ([]*plugins.Deck, error) {
+	decks, pluginID, err := parse(target, mode, format, options)
+
+	for _, deck := range decks {
+		if len(deck.Plugin) == 0 {
+			deck.Plugin = pluginID
+		}
+		if len(deck.Source) == 0 {
+			deck.Source = target
+		}
+	}
+
+	return decks, err
+}
+
+// parse parses a URL or file, and returns the resulting decks as well as the
+// ID of the plugin used.
+func parse(target, mode, format string, options map[string]string) ([]*plugins.Deck, string, error) {
 	if u, err := url.Parse(target); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
 		// Check if the target is a supported URL
-		for _, handler := range URLHandlers {
-			if handler.Regex.MatchString(target) {
-				log.Debugf("Using handler %+v", handler)
-				decks, err := handler.Handler(target, options)
-				return decks, err
+		for _, pluginID := range pluginIDs {
+			for _, handler := range Plugins[pluginID].URLHandlers() {
+				if handler.Regex.MatchString(target) {
+					log.Debugf("Using handler %+v", handler)
+					decks, err := handler.Handler(target, options)
+					return decks, pluginID, err
+				}
 			}
 		}
 
-		return nil, fmt.Errorf("unsupported URL: %s", target)
+		return nil, "", fmt.Errorf("unsupported URL: %s", target)
 	}
 
 	_, err := os.Stat(target)
 
 	if err != nil {
-		return nil, fmt.Errorf("file %s not found: %w", target, err)
+		return nil, "", fmt.Errorf("file %s not found: %w", target, err)
 	}
 
-	var selectedPlugin *plugins.Plugin
-
 	if len(mode) > 0 {
 		plugin, found := Plugins[mode]
 		if !found {
-			return nil, fmt.Errorf("plugin %s not found", mode)
+			return nil, "", fmt.Errorf("plugin %s not found", mode)
 		}
 
 		log.Infof("Using mode %s", mode)
 
-		selectedPlugin = &plugin
+		decks, err := parseFileWithPlugin(target, plugin, format, options)
+		return decks, mode, err
 	}
 
-	if selectedPlugin != nil {
-		return parseFileWithPlugin(target, *selectedPlugin, options)
+	return parseFile(target, options)
+}
+
+// Reload retrieves the cards of the deck contained in the saved object found
+// at path again, using the plugin of mode and options (e.g. to get images of
+// a different quality). The new deck is named after the previous one, and
+// keeps its card back.
+func Reload(path, mode string, options map[string]string) (*plugins.Deck, error) {
+	plugin, found := Plugins[mode]
+	if !found {
+		return nil, fmt.Errorf("plugin %s not found", mode)
 	}
 
-	return parseFile(target, options)
+	exporter, ok := plugin.(plugins.Exporter)
+	if !ok {
+		return nil, fmt.Errorf("saved objects can't be reloaded in mode %s", mode)
+	}
+
+	object, err := tts.ReadSavedObjectFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decks := tts.ExtractDecks(object, tts.SavedObjectName(path))
+	if len(decks) != 1 {
+		return nil, fmt.Errorf("%s contains %d decks, only saved objects containing a single deck can be reloaded", path, len(decks))
+	}
+	previous := decks[0]
+	name := previous.Name
+
+	// Write the cards back to a decklist, then parse it again. The deck name
+	// is cleared, so that all the cards are considered part of the main
+	// deck, even when reloading a sideboard.
+	previous.Name = ""
+	var decklist bytes.Buffer
+	err = exporter.GenericExportHandler()(&decklist, []*plugins.Deck{previous})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the cards of %s: %w", path, err)
+	}
+
+	log.Debugf("Decklist of %s:\n%s", path, decklist.String())
+
+	parsed, err := plugin.GenericFileHandler().FileHandler(&decklist, name, options)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't retrieve the cards of %s: %w", path, err)
+	}
+
+	for _, deck := range parsed {
+		if len(deck.Cards) > 0 {
+			deck.Name = name
+			deck.Plugin = mode
+			deck.Source = path
+			if len(previous.BackURL) > 0 {
+				deck.BackURL = previous.BackURL
+			}
+			return deck, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no card found in %s", path)
 }
@@ -0,0 +1,92 @@
+package deckconverter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+)
+
+func init() {
+	logger := zap.NewExample()
+	log.SetLogger(logger.Sugar())
+}
+
+func TestDetectTarget(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"deck.ydk":    "#main\n32295838\n",
+		"ydk.txt":     "#created by test\n#main\n32295838\n32295838\n#extra\n!side\n",
+		"unknown.txt": "Lorem ipsum dolor sit amet\n",
+		"deck.cod":    "<cockatrice_deck></cockatrice_deck>",
+	}
+	for name, content := range files {
+		if !assert.Nil(t, ioutil.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o644)) {
+			t.FailNow()
+		}
+	}
+
+	testCases := []struct {
+		target   string
+		found    bool
+		expected Detection
+	}{
+		{"https://ygoprodeck.com/deck/test", true, Detection{PluginID: "ygo", Confidence: 1}},
+		{"https://example.com/deck", false, Detection{}},
+		// The files are recognized by their extension first
+		{filepath.Join(tmpDir, "deck.ydk"), true, Detection{PluginID: "ygo", Confidence: 1}},
+		{filepath.Join(tmpDir, "deck.cod"), true, Detection{PluginID: "mtg", Confidence: 1}},
+		// Then by their content, along with their format
+		{filepath.Join(tmpDir, "ydk.txt"), true, Detection{PluginID: "ygo", Format: "YGOPRODeck", Confidence: 1}},
+		{filepath.Join(tmpDir, "unknown.txt"), false, Detection{}},
+		{filepath.Join(tmpDir, "missing.txt"), false, Detection{}},
+	}
+
+	for _, testCase := range testCases {
+		detection, found := DetectTarget(testCase.target)
+		assert.Equal(t, testCase.found, found, testCase.target)
+		assert.Equal(t, testCase.expected, detection, testCase.target)
+
+		pluginID, found := FindPlugin(testCase.target)
+		assert.Equal(t, testCase.found, found, testCase.target)
+		assert.Equal(t, testCase.expected.PluginID, pluginID, testCase.target)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	target := filepath.Join(tmpDir, "list.txt")
+	if !assert.Nil(t, ioutil.WriteFile(target, []byte("2 "+filepath.Join(tmpDir, "card.png")+" (Card)\n"), 0o644)) {
+		t.FailNow()
+	}
+
+	for _, format := range []string{"", GenericFormat} {
+		decks, err := ParseFormat(target, "custom", format, nil)
+		if assert.Nil(t, err, format) && assert.Len(t, decks, 1, format) {
+			assert.Equal(t, "list", decks[0].Name)
+			assert.Equal(t, "custom", decks[0].Plugin)
+			assert.Equal(t, target, decks[0].Source)
+			if assert.Len(t, decks[0].Cards, 1) {
+				assert.Equal(t, 2, decks[0].Cards[0].Count)
+			}
+		}
+	}
+
+	_, err = ParseFormat(target, "custom", "YGOPRODeck", nil)
+	assert.EqualError(t, err, "invalid format for custom: YGOPRODeck")
+}
@@ -2,6 +2,7 @@ package deckconverter
 
 import (
 	"log"
+	"sort"
 
 	"github.com/jeandeaual/tts-deckconverter/plugins"
 	"github.com/jeandeaual/tts-deckconverter/plugins/custom"
@@ -14,6 +15,7 @@ import (
 func init() {
 	Plugins = make(map[string]plugins.Plugin)
 	FileExtHandlers = make(map[string]plugins.FileHandler)
+	ExportHandlers = make(map[string]plugins.ExportHandler)
 
 	registerPlugins(
 		mtg.MagicPlugin,
@@ -25,6 +27,7 @@ func init() {
 
 	registerURLHandlers()
 	registerFileExtHandlers()
+	registerExportHandlers()
 }
 
 // Plugins is the list of registered plugins.
@@ -39,6 +42,13 @@ var URLHandlers []plugins.URLHandler
 // FileExtHandlers are all the registered file extension handlers.
 var FileExtHandlers map[string]plugins.FileHandler
 
+// fileExtPlugins are the IDs of the plugins of the file extension handlers,
+// by file extension.
+var fileExtPlugins = make(map[string]string)
+
+// ExportHandlers are all the registered decklist export handlers, by format.
+var ExportHandlers map[string]plugins.ExportHandler
+
 func registerPlugins(plugins ...plugins.Plugin) {
 	for _, plugin := range plugins {
 		Plugins[plugin.PluginID()] = plugin
@@ -66,10 +76,48 @@ func registerFileExtHandlers() {
 			}
 
 			FileExtHandlers[ext] = fileExtHandler
+			fileExtPlugins[ext] = plugin.PluginID()
+		}
+	}
+}
+
+func registerExportHandlers() {
+	for _, pluginID := range pluginIDs {
+		exporter, ok := Plugins[pluginID].(plugins.Exporter)
+		if !ok {
+			continue
+		}
+
+		for format, exportHandler := range exporter.ExportHandlers() {
+			_, found := ExportHandlers[format]
+			if found {
+				log.Fatalf(
+					"Handler for export format %s already exists, cannot "+
+						"register for %s",
+					format,
+					pluginID,
+				)
+			}
+
+			ExportHandlers[format] = exportHandler
 		}
 	}
 }
 
+// AvailableExportFormats lists the decklist formats the decks can be
+// exported to, sorted.
+func AvailableExportFormats() []string {
+	formats := make([]string, 0, len(ExportHandlers))
+
+	for format := range ExportHandlers {
+		formats = append(formats, format)
+	}
+
+	sort.Strings(formats)
+
+	return formats
+}
+
 // AvailablePlugins lists the registered plugins, sorted.
 func AvailablePlugins() []string {
 	return pluginIDs
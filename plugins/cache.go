@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"strings"
+	"sync"
+)
+
+// maxLookupCacheEntries is the number of entries after which a lookup cache
+// is emptied, so that long-running processes don't keep all the cards they
+// retrieved in memory.
+const maxLookupCacheEntries = 10000
+
+// LookupCache keeps the results of the API calls of a plugin in memory, so
+// that a card used in several decks, or in a deck converted again, is only
+// retrieved once per process.
+// It is safe for concurrent use.
+type LookupCache struct {
+	mutex   sync.RWMutex
+	entries map[string]interface{}
+}
+
+// NewLookupCache creates an empty lookup cache.
+func NewLookupCache() *LookupCache {
+	return &LookupCache{
+		entries: make(map[string]interface{}),
+	}
+}
+
+// LookupKey returns the cache key of an API call made with the given
+// parameters.
+func LookupKey(parts ...string) string {
+	return strings.Join(parts, "\x00")
+}
+
+// Get returns the result cached for key, if any.
+func (c *LookupCache) Get(key string) (interface{}, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	value, found := c.entries[key]
+
+	return value, found
+}
+
+// Set caches the result of an API call.
+func (c *LookupCache) Set(key string, value interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.entries) >= maxLookupCacheEntries {
+		c.entries = make(map[string]interface{})
+	}
+	c.entries[key] = value
+}
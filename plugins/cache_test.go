@@ -0,0 +1,33 @@
+package plugins
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCache(t *testing.T) {
+	cache := NewLookupCache()
+
+	_, found := cache.Get(LookupKey("Lightning Bolt", "lea"))
+	assert.False(t, found)
+
+	cache.Set(LookupKey("Lightning Bolt", "lea"), 1)
+	value, found := cache.Get(LookupKey("Lightning Bolt", "lea"))
+	assert.True(t, found)
+	assert.Equal(t, 1, value)
+
+	_, found = cache.Get(LookupKey("Lightning Bolt", ""))
+	assert.False(t, found)
+	assert.NotEqual(t, LookupKey("a", "bc"), LookupKey("ab", "c"))
+
+	// The cache is emptied when it is full
+	for i := 0; i < maxLookupCacheEntries; i++ {
+		cache.Set(strconv.Itoa(i), i)
+	}
+	_, found = cache.Get(LookupKey("Lightning Bolt", "lea"))
+	assert.False(t, found)
+	_, found = cache.Get(strconv.Itoa(maxLookupCacheEntries - 1))
+	assert.True(t, found)
+}
@@ -2,7 +2,9 @@ package custom
 
 import (
 	"bufio"
+	"bytes"
 	"io"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -12,23 +14,36 @@ import (
 )
 
 var cardLineRegexps = []*regexp.Regexp{
+	// Cards with their own back, separated from the face with a "|"
+	regexp.MustCompile(`^\s*(?:(?P<Count>\d+)x?\s+)?(?P<Path>[^|]+?)\s+\|\s+(?P<Back>.+)\s+\((?P<Name>.+)\)$`),
+	regexp.MustCompile(`^\s*(?:(?P<Count>\d+)x?\s+)?(?P<Path>[^|]+?)\s+\|\s+(?P<Back>.+)$`),
 	regexp.MustCompile(`^\s*(?:(?P<Count>\d+)x?\s+)?(?P<Path>.+)\s+\((?P<Name>.+)\)$`),
 	regexp.MustCompile(`^\s*(?:(?P<Count>\d+)x?\s+)?(?P<Path>.+)$`),
 }
 
+// imageRegex matches the lines referring to an image, by URL or by file
+// extension.
+var imageRegex = regexp.MustCompile(`(?i)(^|\s)(https?://\S+|\S+\.(png|jpe?g|gif|bmp|webp))(\s|$)`)
+
+// urlRegex matches the image locations which aren't file paths.
+var urlRegex = regexp.MustCompile(`(?i)^([a-z][a-z0-9+.-]+://|data:)`)
+
 // CardInfo contains a card file path and its name.
 type CardInfo struct {
 	// Name of the card.
 	Path string
 	// Set the card belongs to.
 	Name *string
+	// Back is the path of the card back, if the card has its own back.
+	Back *string
 }
 
 // CardFiles contains the card file paths and their count.
 type CardFiles struct {
 	// Cards are the card names.
 	Cards []CardInfo
-	// Counts is a map of card name to count (number of this card in the deck).
+	// Counts is a map of card path and back to count (number of this card in
+	// the deck).
 	Counts map[string]int
 }
 
@@ -39,27 +54,38 @@ func NewCardNames() *CardFiles {
 }
 
 // Insert a new card in a CardFiles struct.
-func (c *CardFiles) Insert(path string, name *string) {
-	c.InsertCount(path, name, 1)
+func (c *CardFiles) Insert(path string, name *string, back *string) {
+	c.InsertCount(path, name, back, 1)
 }
 
 // InsertCount inserts several new cards in a CardFiles struct.
-func (c *CardFiles) InsertCount(path string, name *string, count int) {
-	_, found := c.Counts[path]
+// Cards with the same face but different backs are kept separate.
+func (c *CardFiles) InsertCount(path string, name *string, back *string, count int) {
+	idx := cardIndex(path, back)
+	_, found := c.Counts[idx]
 	if !found {
 		c.Cards = append(c.Cards, CardInfo{
 			Path: path,
 			Name: name,
+			Back: back,
 		})
-		c.Counts[path] = count
+		c.Counts[idx] = count
 	} else {
-		c.Counts[path] = c.Counts[path] + count
+		c.Counts[idx] = c.Counts[idx] + count
 	}
 }
 
-// Count return the number of cards for a given path.
-func (c *CardFiles) Count(path string) int {
-	return c.Counts[path]
+// Count return the number of cards for a given path and back.
+func (c *CardFiles) Count(path string, back *string) int {
+	return c.Counts[cardIndex(path, back)]
+}
+
+// cardIndex returns the key of a card in CardFiles.Counts.
+func cardIndex(path string, back *string) string {
+	if back == nil {
+		return path
+	}
+	return path + "|" + *back
 }
 
 // String representation of a CardFiles struct.
@@ -67,10 +93,14 @@ func (c *CardFiles) String() string {
 	var sb strings.Builder
 
 	for _, cardInfo := range c.Cards {
-		count := c.Count(cardInfo.Path)
+		count := c.Count(cardInfo.Path, cardInfo.Back)
 		sb.WriteString(strconv.Itoa(count))
 		sb.WriteString(" ")
 		sb.WriteString(cardInfo.Path)
+		if cardInfo.Back != nil {
+			sb.WriteString(" | ")
+			sb.WriteString(*cardInfo.Back)
+		}
 		if cardInfo.Name != nil {
 			sb.WriteString("(")
 			sb.WriteString(*cardInfo.Name)
@@ -82,26 +112,50 @@ func (c *CardFiles) String() string {
 	return sb.String()
 }
 
-func cardFilesToDeck(cards *CardFiles, name string, options map[string]interface{}) (*plugins.Deck, error) {
+func cardFilesToDeck(cards *CardFiles, name string, options plugins.OptionValues) (*plugins.Deck, error) {
+	size := options.String("size")
+	shape := options.String("shape")
+
 	deck := &plugins.Deck{
 		Name:     name,
-		CardSize: plugins.CardSizeStandard,
+		CardSize: cardSizes[size],
+		Shape:    cardShapes[shape].shape,
+		Rounded:  cardShapes[shape].rounded,
 	}
 
+	folder := options.String("folder")
+
 	for _, cardInfo := range cards.Cards {
+		path := resolvePath(cardInfo.Path, folder)
 		card := plugins.CardInfo{
-			ImageURL: cardInfo.Path,
-			Count:    cards.Count(cardInfo.Path),
+			ImageURL: path,
+			Count:    cards.Count(cardInfo.Path, cardInfo.Back),
+			Metadata: map[string]string{
+				plugins.MetadataID: path,
+			},
 		}
 		if cardInfo.Name != nil {
 			card.Name = *cardInfo.Name
 		}
+		if cardInfo.Back != nil {
+			card.BackImageURL = resolvePath(*cardInfo.Back, folder)
+		}
 		deck.Cards = append(deck.Cards, card)
 	}
 
 	return deck, nil
 }
 
+// resolvePath returns the path of an image relative to folder, if it is a
+// relative file path and folder is set. The URLs are returned unchanged.
+func resolvePath(path string, folder string) string {
+	if len(folder) == 0 || filepath.IsAbs(path) || urlRegex.MatchString(path) {
+		return path
+	}
+
+	return filepath.Join(folder, path)
+}
+
 func fromList(file io.Reader, name string, options map[string]string) ([]*plugins.Deck, error) {
 	// Check the options
 	validatedOptions, err := CustomPlugin.AvailableOptions().ValidateNormalize(options)
@@ -173,11 +227,18 @@ func parseList(file io.Reader) (*CardFiles, error) {
 				name = &matches[nameIdx]
 			}
 
+			var back *string
+			backIdx := plugins.IndexOf("Back", groupNames)
+			if backIdx != -1 {
+				back = &matches[backIdx]
+			}
+
 			log.Debugw(
 				"Found card",
 				"path", path,
 				"count", count,
 				"name", name,
+				"back", back,
 				"regex", regex,
 				"matches", matches,
 				"groupNames", groupNames,
@@ -186,7 +247,7 @@ func parseList(file io.Reader) (*CardFiles, error) {
 			if main == nil {
 				main = NewCardNames()
 			}
-			main.InsertCount(path, name, count)
+			main.InsertCount(path, name, back, count)
 
 			break
 		}
@@ -205,3 +266,19 @@ func parseList(file io.Reader) (*CardFiles, error) {
 
 	return main, nil
 }
+
+// detectList returns how confident we are that content is a list of card
+// images.
+func detectList(content []byte) float64 {
+	lines := plugins.DecklistLines(content)
+	if len(lines) == 0 {
+		return 0
+	}
+
+	main, err := parseList(bytes.NewReader(content))
+	if err != nil || main == nil {
+		return 0
+	}
+
+	return 0.95 * float64(plugins.CountMatches(lines, imageRegex)) / float64(len(lines))
+}
@@ -1,6 +1,7 @@
 package custom
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -8,6 +9,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
 )
 
 func init() {
@@ -62,3 +64,112 @@ https://img.scryfall.com/cards/large/front/f/3/f3dd4d92-6471-4f4b-9c70-cbd2196e8
 	assert.Equal(t, expected, deck)
 	assert.Nil(t, err)
 }
+
+func TestParseDeckFileWithBacks(t *testing.T) {
+	deck, err := parseList(
+		strings.NewReader(`2 /home/user/front1.png | /home/user/back1.png (Test 1)
+/home/user/front2.png | https://example.com/back2.png
+/home/user/front1.png | https://example.com/back2.png
+/home/user/front1.png | /home/user/back1.png (Test 1)`),
+	)
+	expectedNames := []string{
+		"Test 1",
+	}
+	expectedBacks := []string{
+		"/home/user/back1.png",
+		"https://example.com/back2.png",
+	}
+	expected := &CardFiles{
+		Cards: []CardInfo{
+			{
+				Path: "/home/user/front1.png",
+				Name: &expectedNames[0],
+				Back: &expectedBacks[0],
+			},
+			{
+				Path: "/home/user/front2.png",
+				Name: nil,
+				Back: &expectedBacks[1],
+			},
+			{
+				Path: "/home/user/front1.png",
+				Name: nil,
+				Back: &expectedBacks[1],
+			},
+		},
+		Counts: map[string]int{
+			"/home/user/front1.png|/home/user/back1.png":          3,
+			"/home/user/front2.png|https://example.com/back2.png": 1,
+			"/home/user/front1.png|https://example.com/back2.png": 1,
+		},
+	}
+
+	assert.Equal(t, expected, deck)
+	assert.Nil(t, err)
+
+	converted, err := cardFilesToDeck(deck, "Test", plugins.OptionValues{})
+	assert.Nil(t, err)
+	if assert.Len(t, converted.Cards, 3) {
+		assert.Equal(t, 3, converted.Cards[0].Count)
+		assert.Equal(t, "/home/user/back1.png", converted.Cards[0].BackImageURL)
+		assert.Equal(t, 1, converted.Cards[2].Count)
+		assert.Equal(t, "https://example.com/back2.png", converted.Cards[2].BackImageURL)
+	}
+}
+
+func TestCardFilesToDeck(t *testing.T) {
+	cards := NewCardNames()
+	cards.Insert("/home/user/tile.png", nil, nil)
+	back := "back.png"
+
+	options, err := CustomPlugin.AvailableOptions().ValidateNormalize(map[string]string{})
+	assert.Nil(t, err)
+	deck, err := cardFilesToDeck(cards, "Test", options)
+	assert.Nil(t, err)
+	assert.Equal(t, plugins.CardSizeStandard, deck.CardSize)
+	assert.Equal(t, plugins.CardShapeRectangle, deck.Shape)
+	assert.False(t, deck.Rounded)
+	if assert.Len(t, deck.Cards, 1) {
+		assert.Equal(t, "/home/user/tile.png", deck.Cards[0].Metadata[plugins.MetadataID])
+	}
+
+	// The relative paths are resolved against the folder option
+	cards.Insert("cards/card.png", nil, &back)
+	cards.Insert("https://example.com/card.png", nil, nil)
+	options, err = CustomPlugin.AvailableOptions().ValidateNormalize(map[string]string{
+		"folder": "/home/user/game/",
+	})
+	assert.Nil(t, err)
+	deck, err = cardFilesToDeck(cards, "Test", options)
+	assert.Nil(t, err)
+	if assert.Len(t, deck.Cards, 3) {
+		assert.Equal(t, "/home/user/tile.png", deck.Cards[0].ImageURL)
+		assert.Equal(t, filepath.Join("/home/user/game", "cards", "card.png"), deck.Cards[1].ImageURL)
+		assert.Equal(t, filepath.Join("/home/user/game", "back.png"), deck.Cards[1].BackImageURL)
+		assert.Equal(t, "https://example.com/card.png", deck.Cards[2].ImageURL)
+	}
+
+	_, err = CustomPlugin.AvailableOptions().ValidateNormalize(map[string]string{"folder": ""})
+	assert.EqualError(t, err, "empty path set for option folder")
+
+	deck, err = cardFilesToDeck(cards, "Test", plugins.OptionValues{
+		"size":  "tarot",
+		"shape": "rounded_hex",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, plugins.CardSizeTarot, deck.CardSize)
+	assert.Equal(t, plugins.CardShapeHex, deck.Shape)
+	assert.True(t, deck.Rounded)
+}
+
+func TestDetectDeckType(t *testing.T) {
+	format, confidence := CustomPlugin.DetectDeckType([]byte(CustomPlugin.GenericFileHandler().Example))
+	assert.Equal(t, "", format)
+	assert.InDelta(t, 0.95, confidence, 0.001)
+
+	_, confidence = CustomPlugin.DetectDeckType([]byte("2 https://example.com/cards/card1.png (Card 1)\n4 Lightning Bolt"))
+	assert.InDelta(t, 0.475, confidence, 0.001)
+
+	_, confidence = CustomPlugin.DetectDeckType([]byte("4 Lightning Bolt"))
+	assert.Equal(t, 0.0, confidence)
+}
@@ -4,6 +4,30 @@ import (
 	"github.com/jeandeaual/tts-deckconverter/plugins"
 )
 
+// cardSizes maps the values of the "size" option to card sizes.
+var cardSizes = map[string]plugins.CardSize{
+	"standard":  plugins.CardSizeStandard,
+	"small":     plugins.CardSizeSmall,
+	"tarot":     plugins.CardSizeTarot,
+	"mini":      plugins.CardSizeMini,
+	"square":    plugins.CardSizeSquare,
+	"oversized": plugins.CardSizeOversized,
+}
+
+type cardShape struct {
+	shape   plugins.CardShape
+	rounded bool
+}
+
+// cardShapes maps the values of the "shape" option to card shapes.
+var cardShapes = map[string]cardShape{
+	"rectangle":         {shape: plugins.CardShapeRectangle, rounded: false},
+	"rounded_rectangle": {shape: plugins.CardShapeRectangle, rounded: true},
+	"hex":               {shape: plugins.CardShapeHex, rounded: false},
+	"rounded_hex":       {shape: plugins.CardShapeHex, rounded: true},
+	"circle":            {shape: plugins.CardShapeCircle, rounded: false},
+}
+
 type customPlugin struct {
 	id   string
 	name string
@@ -18,7 +42,37 @@ func (p customPlugin) PluginName() string {
 }
 
 func (p customPlugin) AvailableOptions() plugins.Options {
-	return plugins.Options{}
+	return plugins.Options{
+		"size": plugins.Option{
+			Type:        plugins.OptionTypeEnum,
+			Description: "card size (standard: 63×88mm, small: 59×86mm, tarot: 70×120mm, mini: 44×68mm, square: 70×70mm, oversized: 5×7in)",
+			AllowedValues: []string{
+				"standard",
+				"small",
+				"tarot",
+				"mini",
+				"square",
+				"oversized",
+			},
+			DefaultValue: "standard",
+		},
+		"shape": plugins.Option{
+			Type:        plugins.OptionTypeEnum,
+			Description: "card shape",
+			AllowedValues: []string{
+				"rectangle",
+				"rounded_rectangle",
+				"hex",
+				"rounded_hex",
+				"circle",
+			},
+			DefaultValue: "rectangle",
+		},
+		"folder": plugins.Option{
+			Type:        plugins.OptionTypePath,
+			Description: "folder the relative image paths of the list are resolved against (instead of the current folder)",
+		},
+	}
 }
 
 func (p customPlugin) URLHandlers() []plugins.URLHandler {
@@ -39,10 +93,15 @@ func (p customPlugin) GenericFileHandler() plugins.DeckType {
 		Example: `1 https://example.com/cards/card1.png (Card Name 1)
 4 https://example.com/cards/card2.png (Card Name 2)
 2 C:\Users\User\Documents\Cards\card3.png (Card Name 3)
-C:\Users\User\Documents\Cards\card4.png`,
+C:\Users\User\Documents\Cards\card4.png
+1 https://example.com/cards/card5.png | https://example.com/cards/back5.png (Card Name 5)`,
 	}
 }
 
+func (p customPlugin) DetectDeckType(content []byte) (string, float64) {
+	return "", detectList(content)
+}
+
 func (p customPlugin) AvailableBacks() map[string]plugins.Back {
 	return map[string]plugins.Back{}
 }
@@ -5,21 +5,46 @@ import (
 	"time"
 
 	scryfall "github.com/BlueMonday/go-scryfall"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
 )
 
 // See https://scryfall.com/docs/api#rate-limits-and-good-citizenship
 var rateLimiter = time.NewTicker(100 * time.Millisecond)
 
+// lookupCache keeps the cards and rulings retrieved from Scryfall.
+var lookupCache = plugins.NewLookupCache()
+
 func getCard(ctx context.Context, client *scryfall.Client, id string) (scryfall.Card, error) {
+	key := plugins.LookupKey("card", id)
+	if card, found := lookupCache.Get(key); found {
+		return card.(scryfall.Card), nil
+	}
+
 	<-rateLimiter.C
-	return client.GetCard(ctx, id)
+	card, err := client.GetCard(ctx, id)
+	if err == nil {
+		lookupCache.Set(key, card)
+	}
+
+	return card, err
 }
 
 func getCardByName(ctx context.Context, client *scryfall.Client, name string, opts scryfall.GetCardByNameOptions) (scryfall.Card, error) {
+	key := plugins.LookupKey("name", name, opts.Set)
+	if card, found := lookupCache.Get(key); found {
+		return card.(scryfall.Card), nil
+	}
+
 	<-rateLimiter.C
 	// Fuzzy search is required to match card names in languages other
 	// than English ("printed_name")
-	return client.GetCardByName(ctx, name, false, opts)
+	card, err := client.GetCardByName(ctx, name, false, opts)
+	if err == nil {
+		lookupCache.Set(key, card)
+	}
+
+	return card, err
 }
 
 func listSets(ctx context.Context, client *scryfall.Client) ([]scryfall.Set, error) {
@@ -28,6 +53,16 @@ func listSets(ctx context.Context, client *scryfall.Client) ([]scryfall.Set, err
 }
 
 func getRulings(ctx context.Context, client *scryfall.Client, cardID string) ([]scryfall.Ruling, error) {
+	key := plugins.LookupKey("rulings", cardID)
+	if rulings, found := lookupCache.Get(key); found {
+		return rulings.([]scryfall.Ruling), nil
+	}
+
 	<-rateLimiter.C
-	return client.GetRulings(ctx, cardID)
+	rulings, err := client.GetRulings(ctx, cardID)
+	if err == nil {
+		lookupCache.Set(key, rulings)
+	}
+
+	return rulings, err
 }
@@ -1,6 +1,7 @@
 package mtg
 
 import (
+	"bytes"
 	"encoding/xml"
 	"io"
 	"io/ioutil"
@@ -9,6 +10,9 @@ import (
 	"github.com/jeandeaual/tts-deckconverter/plugins"
 )
 
+// cockatriceDeckType is the deck type of the Cockatrice deck files.
+const cockatriceDeckType = "Cockatrice"
+
 // CockatriceDeck is the main tag in a Cockatrice deck file (.cod)
 type CockatriceDeck struct {
 	XMLName  xml.Name         `xml:"cockatrice_deck"`
@@ -69,7 +73,7 @@ func fromCockatriceDeckFile(file io.Reader, name string, options map[string]stri
 		tokenIDs = append(tokenIDs, sideTokenIDs...)
 	}
 
-	if generateTokens, found := validatedOptions["tokens"]; found && generateTokens.(bool) {
+	if validatedOptions.Bool("tokens") {
 		tokenDeck, err := tokenIDsToDeck(tokenIDs, name+" - Tokens", validatedOptions)
 		if err != nil {
 			return nil, err
@@ -129,3 +133,21 @@ func parseCockatriceDeckFile(file io.Reader) (*CardNames, *CardNames, error) {
 
 	return main, side, nil
 }
+
+// isCockatriceDeckFile returns true if content looks like a Cockatrice deck
+// file.
+func isCockatriceDeckFile(content []byte) bool {
+	return bytes.Contains(content, []byte("<cockatrice_deck"))
+}
+
+// detectCockatriceDeckFile returns how confident we are that content is a
+// valid Cockatrice deck file.
+func detectCockatriceDeckFile(content []byte) float64 {
+	main, side, err := parseCockatriceDeckFile(bytes.NewReader(content))
+	if err != nil || (main == nil && side == nil) {
+		// Still a Cockatrice file, but the parser will report the error
+		return 0.6
+	}
+
+	return 1
+}
@@ -0,0 +1,120 @@
+package mtg
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+const (
+	sideboardSuffix  = " - Sideboard"
+	maybeboardSuffix = " - Maybeboard"
+	tokensSuffix     = " - Tokens"
+)
+
+// exportName returns the name of a card as written in a decklist.
+func exportName(card plugins.CardInfo) string {
+	name := plugins.BaseCardName(card.Name)
+
+	if len(card.BackImageURL) > 0 {
+		// Double-faced cards using their back face as card back are named
+		// after both faces, only keep the front one
+		name = strings.SplitN(name, " // ", 2)[0]
+	}
+
+	return name
+}
+
+// splitDecks returns the main deck, sideboard and maybeboard cards, based on
+// the names of the decks generated by the plugin. Tokens are skipped.
+func splitDecks(decks []*plugins.Deck) (main, side, maybe []plugins.CardInfo) {
+	for _, deck := range decks {
+		switch {
+		case strings.HasSuffix(deck.Name, sideboardSuffix):
+			side = append(side, deck.Cards...)
+		case strings.HasSuffix(deck.Name, maybeboardSuffix):
+			maybe = append(maybe, deck.Cards...)
+		case strings.HasSuffix(deck.Name, tokensSuffix):
+			continue
+		default:
+			main = append(main, deck.Cards...)
+		}
+	}
+
+	return
+}
+
+// writeCards writes a line for each card. If withSet is set, the set and
+// collector number of the card are appended to its name when they are known
+// (e.g. "4 Opt (ELD) 59").
+func writeCards(w *bufio.Writer, cards []plugins.CardInfo, withSet bool) error {
+	for _, card := range cards {
+		name := exportName(card)
+		if len(name) == 0 {
+			return errors.New("found a card without a name")
+		}
+		if set := card.Metadata[plugins.MetadataSet]; withSet && len(set) > 0 {
+			name += " (" + set + ")"
+			if number := card.Metadata[plugins.MetadataNumber]; len(number) > 0 {
+				name += " " + number
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%d %s\n", card.Count, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportMTGO writes the decks in the MTGO text format: the main deck, then
+// the sideboard after an empty line.
+func exportMTGO(w io.Writer, decks []*plugins.Deck) error {
+	main, side, _ := splitDecks(decks)
+	bw := bufio.NewWriter(w)
+
+	if err := writeCards(bw, main, false); err != nil {
+		return err
+	}
+
+	if len(side) > 0 {
+		if _, err := bw.WriteString("\n"); err != nil {
+			return err
+		}
+		if err := writeCards(bw, side, false); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// exportArena writes the decks in the Magic Arena text format, including the
+// set of the cards.
+// Arena doesn't support maybeboards, so they are skipped.
+func exportArena(w io.Writer, decks []*plugins.Deck) error {
+	main, side, _ := splitDecks(decks)
+	bw := bufio.NewWriter(w)
+
+	if _, err := bw.WriteString("Deck\n"); err != nil {
+		return err
+	}
+	if err := writeCards(bw, main, true); err != nil {
+		return err
+	}
+
+	if len(side) > 0 {
+		if _, err := bw.WriteString("\nSideboard\n"); err != nil {
+			return err
+		}
+		if err := writeCards(bw, side, true); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
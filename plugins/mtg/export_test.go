@@ -0,0 +1,79 @@
+package mtg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+var exportDecks = []*plugins.Deck{
+	{
+		Name: "Test",
+		Cards: []plugins.CardInfo{
+			{
+				Name:     "Lightning Bolt\n1CMC\n[b]Instant[/b]",
+				Count:    4,
+				Metadata: map[string]string{plugins.MetadataSet: "M10"},
+			},
+			{
+				Name:         "Delver of Secrets // Insectile Aberration\n1CMC\n[b]Creature — Human Wizard // Creature — Human Insect[/b]",
+				BackImageURL: "https://example.com/back.png",
+				Count:        2,
+			},
+			{Name: "Fire // Ice\n4CMC\n[b]Instant // Instant[/b]", Count: 1},
+		},
+	},
+	{
+		Name: "Test" + sideboardSuffix,
+		Cards: []plugins.CardInfo{
+			{
+				Name:     "Negate",
+				Count:    2,
+				Metadata: map[string]string{plugins.MetadataSet: "RIX", plugins.MetadataNumber: "44"},
+			},
+		},
+	},
+	{
+		Name:  "Test" + maybeboardSuffix,
+		Cards: []plugins.CardInfo{{Name: "Opt", Count: 1}},
+	},
+	{
+		Name:  "Test" + tokensSuffix,
+		Cards: []plugins.CardInfo{{Name: "Insect", Count: 1}},
+	},
+}
+
+func TestExportMTGO(t *testing.T) {
+	var sb strings.Builder
+
+	err := exportMTGO(&sb, exportDecks)
+	assert.Nil(t, err)
+	assert.Equal(t, `4 Lightning Bolt
+2 Delver of Secrets
+1 Fire // Ice
+
+2 Negate
+`, sb.String())
+}
+
+func TestExportArena(t *testing.T) {
+	var sb strings.Builder
+
+	err := exportArena(&sb, exportDecks)
+	assert.Nil(t, err)
+	assert.Equal(t, `Deck
+4 Lightning Bolt (M10)
+2 Delver of Secrets
+1 Fire // Ice
+
+Sideboard
+2 Negate (RIX) 44
+`, sb.String())
+
+	sb.Reset()
+	err = exportArena(&sb, []*plugins.Deck{{Name: "Test", Cards: []plugins.CardInfo{{Count: 1}}}})
+	assert.NotNil(t, err)
+}
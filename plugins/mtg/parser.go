@@ -45,6 +45,14 @@ var cardLineRegexps = []*regexp.Regexp{
 	// TODO: Support .dck for CubeCobra
 }
 
+// setLineRegexps are the card line formats with a set, which are specific
+// to Magic decklists (e.g. "4 Lightning Bolt (M10) 146").
+var setLineRegexps = cardLineRegexps[:2]
+
+// sectionRegex matches the lines starting a new board, including the
+// sections of the Arena exports.
+var sectionRegex = regexp.MustCompile(`^(Deck|Commander|Companion|Sideboard|Maybeboard)\b`)
+
 // DeckType is the type of a parsed deck.
 type DeckType int
 
@@ -189,19 +197,36 @@ func getImageURL(
 	return imageURL
 }
 
-func checkRulings(ctx context.Context, client *scryfall.Client, cardID string, options map[string]interface{}) ([]scryfall.Ruling, error) {
+func checkRulings(ctx context.Context, client *scryfall.Client, cardID string, options plugins.OptionValues) ([]scryfall.Ruling, error) {
 	var (
 		rulings []scryfall.Ruling
 		err     error
 	)
 
 	// Check the options to see if we want the rulings
-	if showRulings, found := options["rulings"]; found && showRulings.(bool) {
+	if options.Bool("rulings") {
 		log.Debugf("Querying rulings for card ID %s", cardID)
 		rulings, err = getRulings(ctx, client, cardID)
 	}
 
-	return rulings, err
+	return filterRulings(rulings, options.List("ruling_sources")), err
+}
+
+// filterRulings returns the rulings coming from one of the sources, or all
+// the rulings if the sources aren't set.
+func filterRulings(rulings []scryfall.Ruling, sources []string) []scryfall.Ruling {
+	if len(rulings) == 0 || sources == nil {
+		return rulings
+	}
+
+	filtered := make([]scryfall.Ruling, 0, len(rulings))
+	for _, ruling := range rulings {
+		if plugins.IndexOf(string(ruling.Source), sources) >= 0 {
+			filtered = append(filtered, ruling)
+		}
+	}
+
+	return filtered
 }
 
 func parseRelatedTokenIDs(card scryfall.Card) []string {
@@ -282,6 +307,7 @@ func buildDoubleFacedCard(
 	rulings []scryfall.Ruling,
 	imageQuality string,
 	detailedDescription bool,
+	flip bool,
 	count int,
 	deck *plugins.Deck,
 ) (plugins.CardInfo, error) {
@@ -295,11 +321,25 @@ func buildDoubleFacedCard(
 	frontImageURL := getImageURL(&front.ImageURIs, card.HighresImage, imageQuality)
 	backImageURL := getImageURL(&back.ImageURIs, card.HighresImage, imageQuality)
 
+	if flip {
+		// Use the back face as the card back, so that the card can be
+		// flipped in TTS
+		return plugins.CardInfo{
+			Name:         buildCardFacesName(card),
+			Description:  buildCardFacesDescription(card.CardFaces, rulings, detailedDescription),
+			ImageURL:     frontImageURL,
+			BackImageURL: backImageURL,
+			Count:        count,
+			Sideways:     isSideways(card),
+		}, nil
+	}
+
 	return plugins.CardInfo{
 		Name:        buildCardFaceName(front.Name, card.CMC, front.TypeLine),
 		Description: buildCardFaceDescription(front, rulings, detailedDescription),
 		ImageURL:    frontImageURL,
 		Count:       count,
+		Sideways:    isSideways(card),
 		AlternativeState: &plugins.CardInfo{
 			Name:        buildCardFaceName(back.Name, card.CMC, back.TypeLine),
 			Description: buildCardFaceDescription(back, rulings, detailedDescription),
@@ -343,10 +383,11 @@ func buildSingleFacedCard(
 		ImageURL:    imageURL,
 		Count:       count,
 		Oversized:   card.Oversized,
+		Sideways:    isSideways(card),
 	}, nil
 }
 
-func cardNamesToDeck(cards *CardNames, name string, options map[string]interface{}) (*plugins.Deck, []string, error) {
+func cardNamesToDeck(cards *CardNames, name string, options plugins.OptionValues) (*plugins.Deck, []string, error) {
 	ctx := context.Background()
 	deck := &plugins.Deck{
 		Name:     name,
@@ -360,15 +401,12 @@ func cardNamesToDeck(cards *CardNames, name string, options map[string]interface
 		return deck, tokenIDs, err
 	}
 
-	imageQuality := MagicPlugin.AvailableOptions()["quality"].DefaultValue.(string)
-	if quality, found := options["quality"]; found {
-		imageQuality = quality.(string)
-	}
+	imageQuality := options.String("quality")
+	detailedDescription := options.Bool("detailed_description")
+	flipDFC := options.Bool("flip_dfc")
 
-	detailedDescription := MagicPlugin.AvailableOptions()["detailed_description"].DefaultValue.(bool)
-	if description, found := options["detailed_description"]; found {
-		detailedDescription = description.(bool)
-	}
+	thumbnail := thumbnailMode(options.String("thumbnail"))
+	thumbnailCandidates := make([]thumbnailCandidate, 0, len(cards.Names))
 
 	for _, cardInfo := range cards.Names {
 		count := cards.Count(cardInfo.Name, cardInfo.Set)
@@ -449,9 +487,9 @@ func cardNamesToDeck(cards *CardNames, name string, options map[string]interface
 		switch card.Layout {
 		case scryfall.LayoutMeld:
 			cardInfo, err = buildMeldCard(ctx, client, card, rulings, imageQuality, detailedDescription, count, deck)
-		case scryfall.LayoutTransform, scryfall.LayoutDoubleSided, scryfall.LayoutModalDFC:
+		case scryfall.LayoutTransform, scryfall.LayoutDoubleSided, scryfall.LayoutModalDFC, layoutBattle:
 			// For transform and other two-sided cards
-			cardInfo, err = buildDoubleFacedCard(card, rulings, imageQuality, detailedDescription, count, deck)
+			cardInfo, err = buildDoubleFacedCard(card, rulings, imageQuality, detailedDescription, flipDFC, count, deck)
 		default:
 			cardInfo, err = buildSingleFacedCard(card, rulings, imageQuality, detailedDescription, count, deck)
 		}
@@ -461,11 +499,27 @@ func cardNamesToDeck(cards *CardNames, name string, options map[string]interface
 			continue
 		}
 
+		// Keep the printing of the card, so that the same version is
+		// retrieved when refreshing the deck
+		cardInfo.Metadata = map[string]string{
+			plugins.MetadataSet:    strings.ToUpper(card.Set),
+			plugins.MetadataNumber: card.CollectorNumber,
+		}
+
+		thumbnailCandidates = append(thumbnailCandidates, thumbnailCandidate{
+			imageURL: cardInfo.ImageURL,
+			rarity:   rarityRanks[card.Rarity],
+			// Deck lists start with the commanders
+			commander: len(deck.Cards) < 2 && count == 1 && isCommander(card),
+		})
+
 		deck.Cards = append(deck.Cards, cardInfo)
 
 		log.Infof("Retrieved %s", card.Name)
 	}
 
+	deck.ThumbnailURLs = selectThumbnailURLs(thumbnailCandidates, thumbnail)
+
 	return deck, tokenIDs, nil
 }
 
@@ -483,7 +537,7 @@ func removeDuplicates(s []string) []string {
 	return s[:i]
 }
 
-func tokenIDsToDeck(tokenIDs []string, name string, options map[string]interface{}) (*plugins.Deck, error) {
+func tokenIDsToDeck(tokenIDs []string, name string, options plugins.OptionValues) (*plugins.Deck, error) {
 	ctx := context.Background()
 	deck := &plugins.Deck{
 		Name:     name,
@@ -497,15 +551,9 @@ func tokenIDsToDeck(tokenIDs []string, name string, options map[string]interface
 		return deck, err
 	}
 
-	imageQuality := MagicPlugin.AvailableOptions()["quality"].DefaultValue.(string)
-	if quality, found := options["quality"]; found {
-		imageQuality = quality.(string)
-	}
-
-	detailedDescription := MagicPlugin.AvailableOptions()["detailed_description"].DefaultValue.(bool)
-	if description, found := options["detailed_description"]; found {
-		detailedDescription = description.(bool)
-	}
+	imageQuality := options.String("quality")
+	detailedDescription := options.Bool("detailed_description")
+	flipDFC := options.Bool("flip_dfc")
 
 	tokenIDs = removeDuplicates(tokenIDs)
 
@@ -535,7 +583,7 @@ func tokenIDsToDeck(tokenIDs []string, name string, options map[string]interface
 		var cardInfo plugins.CardInfo
 
 		if card.Layout == scryfall.LayoutDoubleFacedToken {
-			cardInfo, err = buildDoubleFacedCard(card, rulings, imageQuality, detailedDescription, 1, deck)
+			cardInfo, err = buildDoubleFacedCard(card, rulings, imageQuality, detailedDescription, flipDFC, 1, deck)
 		} else {
 			cardInfo, err = buildSingleFacedCard(card, rulings, imageQuality, detailedDescription, 1, deck)
 		}
@@ -579,7 +627,7 @@ func fromDeckFile(file io.Reader, name string, options map[string]string) ([]*pl
 	}
 
 	if side != nil {
-		sideDeck, sideTokenIDs, err := cardNamesToDeck(side, name+" - Sideboard", validatedOptions)
+		sideDeck, sideTokenIDs, err := cardNamesToDeck(side, name+sideboardSuffix, validatedOptions)
 		if err != nil {
 			return nil, err
 		}
@@ -589,7 +637,7 @@ func fromDeckFile(file io.Reader, name string, options map[string]string) ([]*pl
 	}
 
 	if maybe != nil {
-		maybeDeck, maybeTokenIDs, err := cardNamesToDeck(maybe, name+" - Maybeboard", validatedOptions)
+		maybeDeck, maybeTokenIDs, err := cardNamesToDeck(maybe, name+maybeboardSuffix, validatedOptions)
 		if err != nil {
 			return nil, err
 		}
@@ -598,8 +646,8 @@ func fromDeckFile(file io.Reader, name string, options map[string]string) ([]*pl
 		tokenIDs = append(tokenIDs, maybeTokenIDs...)
 	}
 
-	if generateTokens, found := validatedOptions["tokens"]; (!found || generateTokens.(bool)) && len(tokenIDs) > 0 {
-		tokenDeck, err := tokenIDsToDeck(tokenIDs, name+" - Tokens", validatedOptions)
+	if validatedOptions.Bool("tokens") && len(tokenIDs) > 0 {
+		tokenDeck, err := tokenIDsToDeck(tokenIDs, name+tokensSuffix, validatedOptions)
 		if err != nil {
 			return nil, err
 		}
@@ -825,6 +873,32 @@ func parseDeckFile(file io.Reader) (*CardNames, *CardNames, *CardNames, error) {
 	return main, side, maybe, nil
 }
 
+// detectDeckFile returns how confident we are that content is a Magic
+// decklist.
+func detectDeckFile(content []byte) float64 {
+	lines := plugins.DecklistLines(content)
+	if len(lines) == 0 {
+		return 0
+	}
+
+	main, side, maybe, err := parseDeckFile(bytes.NewReader(content))
+	if err != nil || (main == nil && side == nil && maybe == nil) {
+		return 0
+	}
+
+	cardLines := plugins.CountMatches(lines, cardLineRegexps...)
+	setLines := plugins.CountMatches(lines, setLineRegexps...)
+	coverage := float64(cardLines+plugins.CountMatches(lines, sectionRegex)) / float64(len(lines))
+
+	if setLines == 0 {
+		// Lines like "4 Lightning Bolt" are used by the decklists of most
+		// card games
+		return 0.4 * coverage
+	}
+
+	return (0.6 + 0.4*float64(setLines)/float64(cardLines)) * coverage
+}
+
 func queryDeckFile(fileURL string, deckName string, options map[string]string) (decks []*plugins.Deck, err error) {
 	// Build the request
 	req, err := http.NewRequest("GET", fileURL, nil)
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	scryfall "github.com/BlueMonday/go-scryfall"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 
@@ -96,3 +97,56 @@ func TestParseDeckFile(t *testing.T) {
 	assert.Nil(t, maybe)
 	assert.Nil(t, err)
 }
+
+func TestDetectDeckType(t *testing.T) {
+	format, confidence := MagicPlugin.DetectDeckType([]byte(MagicPlugin.DeckTypeHandlers()[cockatriceDeckType].Example))
+	assert.Equal(t, cockatriceDeckType, format)
+	assert.Equal(t, 1.0, confidence)
+
+	format, confidence = MagicPlugin.DetectDeckType([]byte("Deck\n4 Lightning Bolt (M10) 146\n2 Path to Exile (CON) 15\n\nSideboard\n1 [M19] Duress"))
+	assert.Equal(t, "", format)
+	assert.Equal(t, 1.0, confidence)
+
+	// Plain card lines are used by other card games too
+	_, confidence = MagicPlugin.DetectDeckType([]byte("4 Lightning Bolt\n2 Path to Exile"))
+	assert.Equal(t, 0.4, confidence)
+
+	_, confidence = MagicPlugin.DetectDeckType([]byte("Hello\nWorld"))
+	assert.Equal(t, 0.0, confidence)
+
+	_, confidence = MagicPlugin.DetectDeckType(nil)
+	assert.Equal(t, 0.0, confidence)
+}
+
+func TestRulingSources(t *testing.T) {
+	rulings := []scryfall.Ruling{
+		{Source: scryfall.SourceWOTC, Comment: "Official ruling"},
+		{Source: scryfall.SourceScryfall, Comment: "Scryfall note"},
+	}
+
+	_, err := MagicPlugin.AvailableOptions().ValidateNormalize(map[string]string{
+		"ruling_sources": "wotc",
+	})
+	assert.EqualError(t, err, "option ruling_sources requires option rulings to be set")
+
+	_, err = MagicPlugin.AvailableOptions().ValidateNormalize(map[string]string{
+		"rulings":        "false",
+		"ruling_sources": "wotc",
+	})
+	assert.EqualError(t, err, "option ruling_sources requires option rulings to be enabled")
+
+	options, err := MagicPlugin.AvailableOptions().ValidateNormalize(map[string]string{
+		"rulings": "true",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, rulings, filterRulings(rulings, options.List("ruling_sources")))
+
+	options, err = MagicPlugin.AvailableOptions().ValidateNormalize(map[string]string{
+		"rulings":        "true",
+		"ruling_sources": "wotc",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, rulings[:1], filterRulings(rulings, options.List("ruling_sources")))
+
+	assert.Equal(t, rulings, filterRulings(rulings, nil))
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 
+	scryfall "github.com/BlueMonday/go-scryfall"
 	"github.com/antchfx/htmlquery"
 
 	"github.com/jeandeaual/tts-deckconverter/log"
@@ -22,6 +23,14 @@ const (
 	png    imageQuality = "png"
 )
 
+type thumbnailMode string
+
+const (
+	thumbnailFirst     thumbnailMode = "first"
+	thumbnailCommander thumbnailMode = "commander"
+	thumbnailRarity    thumbnailMode = "rarity"
+)
+
 type magicPlugin struct {
 	id   string
 	name string
@@ -58,11 +67,33 @@ func (p magicPlugin) AvailableOptions() plugins.Options {
 			Description:  "show all card info in the description of the card",
 			DefaultValue: false,
 		},
+		"flip_dfc": plugins.Option{
+			Type:         plugins.OptionTypeBool,
+			Description:  "use the back face of double-faced cards as their card back instead of a separate state",
+			DefaultValue: false,
+		},
+		"thumbnail": plugins.Option{
+			Type:        plugins.OptionTypeEnum,
+			Description: "cards shown in the deck thumbnail: the first card, the commanders followed by the rarest cards, or the rarest cards",
+			AllowedValues: []string{
+				string(thumbnailFirst),
+				string(thumbnailCommander),
+				string(thumbnailRarity),
+			},
+			DefaultValue: string(thumbnailFirst),
+		},
 		"rulings": plugins.Option{
 			Type:         plugins.OptionTypeBool,
 			Description:  "add the rulings to each card description",
 			DefaultValue: false,
 		},
+		"ruling_sources": plugins.Option{
+			Type:          plugins.OptionTypeList,
+			Description:   "sources of the rulings added to the card descriptions: the official rulings of Wizards of the Coast, or the notes of Scryfall",
+			AllowedValues: []string{string(scryfall.SourceWOTC), string(scryfall.SourceScryfall)},
+			DefaultValue:  []string{string(scryfall.SourceWOTC), string(scryfall.SourceScryfall)},
+			Requires:      []string{"rulings"},
+		},
 	}
 }
 
@@ -281,9 +312,20 @@ func (p magicPlugin) FileExtHandlers() map[string]plugins.FileHandler {
 	}
 }
 
+func (p magicPlugin) ExportHandlers() map[string]plugins.ExportHandler {
+	return map[string]plugins.ExportHandler{
+		"mtgo":  exportMTGO,
+		"arena": exportArena,
+	}
+}
+
+func (p magicPlugin) GenericExportHandler() plugins.ExportHandler {
+	return exportArena
+}
+
 func (p magicPlugin) DeckTypeHandlers() map[string]plugins.DeckType {
 	return map[string]plugins.DeckType{
-		"Cockatrice": {
+		cockatriceDeckType: {
 			FileHandler: fromCockatriceDeckFile,
 			Example: `<?xml version="1.0" encoding="UTF-8"?>
 <cockatrice_deck version="1">
@@ -318,6 +360,14 @@ Maybeboard
 	}
 }
 
+func (p magicPlugin) DetectDeckType(content []byte) (string, float64) {
+	if isCockatriceDeckFile(content) {
+		return cockatriceDeckType, detectCockatriceDeckFile(content)
+	}
+
+	return "", detectDeckFile(content)
+}
+
 func (p magicPlugin) AvailableBacks() map[string]plugins.Back {
 	return map[string]plugins.Back{
 		plugins.DefaultBackKey: {
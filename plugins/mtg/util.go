@@ -1,6 +1,7 @@
 package mtg
 
 import (
+	"sort"
 	"strconv"
 	"strings"
 
@@ -9,6 +10,110 @@ import (
 
 const dateFormat = "2006-01-02"
 
+// layoutBattle is the layout of the Battle cards, which is not yet defined by
+// go-scryfall.
+const layoutBattle scryfall.Layout = "battle"
+
+// maxThumbnailCards is the maximum number of cards fanned out in the
+// thumbnail of a deck.
+const maxThumbnailCards = 5
+
+// rarityRanks orders the card rarities, from the most common to the rarest.
+var rarityRanks = map[string]int{
+	"common":   0,
+	"uncommon": 1,
+	"rare":     2,
+	"special":  3,
+	"bonus":    3,
+	"mythic":   4,
+}
+
+// thumbnailCandidate is a card which can be shown in the thumbnail of a deck.
+type thumbnailCandidate struct {
+	imageURL  string
+	rarity    int
+	commander bool
+}
+
+// isCommander returns whether or not a card can be the commander of a deck.
+func isCommander(card scryfall.Card) bool {
+	if strings.Contains(card.OracleText, "can be your commander") {
+		return true
+	}
+
+	typeLine := card.TypeLine
+	if len(card.CardFaces) > 0 {
+		// Only check the front face of double-faced cards
+		typeLine = card.CardFaces[0].TypeLine
+	}
+
+	return strings.Contains(typeLine, "Legendary") && strings.Contains(typeLine, "Creature")
+}
+
+// selectThumbnailURLs returns the images of the cards to fan out in the deck
+// thumbnail.
+// With the commander mode, the commanders are shown first, followed by the
+// rarest cards. nil is returned if there aren't enough cards to fan out,
+// in which case only the first card is used.
+func selectThumbnailURLs(candidates []thumbnailCandidate, mode thumbnailMode) []string {
+	if mode == thumbnailFirst {
+		return nil
+	}
+
+	urls := make([]string, 0, maxThumbnailCards)
+	added := make(map[string]bool)
+	add := func(candidate thumbnailCandidate) {
+		if len(urls) < maxThumbnailCards && !added[candidate.imageURL] {
+			urls = append(urls, candidate.imageURL)
+			added[candidate.imageURL] = true
+		}
+	}
+
+	if mode == thumbnailCommander {
+		for _, candidate := range candidates {
+			if candidate.commander {
+				add(candidate)
+			}
+		}
+	}
+
+	sorted := make([]thumbnailCandidate, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].rarity > sorted[j].rarity
+	})
+	for _, candidate := range sorted {
+		add(candidate)
+	}
+
+	if len(urls) < 2 {
+		return nil
+	}
+
+	return urls
+}
+
+// isSideways returns whether or not a card is printed in landscape
+// orientation (split cards, rooms, planes, phenomena and the front face of
+// battles).
+func isSideways(card scryfall.Card) bool {
+	switch card.Layout {
+	case scryfall.LayoutPlanar, layoutBattle:
+		return true
+	case scryfall.LayoutSplit:
+		// Aftermath cards use the split layout, but are printed in portrait
+		// orientation
+		for _, face := range card.CardFaces {
+			if face.OracleText != nil && strings.Contains(*face.OracleText, "Aftermath") {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
 func appendRulings(sb *strings.Builder, rulings []scryfall.Ruling) {
 	if sb == nil || rulings == nil || len(rulings) == 0 {
 		return
@@ -108,3 +108,70 @@ func TestBuildCardFacesDescription(t *testing.T) {
 		},
 	}, nil, false))
 }
+
+func TestIsSideways(t *testing.T) {
+	aftermath := "Aftermath (Cast this spell only from your graveyard. Then exile it.)"
+	other := "Draw a card."
+
+	assert.False(t, isSideways(scryfall.Card{Layout: scryfall.LayoutNormal}))
+	assert.False(t, isSideways(scryfall.Card{Layout: scryfall.LayoutTransform}))
+	assert.True(t, isSideways(scryfall.Card{Layout: scryfall.LayoutPlanar}))
+	assert.True(t, isSideways(scryfall.Card{Layout: layoutBattle}))
+	assert.True(t, isSideways(scryfall.Card{
+		Layout: scryfall.LayoutSplit,
+		CardFaces: []scryfall.CardFace{
+			{OracleText: &other},
+			{OracleText: &other},
+		},
+	}))
+	assert.False(t, isSideways(scryfall.Card{
+		Layout: scryfall.LayoutSplit,
+		CardFaces: []scryfall.CardFace{
+			{OracleText: &other},
+			{OracleText: &aftermath},
+		},
+	}))
+}
+
+func TestIsCommander(t *testing.T) {
+	assert.True(t, isCommander(scryfall.Card{TypeLine: "Legendary Creature — Elf Druid"}))
+	assert.True(t, isCommander(scryfall.Card{
+		TypeLine:   "Legendary Planeswalker — Teferi",
+		OracleText: "Teferi, Temporal Archmage can be your commander.",
+	}))
+	assert.True(t, isCommander(scryfall.Card{
+		TypeLine: "Legendary Creature — Human Werewolf // Legendary Creature — Werewolf",
+		CardFaces: []scryfall.CardFace{
+			{TypeLine: "Legendary Creature — Human Werewolf"},
+			{TypeLine: "Legendary Creature — Werewolf"},
+		},
+	}))
+	assert.False(t, isCommander(scryfall.Card{TypeLine: "Legendary Artifact"}))
+	assert.False(t, isCommander(scryfall.Card{TypeLine: "Creature — Elf Druid"}))
+}
+
+func TestSelectThumbnailURLs(t *testing.T) {
+	candidates := []thumbnailCandidate{
+		{imageURL: "commander", rarity: rarityRanks["rare"], commander: true},
+		{imageURL: "common", rarity: rarityRanks["common"]},
+		{imageURL: "mythic", rarity: rarityRanks["mythic"]},
+		{imageURL: "uncommon", rarity: rarityRanks["uncommon"]},
+		{imageURL: "mythic2", rarity: rarityRanks["mythic"]},
+		{imageURL: "rare", rarity: rarityRanks["rare"]},
+	}
+
+	assert.Nil(t, selectThumbnailURLs(candidates, thumbnailFirst))
+	assert.Equal(
+		t,
+		[]string{"commander", "mythic", "mythic2", "rare", "uncommon"},
+		selectThumbnailURLs(candidates, thumbnailCommander),
+	)
+	assert.Equal(
+		t,
+		[]string{"mythic", "mythic2", "commander", "rare", "uncommon"},
+		selectThumbnailURLs(candidates, thumbnailRarity),
+	)
+
+	// A single card isn't fanned out
+	assert.Nil(t, selectThumbnailURLs(candidates[:1], thumbnailRarity))
+}
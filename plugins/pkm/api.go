@@ -4,17 +4,32 @@ import (
 	"time"
 
 	pokemontcgsdk "github.com/PokemonTCG/pokemon-tcg-sdk-go/src"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
 )
 
 // See https://docs.pokemontcg.io/#documentationrate_limits
 var rateLimiter = time.NewTicker(1.4 * 1000 * time.Millisecond)
 
+// lookupCache keeps the cards retrieved from the Pokémon TCG API.
+var lookupCache = plugins.NewLookupCache()
+
 func getCards(name string, setCode string) ([]pokemontcgsdk.PokemonCard, error) {
+	key := plugins.LookupKey(name, setCode)
+	if cards, found := lookupCache.Get(key); found {
+		return cards.([]pokemontcgsdk.PokemonCard), nil
+	}
+
 	<-rateLimiter.C
-	return pokemontcgsdk.GetCards(map[string]string{
+	cards, err := pokemontcgsdk.GetCards(map[string]string{
 		"name":    name,
 		"setCode": setCode,
 	})
+	if err == nil {
+		lookupCache.Set(key, cards)
+	}
+
+	return cards, err
 }
 
 func getSets() ([]pokemontcgsdk.Set, error) {
@@ -0,0 +1,116 @@
+package pkm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+// imageURLRegex extracts the set and the number of a card from the URL of
+// its image (e.g. https://images.pokemontcg.io/sm1/1_hires.png).
+var imageURLRegex = regexp.MustCompile(`/([A-Za-z0-9.-]+)/([A-Za-z0-9-]+?)(?:_hires)?\.png$`)
+
+// ptcgoSections are the sections of a PTCGO decklist, with the super type
+// of the cards they contain.
+var ptcgoSections = []struct {
+	title     string
+	superType string
+}{
+	{"Pokémon", "Pokémon"},
+	{"Trainer Cards", "Trainer"},
+	{"Energy", "Energy"},
+}
+
+// superType returns the super type of a card, found on the first line of its
+// description.
+func superType(card plugins.CardInfo) string {
+	return strings.TrimSpace(strings.SplitN(card.Description, "\n", 2)[0])
+}
+
+// ptcgoLine returns the line of a card in a PTCGO decklist.
+// The set and the number are read from the metadata of the card, or from the
+// URL of its image for the decks generated without them (the image URL of
+// the cards of a template sheet doesn't contain them).
+func ptcgoLine(card plugins.CardInfo) (string, error) {
+	set := card.Metadata[plugins.MetadataSet]
+	number := card.Metadata[plugins.MetadataNumber]
+
+	if len(set) == 0 || len(number) == 0 {
+		matches := imageURLRegex.FindStringSubmatch(card.ImageURL)
+		if matches == nil {
+			return "", fmt.Errorf("couldn't find the set of card %s in %s", card.Name, card.ImageURL)
+		}
+
+		set = matches[1]
+		number = matches[2]
+	}
+
+	ptcgoSet, found := getPTCGOSetCode(set)
+	if !found || len(ptcgoSet) == 0 {
+		ptcgoSet = strings.ToUpper(set)
+	}
+
+	return fmt.Sprintf("* %d %s %s %s\n", card.Count, plugins.BaseCardName(card.Name), ptcgoSet, number), nil
+}
+
+// exportPTCGO writes the decks in the Pokémon TCG Online format.
+func exportPTCGO(w io.Writer, decks []*plugins.Deck) error {
+	var cards []plugins.CardInfo
+	for _, deck := range decks {
+		cards = append(cards, deck.Cards...)
+	}
+
+	bw := bufio.NewWriter(w)
+	total := 0
+
+	if _, err := bw.WriteString("****** Pokémon Trading Card Game Deck List ******\n\n"); err != nil {
+		return err
+	}
+
+	for _, section := range ptcgoSections {
+		var (
+			lines []string
+			count int
+		)
+
+		for _, card := range cards {
+			cardType := superType(card)
+			// Cards of unknown type are put with the trainers
+			if cardType != section.superType &&
+				!(section.superType == "Trainer" && cardType != "Pokémon" && cardType != "Energy") {
+				continue
+			}
+
+			line, err := ptcgoLine(card)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, line)
+			count += card.Count
+		}
+
+		if _, err := fmt.Fprintf(bw, "##%s - %d\n\n", section.title, count); err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if _, err := bw.WriteString(line); err != nil {
+				return err
+			}
+		}
+		if _, err := bw.WriteString("\n"); err != nil {
+			return err
+		}
+
+		total += count
+	}
+
+	if _, err := fmt.Fprintf(bw, "Total Cards - %d\n", total); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
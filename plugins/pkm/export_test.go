@@ -0,0 +1,155 @@
+package pkm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+func TestExportPTCGO(t *testing.T) {
+	// Don't query the API
+	standardSetToPTCGOSetMap = newSetMap()
+	standardSetToPTCGOSetMap.Store("sm1", "SUM")
+	defer func() {
+		standardSetToPTCGOSetMap = nil
+	}()
+
+	var sb strings.Builder
+
+	err := exportPTCGO(&sb, []*plugins.Deck{
+		{
+			Name: "Test",
+			Cards: []plugins.CardInfo{
+				{
+					Name:        "Rowlet",
+					Description: "Pokémon\nBasic\n\n60 HP",
+					ImageURL:    "https://images.pokemontcg.io/sm1/9_hires.png",
+					Count:       4,
+				},
+				{
+					Name:        "Grass Energy",
+					Description: "Energy\nBasic",
+					ImageURL:    "https://images.pokemontcg.io/sm1/164_hires.png",
+					Count:       10,
+				},
+				{
+					Name:        "Professor Kukui",
+					Description: "Trainer\nSupporter",
+					ImageURL:    "https://images.pokemontcg.io/sm1/128.png",
+					Count:       2,
+				},
+				{
+					Name:        "Unknown",
+					Description: "Trainer\nItem",
+					ImageURL:    "https://images.pokemontcg.io/xy1/1_hires.png",
+					Count:       1,
+				},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `****** Pokémon Trading Card Game Deck List ******
+
+##Pokémon - 4
+
+* 4 Rowlet SUM 9
+
+##Trainer Cards - 3
+
+* 2 Professor Kukui SUM 128
+* 1 Unknown XY1 1
+
+##Energy - 10
+
+* 10 Grass Energy SUM 164
+
+Total Cards - 17
+`, sb.String())
+}
+
+func TestExportPTCGOTemplate(t *testing.T) {
+	// Don't query the API
+	standardSetToPTCGOSetMap = newSetMap()
+	standardSetToPTCGOSetMap.Store("sm1", "SUM")
+	defer func() {
+		standardSetToPTCGOSetMap = nil
+	}()
+
+	var sb strings.Builder
+
+	// Cards read back from a deck generated with a template sheet
+	err := exportPTCGO(&sb, []*plugins.Deck{
+		{
+			Name: "Test",
+			Cards: []plugins.CardInfo{
+				{
+					Name:        "Rowlet",
+					Description: "Pokémon\nBasic\n\n60 HP",
+					ImageURL:    "https://i.imgur.com/template.jpg#0",
+					Count:       4,
+					Metadata: map[string]string{
+						plugins.MetadataSet:    "sm1",
+						plugins.MetadataNumber: "9",
+					},
+				},
+				{
+					Name:        "Professor Kukui",
+					Description: "Trainer\nSupporter",
+					ImageURL:    "https://i.imgur.com/template.jpg#1",
+					Count:       2,
+					Metadata: map[string]string{
+						plugins.MetadataSet:    "sm1",
+						plugins.MetadataNumber: "128",
+					},
+				},
+				{
+					Name:        "Grass Energy",
+					Description: "Energy\nBasic",
+					ImageURL:    "https://i.imgur.com/template.jpg#2",
+					Count:       10,
+					Metadata: map[string]string{
+						plugins.MetadataSet:    "sm1",
+						plugins.MetadataNumber: "164",
+					},
+				},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `****** Pokémon Trading Card Game Deck List ******
+
+##Pokémon - 4
+
+* 4 Rowlet SUM 9
+
+##Trainer Cards - 2
+
+* 2 Professor Kukui SUM 128
+
+##Energy - 10
+
+* 10 Grass Energy SUM 164
+
+Total Cards - 16
+`, sb.String())
+
+	// Without metadata, the set can't be found
+	sb.Reset()
+	err = exportPTCGO(&sb, []*plugins.Deck{
+		{
+			Name: "Test",
+			Cards: []plugins.CardInfo{
+				{
+					Name:        "Rowlet",
+					Description: "Pokémon\nBasic\n\n60 HP",
+					ImageURL:    "https://i.imgur.com/template.jpg#0",
+					Count:       4,
+				},
+			},
+		},
+	})
+	assert.EqualError(t, err, "couldn't find the set of card Rowlet in https://i.imgur.com/template.jpg#0")
+}
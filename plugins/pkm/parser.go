@@ -2,6 +2,7 @@ package pkm
 
 import (
 	"bufio"
+	"bytes"
 	"io"
 	"regexp"
 	"strconv"
@@ -24,6 +25,15 @@ var cardLineRegexps = []*regexp.Regexp{
 	regexp.MustCompile(`^\s*\**\s*(?P<Count>\d+)\s+(?P<Name>.+)\s+(?P<Set>[A-Za-z0-9_-]+)\s+(?P<NumberInSet>[A-Za-z0-9]+)$`),
 }
 
+// ptcgoLineRegex is a stricter version of the PTCGO card line format, with
+// a set code (e.g. "SM9b" or "PR-SM") and a number containing a digit, to
+// tell the Pokémon decklists apart from the other card games.
+var ptcgoLineRegex = regexp.MustCompile(`^\**\s*\d+\s+.+\s+[A-Z][A-Za-z0-9]*(-[A-Za-z0-9]+)?\s+[A-Za-z]*\d+[A-Za-z]*$`)
+
+// sectionRegex matches the section headers of the PTCGO and PTCGL exports
+// (e.g. "Pokémon - 11" or "Trainer: 46").
+var sectionRegex = regexp.MustCompile(`^(Pok[eé]mon|Trainer|Energy|Total Cards)\s*[-:]\s*\d+$`)
+
 // CardInfo contains a card name, its set and its number in a set.
 type CardInfo struct {
 	// Name of the card.
@@ -94,7 +104,7 @@ func (c *CardNames) String() string {
 	return sb.String()
 }
 
-func cardNamesToDeck(cards *CardNames, name string, options map[string]interface{}) (*plugins.Deck, error) {
+func cardNamesToDeck(cards *CardNames, name string, options plugins.OptionValues) (*plugins.Deck, error) {
 	deck := &plugins.Deck{
 		Name:     name,
 		BackURL:  PokemonPlugin.AvailableBacks()[plugins.DefaultBackKey].URL,
@@ -157,6 +167,10 @@ func cardNamesToDeck(cards *CardNames, name string, options map[string]interface
 			Description: buildCardDescription(card),
 			ImageURL:    card.ImageURLHiRes,
 			Count:       count,
+			Metadata: map[string]string{
+				plugins.MetadataSet:    card.SetCode,
+				plugins.MetadataNumber: card.Number,
+			},
 		})
 	}
 
@@ -272,3 +286,29 @@ func parseDeckFile(file io.Reader) (*CardNames, error) {
 
 	return main, nil
 }
+
+// detectDeckFile returns how confident we are that content is a Pokémon
+// decklist.
+func detectDeckFile(content []byte) float64 {
+	lines := plugins.DecklistLines(content)
+	if len(lines) == 0 {
+		return 0
+	}
+
+	main, err := parseDeckFile(bytes.NewReader(content))
+	if err != nil || main == nil {
+		return 0
+	}
+
+	cardLines := plugins.CountMatches(lines, ptcgoLineRegex)
+	if cardLines == 0 {
+		return 0
+	}
+	sectionLines := plugins.CountMatches(lines, sectionRegex)
+	confidence := 0.9 * float64(cardLines+sectionLines) / float64(len(lines))
+	if sectionLines > 0 {
+		confidence += 0.1
+	}
+
+	return confidence
+}
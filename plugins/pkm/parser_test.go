@@ -105,3 +105,19 @@ Total Cards - 34
 	assert.Equal(t, expected, main)
 	assert.Nil(t, err)
 }
+
+func TestDetectDeckType(t *testing.T) {
+	format, confidence := PokemonPlugin.DetectDeckType([]byte(PokemonPlugin.GenericFileHandler().Example))
+	assert.Equal(t, "", format)
+	assert.InDelta(t, 1.0, confidence, 0.001)
+
+	_, confidence = PokemonPlugin.DetectDeckType([]byte("2 Hoopa SLG 55\n2 Genesect SM9b 36"))
+	assert.InDelta(t, 0.9, confidence, 0.001)
+
+	// The last words of the Magic card names aren't set codes and numbers
+	_, confidence = PokemonPlugin.DetectDeckType([]byte("1 Jace, the Mind Sculptor\n4 Lightning Bolt (M10) 146"))
+	assert.Equal(t, 0.0, confidence)
+
+	_, confidence = PokemonPlugin.DetectDeckType(nil)
+	assert.Equal(t, 0.0, confidence)
+}
@@ -48,6 +48,16 @@ func (p pokemonPlugin) FileExtHandlers() map[string]plugins.FileHandler {
 	}
 }
 
+func (p pokemonPlugin) ExportHandlers() map[string]plugins.ExportHandler {
+	return map[string]plugins.ExportHandler{
+		"ptcgo": exportPTCGO,
+	}
+}
+
+func (p pokemonPlugin) GenericExportHandler() plugins.ExportHandler {
+	return exportPTCGO
+}
+
 func (p pokemonPlugin) DeckTypeHandlers() map[string]plugins.DeckType {
 	return map[string]plugins.DeckType{}
 }
@@ -79,6 +89,10 @@ Energy - 3
 	}
 }
 
+func (p pokemonPlugin) DetectDeckType(content []byte) (string, float64) {
+	return "", detectDeckFile(content)
+}
+
 func (p pokemonPlugin) AvailableBacks() map[string]plugins.Back {
 	return map[string]plugins.Back{
 		plugins.DefaultBackKey: {
@@ -40,8 +40,12 @@ func (sm *setMap) Store(key, value string) {
 var (
 	ptcgoSetToStandardSetMap *setMap
 	standardSetToPTCGOSetMap *setMap
+	// setsMutex guards the retrieval of the sets, since the decks can be
+	// converted concurrently
+	setsMutex sync.Mutex
 )
 
+// setUp retrieves the sets and fills the set maps. setsMutex should be held.
 func setUp() bool {
 	sets, err := getSets()
 	if err != nil {
@@ -49,31 +53,50 @@ func setUp() bool {
 		return false
 	}
 
-	ptcgoSetToStandardSetMap = newSetMap()
-	standardSetToPTCGOSetMap = newSetMap()
+	ptcgoToStandard := newSetMap()
+	standardToPTCGO := newSetMap()
 
 	for _, set := range sets {
-		ptcgoSetToStandardSetMap.Store(set.PtcgoCode, set.Code)
-		standardSetToPTCGOSetMap.Store(set.Code, set.PtcgoCode)
+		ptcgoToStandard.Store(set.PtcgoCode, set.Code)
+		standardToPTCGO.Store(set.Code, set.PtcgoCode)
 	}
 
+	// The maps are only visible once they are complete
+	ptcgoSetToStandardSetMap = ptcgoToStandard
+	standardSetToPTCGOSetMap = standardToPTCGO
+
 	return true
 }
 
+// loadedSetMap returns the set map, retrieving the sets if they haven't
+// been retrieved yet. It returns nil if the sets couldn't be retrieved.
+func loadedSetMap(sets **setMap) *setMap {
+	setsMutex.Lock()
+	defer setsMutex.Unlock()
+
+	if *sets == nil && !setUp() {
+		return nil
+	}
+
+	return *sets
+}
+
 func getSetCode(ptcgoSetCode string) (string, bool) {
 	ptcgoSetCode = strings.TrimSuffix(ptcgoSetCode, "Energy")
 
-	if ptcgoSetToStandardSetMap == nil {
-		setUp()
+	sets := loadedSetMap(&ptcgoSetToStandardSetMap)
+	if sets == nil {
+		return "", false
 	}
 
-	return ptcgoSetToStandardSetMap.Load(ptcgoSetCode)
+	return sets.Load(ptcgoSetCode)
 }
 
 func getPTCGOSetCode(setCode string) (string, bool) {
-	if standardSetToPTCGOSetMap == nil {
-		setUp()
+	sets := loadedSetMap(&standardSetToPTCGOSetMap)
+	if sets == nil {
+		return "", false
 	}
 
-	return standardSetToPTCGOSetMap.Load(strings.ToLower(setCode))
+	return sets.Load(strings.ToLower(setCode))
 }
@@ -15,7 +15,9 @@ func init() {
 }
 
 func TestSetup(t *testing.T) {
+	setsMutex.Lock()
 	ok := setUp()
+	setsMutex.Unlock()
 	assert.True(t, ok)
 }
 
@@ -4,7 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -19,8 +22,20 @@ const (
 	OptionTypeBool
 	// OptionTypeInt represents an integer option.
 	OptionTypeInt
+	// OptionTypeString represents a free text option.
+	OptionTypeString
+	// OptionTypeFloat represents a decimal number option.
+	OptionTypeFloat
+	// OptionTypeList represents an option with several comma-separated
+	// values, restricted to AllowedValues if it is set.
+	OptionTypeList
+	// OptionTypePath represents a file or folder path option.
+	OptionTypePath
 )
 
+// listSeparator separates the values of an OptionTypeList option.
+const listSeparator = ","
+
 // String representation of an OptionType.
 func (ot OptionType) String() string {
 	switch ot {
@@ -30,6 +45,14 @@ func (ot OptionType) String() string {
 		return "bool"
 	case OptionTypeInt:
 		return "int"
+	case OptionTypeString:
+		return "string"
+	case OptionTypeFloat:
+		return "float"
+	case OptionTypeList:
+		return "list"
+	case OptionTypePath:
+		return "path"
 	default:
 		return "unknown"
 	}
@@ -42,60 +65,235 @@ type Option struct {
 	// Description of the option.
 	Description string
 	// DefaultValue is the value the option is set to if no value is provided
-	// by the user.
+	// by the user. Its type should match the one of the normalized values
+	// (string for enum, string and path options, []string for list
+	// options, float64 for float options).
 	DefaultValue interface{}
-	// AllowedValues should only be set when Type is OptionTypeEnum
+	// AllowedValues should only be set when Type is OptionTypeEnum or
+	// OptionTypeList.
 	AllowedValues []string
+	// Required is true if the option has to be set by the user.
+	Required bool
+	// Requires lists the options which have to be set (or enabled, for
+	// boolean options) when this option is set by the user.
+	Requires []string
 }
 
 // Options is a map of option IDs to option.
 type Options map[string]Option
 
+// Validate checks the names and the values of plugin options, without
+// checking the required options and the dependencies between options. It is
+// used for partial settings (e.g. a profile of the configuration file),
+// which are completed by other settings before the conversion.
+func (o Options) Validate(options map[string]string) error {
+	_, _, err := o.normalizeValues(options)
+	return err
+}
+
 // ValidateNormalize validates plugin options entered by the user and normalizes
-// their values.
-func (o Options) ValidateNormalize(options map[string]string) (map[string]interface{}, error) {
-	output := make(map[string]interface{})
+// their values. The options which aren't set are set to their default value.
+func (o Options) ValidateNormalize(options map[string]string) (OptionValues, error) {
+	output, keys, err := o.normalizeValues(options)
+	if err != nil {
+		return output, err
+	}
+
+	// The dependencies are checked against the options set by the user,
+	// before the default values are added
+	for _, key := range keys {
+		for _, dependency := range o[key].Requires {
+			if _, found := options[dependency]; !found {
+				return output, fmt.Errorf("option %s requires option %s to be set", key, dependency)
+			}
+			if enabled, ok := output[dependency].(bool); ok && !enabled {
+				return output, fmt.Errorf("option %s requires option %s to be enabled", key, dependency)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, found := output[name]; found {
+			continue
+		}
+		option := o[name]
+		if option.Required {
+			return output, fmt.Errorf("option %s is required", name)
+		}
+		if option.DefaultValue != nil {
+			output[name] = option.DefaultValue
+		}
+	}
+
+	return output, nil
+}
+
+// normalizeValues normalizes the values of the options set by the user, and
+// returns them with their sorted names.
+func (o Options) normalizeValues(options map[string]string) (OptionValues, []string, error) {
+	output := make(OptionValues)
 
-	for key, value := range options {
+	// Sort the keys so that the errors are consistent
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
 		option, found := o[key]
 		if !found {
-			return output, fmt.Errorf("invalid option: %s", key)
+			return output, keys, fmt.Errorf("invalid option: %s", key)
 		}
 
-		switch option.Type {
-		case OptionTypeBool:
-			// Try to convert to bool
-			if parsed, err := strconv.ParseBool(value); err == nil {
-				output[key] = parsed
+		value, err := option.normalize(key, options[key])
+		if err != nil {
+			return output, keys, err
+		}
+		output[key] = value
+	}
+
+	return output, keys, nil
+}
+
+// normalize converts the value of the option key entered by the user.
+func (option Option) normalize(key, value string) (interface{}, error) {
+	switch option.Type {
+	case OptionTypeBool:
+		// Try to convert to bool
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed, nil
+		}
+		lower := strings.ToLower(value)
+		return lower == "on" || lower == "yes" || lower == "y", nil
+	case OptionTypeInt:
+		// Try to convert to int
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't convert option %s value (%s) to int", key, value)
+		}
+		return parsed, nil
+	case OptionTypeFloat:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't convert option %s value (%s) to float", key, value)
+		}
+		return parsed, nil
+	case OptionTypeEnum:
+		if option.AllowedValues == nil {
+			return nil, fmt.Errorf("no allowed values set for option %s", key)
+		}
+		if err := option.checkAllowed(key, value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	case OptionTypeList:
+		values := []string{}
+		for _, element := range strings.Split(value, listSeparator) {
+			element = strings.TrimSpace(element)
+			if len(element) == 0 {
 				continue
 			}
-			lower := strings.ToLower(value)
-			output[key] = lower == "on" || lower == "yes" || lower == "y"
-		case OptionTypeInt:
-			// Try to convert to int
-			parsed, err := strconv.Atoi(value)
-			if err != nil {
-				return output, fmt.Errorf("couldn't convert option %s value (%s) to int", key, value)
-			}
-			output[key] = parsed
-		case OptionTypeEnum:
-			// Try to convert to int
-			if option.AllowedValues == nil {
-				return output, fmt.Errorf("no allowed values set for option %s", key)
+			if option.AllowedValues != nil {
+				if err := option.checkAllowed(key, element); err != nil {
+					return nil, err
+				}
 			}
-			if IndexOf(value, option.AllowedValues) < 0 {
-				return output, fmt.Errorf(
-					"invalid value set for option %s: %s (allowed values are %s)",
-					key,
-					value,
-					strings.Join(option.AllowedValues, ", "),
-				)
+			values = append(values, element)
+		}
+		return values, nil
+	case OptionTypePath:
+		if len(value) == 0 {
+			return nil, fmt.Errorf("empty path set for option %s", key)
+		}
+		if value == "~" || strings.HasPrefix(value, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("couldn't expand option %s value (%s): %w", key, value, err)
 			}
-			output[key] = value
+			value = filepath.Join(home, value[1:])
 		}
+		return filepath.Clean(value), nil
+	default:
+		return value, nil
 	}
+}
 
-	return output, nil
+// checkAllowed returns an error if value isn't one of the allowed values of
+// the option key.
+func (option Option) checkAllowed(key, value string) error {
+	if IndexOf(value, option.AllowedValues) < 0 {
+		return fmt.Errorf(
+			"invalid value set for option %s: %s (allowed values are %s)",
+			key,
+			value,
+			strings.Join(option.AllowedValues, ", "),
+		)
+	}
+
+	return nil
+}
+
+// OptionValues are the plugin option values normalized by
+// Options.ValidateNormalize. The getters return the zero value of their type
+// if the option isn't set or has another type.
+type OptionValues map[string]interface{}
+
+// String returns the value of an enum, string or path option.
+func (v OptionValues) String(key string) string {
+	value, _ := v[key].(string)
+	return value
+}
+
+// Bool returns the value of a boolean option.
+func (v OptionValues) Bool(key string) bool {
+	value, _ := v[key].(bool)
+	return value
+}
+
+// Int returns the value of an integer option.
+func (v OptionValues) Int(key string) int {
+	value, _ := v[key].(int)
+	return value
+}
+
+// Float returns the value of a float option.
+func (v OptionValues) Float(key string) float64 {
+	switch value := v[key].(type) {
+	case float64:
+		return value
+	case int:
+		return float64(value)
+	default:
+		return 0
+	}
+}
+
+// List returns the values of a list option.
+func (v OptionValues) List(key string) []string {
+	value, _ := v[key].([]string)
+	return value
+}
+
+// FormatOptionValue returns the text representation of a normalized option
+// value, as it would be entered by the user.
+func FormatOptionValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, listSeparator)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
 }
 
 // Back represents the back of a card.
@@ -155,20 +353,67 @@ type Plugin interface {
 	AvailableBacks() map[string]Back
 }
 
+// ExportHandler writes decks back to a text decklist.
+// The decks are the ones generated by the plugin from a single decklist
+// (e.g. a main deck followed by its sideboard).
+type ExportHandler func(w io.Writer, decks []*Deck) error
+
+// Exporter is implemented by the plugins which can write decks back to text
+// decklists.
+type Exporter interface {
+	// ExportHandlers returns the list of decklist formats supported by the
+	// plugin and their writing functions.
+	ExportHandlers() map[string]ExportHandler
+	// GenericExportHandler returns the writing function whose output can be
+	// parsed by GenericFileHandler.
+	GenericExportHandler() ExportHandler
+}
+
+// Detector is implemented by the plugins which can recognize their
+// decklists from their content, when the file extension doesn't tell the
+// plugin apart (e.g. with stdin or .txt files).
+type Detector interface {
+	// DetectDeckType parses the content of a decklist, without retrieving
+	// its cards, and returns its format (a key of DeckTypeHandlers, or an
+	// empty string for the generic format) and how confident the plugin is
+	// that it can parse it, from 0 to 1.
+	DetectDeckType(content []byte) (format string, confidence float64)
+}
+
 // Template represents a TTS file template.
 // See https://berserk-games.com/knowledgebase/custom-decks/.
 type Template struct {
 	URL     string
 	NumCols int
 	NumRows int
+	// BackURL is the URL of the back sheet of the template, containing the
+	// back of each card in the same position as its face.
+	// Only set if at least one of the cards has its own back.
+	BackURL string
 }
 
 // TemplateInfo maps card image URLs to templates.
 type TemplateInfo struct {
-	// ImageURLCardIDMap is a map between card image URLs and card IDs.
+	// ImageURLCardIDMap is a map between card images and card IDs, keyed by
+	// TemplateImageKey.
 	ImageURLCardIDMap map[string]int
 	// Templates is a map of template ID to template.
 	Templates map[int]*Template
+	// FileName is the name (without extension) chosen for the files of the
+	// deck when its templates were generated, so that the deck is saved
+	// under the name of its templates. Empty if it hasn't been chosen yet.
+	FileName string
+}
+
+// TemplateImageKey returns the key of a card face in
+// TemplateInfo.ImageURLCardIDMap. Faces with the same image but different
+// backs have their own card ID, since the back is part of the template.
+func TemplateImageKey(imageURL string, backImageURL string) string {
+	if len(backImageURL) == 0 {
+		return imageURL
+	}
+
+	return imageURL + "\x00" + backImageURL
 }
 
 // GetAssociatedTemplate returns the template containing the image of the
@@ -198,6 +443,11 @@ type CardInfo struct {
 	Description string
 	// ImageURL is the URL of the card image
 	ImageURL string
+	// BackImageURL is the URL of the back of the card, if it differs from
+	// the back of the rest of the deck
+	// Used for double-faced cards that can be flipped instead of using
+	// states, or games where each card has its own back
+	BackImageURL string
 	// Count is the amount of this card in the current deck
 	Count int
 	// AlternativeState is used for double-faced cards (transforms and melds
@@ -207,8 +457,30 @@ type CardInfo struct {
 	// Oversized card
 	// Used for plane, scheme or meld results in MTG
 	Oversized bool
+	// Sideways is set for landscape cards, which TTS will then rotate when
+	// displaying them in hand or when hovering over them.
+	// Used for split cards, rooms, battles and planes in MTG, or crests in
+	// Vanguard
+	Sideways bool
+	// Metadata contains plugin-specific information about the card (e.g. its
+	// set), used to find the same card again when refreshing a saved object.
+	// It is saved in the GM notes of the card.
+	Metadata map[string]string
 }
 
+// Keys of the card metadata shared by the plugins.
+const (
+	// MetadataSet is the code of the set of the printing of a card.
+	MetadataSet = "set"
+	// MetadataNumber is the collector number of the printing of a card in
+	// its set.
+	MetadataNumber = "number"
+	// MetadataID identifies a card in the source of the plugin (e.g. its ID
+	// in a card database, or the image file of a custom card), for the cards
+	// which don't have a set.
+	MetadataID = "id"
+)
+
 // CardSize is the size format of a card
 type CardSize int
 
@@ -217,6 +489,26 @@ const (
 	CardSizeStandard CardSize = iota
 	// CardSizeSmall is the size of a Yu-Gi-Oh or Cardfight!! Vanguard card
 	CardSizeSmall
+	// CardSizeTarot is the size of a tarot card (70×120mm)
+	CardSizeTarot
+	// CardSizeMini is the size of a mini Euro card (44×68mm)
+	CardSizeMini
+	// CardSizeSquare is the size of a square card (70×70mm)
+	CardSizeSquare
+	// CardSizeOversized is the size of an oversized 5×7 inches card
+	CardSizeOversized
+)
+
+// CardShape is the shape of the cards in a deck
+type CardShape int
+
+const (
+	// CardShapeRectangle is the shape of standard cards
+	CardShapeRectangle CardShape = iota
+	// CardShapeHex is the shape of hexagonal tiles
+	CardShapeHex
+	// CardShapeCircle is the shape of round tokens
+	CardShapeCircle
 )
 
 // Deck contains the information about a deck used to build it in TTS.
@@ -226,6 +518,16 @@ type Deck struct {
 	BackURL      string
 	TemplateInfo *TemplateInfo
 	CardSize     CardSize
+	Shape        CardShape
+	// Rounded is set if the corners of rectangle and hex cards are rounded
 	Rounded      bool
 	ThumbnailURL string
+	// ThumbnailURLs lists the images of the representative cards of the deck,
+	// fanned out in its thumbnail
+	// Takes precedence over ThumbnailURL when it contains several images
+	ThumbnailURLs []string
+	// Plugin is the ID of the plugin which parsed the deck
+	Plugin string
+	// Source is the URL or file the deck has been parsed from
+	Source string
 }
@@ -0,0 +1,145 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testOptions = Options{
+	"quality": Option{
+		Type:          OptionTypeEnum,
+		AllowedValues: []string{"small", "large"},
+		DefaultValue:  "small",
+	},
+	"rulings": Option{
+		Type:         OptionTypeBool,
+		DefaultValue: false,
+	},
+	"count": Option{
+		Type: OptionTypeInt,
+	},
+	"scale": Option{
+		Type:         OptionTypeFloat,
+		DefaultValue: 1.0,
+	},
+	"query": Option{
+		Type:     OptionTypeString,
+		Requires: []string{"rulings"},
+	},
+	"boards": Option{
+		Type:          OptionTypeList,
+		AllowedValues: []string{"main", "side", "maybe"},
+		DefaultValue:  []string{"main"},
+	},
+	"images": Option{
+		Type: OptionTypePath,
+	},
+}
+
+func TestValidateNormalizeDefaults(t *testing.T) {
+	options, err := testOptions.ValidateNormalize(map[string]string{})
+	assert.Nil(t, err)
+	assert.Equal(t, OptionValues{
+		"quality": "small",
+		"rulings": false,
+		"scale":   1.0,
+		"boards":  []string{"main"},
+	}, options)
+
+	assert.Equal(t, "small", options.String("quality"))
+	assert.False(t, options.Bool("rulings"))
+	assert.Equal(t, 0, options.Int("count"))
+	assert.Equal(t, 1.0, options.Float("scale"))
+	assert.Equal(t, []string{"main"}, options.List("boards"))
+	assert.Equal(t, "", options.String("images"))
+}
+
+func TestValidateNormalize(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	options, err := testOptions.ValidateNormalize(map[string]string{
+		"quality": "large",
+		"rulings": "yes",
+		"count":   "3",
+		"scale":   "0.5",
+		"query":   "t:instant cmc=1",
+		"boards":  "main, side,",
+		"images":  "~/cards/",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "large", options.String("quality"))
+	assert.True(t, options.Bool("rulings"))
+	assert.Equal(t, 3, options.Int("count"))
+	assert.Equal(t, 0.5, options.Float("scale"))
+	assert.Equal(t, "t:instant cmc=1", options.String("query"))
+	assert.Equal(t, []string{"main", "side"}, options.List("boards"))
+	assert.Equal(t, filepath.Join(home, "cards"), options.String("images"))
+}
+
+func TestValidateNormalizeErrors(t *testing.T) {
+	for message, options := range map[string]map[string]string{
+		"invalid option: unknown":                                                            {"unknown": "1"},
+		"couldn't convert option count value (a) to int":                                     {"count": "a"},
+		"couldn't convert option scale value (a) to float":                                   {"scale": "a"},
+		"invalid value set for option quality: png (allowed values are small, large)":        {"quality": "png"},
+		"invalid value set for option boards: tokens (allowed values are main, side, maybe)": {"boards": "main,tokens"},
+		"empty path set for option images":                                                   {"images": ""},
+		"option query requires option rulings to be set":                                     {"query": "a"},
+		"option query requires option rulings to be enabled":                                 {"query": "a", "rulings": "no"},
+	} {
+		_, err := testOptions.ValidateNormalize(options)
+		assert.EqualError(t, err, message)
+	}
+
+	_, err := testOptions.ValidateNormalize(map[string]string{"query": "a", "rulings": "true"})
+	assert.Nil(t, err)
+
+	// The first missing option is reported, in alphabetical order
+	required := Options{
+		"deck":   Option{Type: OptionTypeString, Required: true},
+		"author": Option{Type: OptionTypeString, Required: true},
+	}
+	for i := 0; i < 10; i++ {
+		_, err = required.ValidateNormalize(map[string]string{})
+		assert.EqualError(t, err, "option author is required")
+	}
+
+	// The default value of a dependency doesn't count
+	dependencies := Options{
+		"rulings": Option{Type: OptionTypeBool, DefaultValue: true},
+		"query":   Option{Type: OptionTypeString, Requires: []string{"rulings"}},
+	}
+	_, err = dependencies.ValidateNormalize(map[string]string{"query": "a"})
+	assert.EqualError(t, err, "option query requires option rulings to be set")
+}
+
+func TestFormatOptionValue(t *testing.T) {
+	for value, expected := range map[interface{}]string{
+		"small": "small",
+		false:   "false",
+		3:       "3",
+		0.5:     "0.5",
+	} {
+		assert.Equal(t, expected, FormatOptionValue(value))
+	}
+	assert.Equal(t, "main,side", FormatOptionValue([]string{"main", "side"}))
+
+	// The formatted default values are valid option values
+	for name, option := range testOptions {
+		if option.DefaultValue == nil {
+			continue
+		}
+		options, err := testOptions.ValidateNormalize(map[string]string{
+			name: FormatOptionValue(option.DefaultValue),
+		})
+		if assert.Nil(t, err) {
+			assert.Equal(t, option.DefaultValue, options[name])
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package plugins
 
 import (
+	"bufio"
+	"bytes"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"unicode"
@@ -19,6 +22,68 @@ func IndexOf(element string, data []string) int {
 	return -1
 }
 
+// BaseCardName returns the name of a card without the additional information
+// some plugins append to it (e.g. the mana value and type line of Magic
+// cards), which is found after the first line.
+func BaseCardName(name string) string {
+	return strings.TrimSpace(strings.SplitN(name, "\n", 2)[0])
+}
+
+// MainBoard is the board of the decks which don't have a board suffix.
+const MainBoard = "Main"
+
+// boards are the names of the boards the plugins append to the deck names
+// (e.g. "Burn - Sideboard").
+var boards = []string{"Sideboard", "Maybeboard", "Tokens", "Extra", "Side", "G deck"}
+
+// SplitBoard splits the name of a deck into the name of the decklist and
+// the board appended by the plugin (MainBoard if there is none).
+func SplitBoard(deckName string) (name, board string) {
+	for _, b := range boards {
+		if suffix := " - " + b; strings.HasSuffix(deckName, suffix) {
+			return strings.TrimSuffix(deckName, suffix), b
+		}
+	}
+
+	return deckName, MainBoard
+}
+
+// DecklistLines returns the lines of a decklist, trimmed, without the empty
+// lines and the comments (starting with "//"), e.g. to score it in
+// Detector.DetectDeckType.
+func DecklistLines(content []byte) []string {
+	var lines []string
+
+	// Remove the UTF-8 BOM, if present
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(content, []byte("\uFEFF"))))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "//") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// CountMatches returns the number of lines matched by at least one of the
+// regular expressions.
+func CountMatches(lines []string, regexps ...*regexp.Regexp) int {
+	count := 0
+
+	for _, line := range lines {
+		for _, regex := range regexps {
+			if regex.MatchString(line) {
+				count++
+				break
+			}
+		}
+	}
+
+	return count
+}
+
 // CapitalizeString puts the first letter of a string in uppercase.
 func CapitalizeString(s string) string {
 	a := []rune(s)
@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"regexp"
 	"runtime"
 	"testing"
 
@@ -14,6 +15,35 @@ func TestIndexOf(t *testing.T) {
 	assert.Equal(t, -1, IndexOf("", []string{"a", "b", "c"}))
 }
 
+func TestSplitBoard(t *testing.T) {
+	name, board := SplitBoard("Burn - Sideboard")
+	assert.Equal(t, "Burn", name)
+	assert.Equal(t, "Sideboard", board)
+
+	name, board = SplitBoard("Starter Deck - Codebreaker - Extra")
+	assert.Equal(t, "Starter Deck - Codebreaker", name)
+	assert.Equal(t, "Extra", board)
+
+	name, board = SplitBoard("Starter Deck - Codebreaker")
+	assert.Equal(t, "Starter Deck - Codebreaker", name)
+	assert.Equal(t, MainBoard, board)
+}
+
+func TestDecklistLines(t *testing.T) {
+	lines := DecklistLines([]byte("\uFEFF4 Lightning Bolt\r\n\n// Comment\n  Sideboard  \n2 Path to Exile\n"))
+	assert.Equal(t, []string{"4 Lightning Bolt", "Sideboard", "2 Path to Exile"}, lines)
+
+	assert.Empty(t, DecklistLines(nil))
+}
+
+func TestCountMatches(t *testing.T) {
+	lines := []string{"4 Lightning Bolt", "Sideboard", "2 Path to Exile (CON) 15"}
+
+	assert.Equal(t, 2, CountMatches(lines, regexp.MustCompile(`^\d+ `)))
+	assert.Equal(t, 3, CountMatches(lines, regexp.MustCompile(`^\d+ `), regexp.MustCompile(`^Sideboard$`)))
+	assert.Equal(t, 0, CountMatches(lines))
+}
+
 func TestCapitalizeString(t *testing.T) {
 	assert.Equal(t, "Test", CapitalizeString("test"))
 	assert.Equal(t, "TEST", CapitalizeString("TEST"))
@@ -1,14 +1,29 @@
 package vanguard
 
 import (
+	"strconv"
 	"time"
 
+	"github.com/jeandeaual/tts-deckconverter/plugins"
 	"github.com/jeandeaual/tts-deckconverter/plugins/vanguard/cardfightwiki"
 )
 
 var rateLimiter = time.NewTicker(100 * time.Millisecond)
 
+// lookupCache keeps the cards retrieved from the Cardfight!! Vanguard Wiki.
+var lookupCache = plugins.NewLookupCache()
+
 func getCard(name string, preferPremium bool) (cardfightwiki.Card, error) {
+	key := plugins.LookupKey(name, strconv.FormatBool(preferPremium))
+	if card, found := lookupCache.Get(key); found {
+		return card.(cardfightwiki.Card), nil
+	}
+
 	<-rateLimiter.C
-	return cardfightwiki.GetCard(name, preferPremium)
+	card, err := cardfightwiki.GetCard(name, preferPremium)
+	if err == nil {
+		lookupCache.Set(key, card)
+	}
+
+	return card, err
 }
@@ -3,6 +3,7 @@ package cardfightwiki
 import (
 	"fmt"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -38,9 +39,14 @@ var (
 	formatsXPath          *xpath.Expr
 	flavorXPath           *xpath.Expr
 	effectXPath           *xpath.Expr
+	setsXPath             *xpath.Expr
 	hrefXPath             *xpath.Expr
 )
 
+// setCodeRegex matches the code of a printing in the sets of a card, e.g.
+// "Booster Set 1: Descent of the King of Knights - BT01/001EN (RRR)".
+var setCodeRegex = regexp.MustCompile(`\b([A-Za-z0-9-]+/[A-Za-z0-9-]+)\s*(?:\(|$)`)
+
 func init() {
 	searchResultLinkXPath = xpath.MustCompile(`//a[contains(@class,'unified-search__result__title')]`)
 	englishImageURLXPath = xpath.MustCompile(`//span[contains(@class,'English')]/a/@href`)
@@ -61,6 +67,7 @@ func init() {
 	formatsXPath = xpath.MustCompile(`//td[normalize-space(text())='Format']/following-sibling::node()[2]`)
 	flavorXPath = xpath.MustCompile(`//table[contains(@class,'flavor')]//td`)
 	effectXPath = xpath.MustCompile(`//table[contains(@class,'effect')]//td`)
+	setsXPath = xpath.MustCompile(`//table[contains(@class,'sets')]//li`)
 	hrefXPath = xpath.MustCompile(`/@href`)
 }
 
@@ -269,6 +276,16 @@ func getCardNames(cardPage *html.Node, cardPageURL string, card *Card) error {
 	return nil
 }
 
+// getCardSets reads the codes of the printings of the card.
+func getCardSets(cardPage *html.Node, card *Card) {
+	for _, set := range htmlquery.QuerySelectorAll(cardPage, setsXPath) {
+		matches := setCodeRegex.FindStringSubmatch(strings.TrimSpace(htmlquery.InnerText(set)))
+		if matches != nil {
+			card.Sets = append(card.Sets, matches[1])
+		}
+	}
+}
+
 // GetCard retrieves a card's information from https://cardfight.fandom.com/
 func GetCard(cardName string, preferPremium bool) (Card, error) {
 	var card Card
@@ -366,5 +383,7 @@ func GetCard(cardName string, preferPremium bool) (Card, error) {
 		card.Effect = &effectText
 	}
 
+	getCardSets(cardPage, &card)
+
 	return card, nil
 }
@@ -0,0 +1,29 @@
+package cardfightwiki
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCardSets(t *testing.T) {
+	cardPage, err := htmlquery.Parse(strings.NewReader(`<html><body>
+<table class="sets"><tbody>
+<tr><th>Sets</th></tr>
+<tr><td><ul>
+<li><a href="/wiki/BT01">Booster Set 1: Descent of the King of Knights</a> - BT01/001 (RRR)</li>
+<li><a href="/wiki/BT01EN">Booster Set 1: Descent of the King of Knights</a> - BT01/001EN (RRR)</li>
+<li><a href="/wiki/Promo">Promo Cards</a></li>
+</ul></td></tr>
+</tbody></table>
+</body></html>`))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	var card Card
+	getCardSets(cardPage, &card)
+	assert.Equal(t, []string{"BT01/001", "BT01/001EN"}, card.Sets)
+}
@@ -20,4 +20,7 @@ type Card struct {
 	Effect           *string
 	EnglishImageURL  string
 	JapaneseImageURL string
+	// Sets are the codes of the printings of the card (e.g. "BT01/001EN"),
+	// in the order of the wiki.
+	Sets []string
 }
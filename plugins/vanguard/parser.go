@@ -2,6 +2,7 @@ package vanguard
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"regexp"
@@ -23,6 +24,9 @@ var cardLineRegexps = []*regexp.Regexp{
 	regexp.MustCompile(`^-?\s*(?P<Count>\d+)x?\s+(?P<Name>.+)$`),
 }
 
+// deckSize is the number of cards of a Vanguard main deck.
+const deckSize = 50
+
 // CardNames contains the card names and their count.
 type CardNames struct {
 	// Names are the card names.
@@ -73,7 +77,7 @@ func (c *CardNames) String() string {
 	return sb.String()
 }
 
-func cardNamesToDeck(cards *CardNames, name string, options map[string]interface{}) (*plugins.Deck, *plugins.Deck, *plugins.Deck, error) {
+func cardNamesToDeck(cards *CardNames, name string, options plugins.OptionValues) (*plugins.Deck, *plugins.Deck, *plugins.Deck, error) {
 	deck := &plugins.Deck{
 		Name:     name,
 		BackURL:  VanguardPlugin.AvailableBacks()[plugins.DefaultBackKey].URL,
@@ -85,20 +89,9 @@ func cardNamesToDeck(cards *CardNames, name string, options map[string]interface
 		tokens *plugins.Deck
 	)
 
-	cardLanguage := VanguardPlugin.AvailableOptions()["lang"].DefaultValue.(string)
-	if option, found := options["lang"]; found {
-		cardLanguage = option.(string)
-	}
-
-	vanguardFirst := VanguardPlugin.AvailableOptions()["vanguard-first"].DefaultValue.(bool)
-	if option, found := options["vanguard-first"]; found {
-		vanguardFirst = option.(bool)
-	}
-
-	preferPremium := VanguardPlugin.AvailableOptions()["prefer-premium"].DefaultValue.(bool)
-	if option, found := options["prefer-premium"]; found {
-		preferPremium = option.(bool)
-	}
+	cardLanguage := options.String("lang")
+	vanguardFirst := options.Bool("vanguard-first")
+	preferPremium := options.Bool("prefer-premium")
 
 	for _, cardName := range cards.Names {
 		count := cards.Count(cardName)
@@ -120,7 +113,10 @@ func cardNamesToDeck(cards *CardNames, name string, options map[string]interface
 		cardInfo := plugins.CardInfo{
 			Description: buildCardDescription(card),
 			Count:       count,
+			// Crests are printed in landscape orientation
+			Sideways: card.Type != nil && *card.Type == "Crest",
 		}
+		imageLanguage := "en"
 		if cardLanguage == "en" {
 			cardInfo.Name = card.EnglishName
 			cardInfo.ImageURL = card.EnglishImageURL
@@ -128,10 +124,12 @@ func cardNamesToDeck(cards *CardNames, name string, options map[string]interface
 			cardInfo.Name = card.JapaneseName
 			if len(card.JapaneseImageURL) > 0 {
 				cardInfo.ImageURL = card.JapaneseImageURL
+				imageLanguage = cardLanguage
 			} else {
 				cardInfo.ImageURL = card.EnglishImageURL
 			}
 		}
+		cardInfo.Metadata = cardMetadata(card, imageLanguage)
 
 		if card.Type != nil && *card.Type == "G Unit" {
 			if gdeck == nil {
@@ -270,6 +268,42 @@ func parseDeckFile(file io.Reader) (*CardNames, error) {
 	return main, nil
 }
 
+// detectDeckFile returns how confident we are that content is a Vanguard
+// decklist.
+func detectDeckFile(content []byte) float64 {
+	lines := plugins.DecklistLines(content)
+	if len(lines) == 0 {
+		return 0
+	}
+
+	main, err := parseDeckFile(bytes.NewReader(content))
+	if err != nil || main == nil {
+		return 0
+	}
+
+	// Lines like "4x Blaster Blade" are used by the decklists of most card
+	// games
+	confidence := 0.3 * float64(plugins.CountMatches(lines, cardLineRegexps...)) / float64(len(lines))
+
+	// Some sites list the cards with a leading dash
+	for _, line := range lines {
+		if strings.HasPrefix(line, "-") {
+			confidence += 0.3
+			break
+		}
+	}
+
+	count := 0
+	for _, cardCount := range main.Counts {
+		count += cardCount
+	}
+	if count == deckSize {
+		confidence += 0.2
+	}
+
+	return confidence
+}
+
 var (
 	japaneseMainDivXPath  *xpath.Expr
 	englishMainDivXPath   *xpath.Expr
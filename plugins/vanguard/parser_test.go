@@ -69,3 +69,19 @@ Grade 0:
 	assert.Equal(t, expected, main)
 	assert.Nil(t, err)
 }
+
+func TestDetectDeckType(t *testing.T) {
+	format, confidence := VanguardPlugin.DetectDeckType([]byte(VanguardPlugin.GenericFileHandler().Example))
+	assert.Equal(t, "", format)
+	assert.InDelta(t, 0.3, confidence, 0.001)
+
+	// A full main deck
+	_, confidence = VanguardPlugin.DetectDeckType([]byte(strings.Repeat("4x Blaster Blade\n", 12) + "2x Wingal"))
+	assert.InDelta(t, 0.5, confidence, 0.001)
+
+	_, confidence = VanguardPlugin.DetectDeckType([]byte("- 4 Alfred Early\n- 4 Stardrive Dragon"))
+	assert.InDelta(t, 0.6, confidence, 0.001)
+
+	_, confidence = VanguardPlugin.DetectDeckType(nil)
+	assert.Equal(t, 0.0, confidence)
+}
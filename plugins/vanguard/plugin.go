@@ -83,6 +83,10 @@ func (p vanguardPlugin) GenericFileHandler() plugins.DeckType {
 	}
 }
 
+func (p vanguardPlugin) DetectDeckType(content []byte) (string, float64) {
+	return "", detectDeckFile(content)
+}
+
 func (p vanguardPlugin) AvailableBacks() map[string]plugins.Back {
 	return map[string]plugins.Back{
 		plugins.DefaultBackKey: {
@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/jeandeaual/tts-deckconverter/plugins"
 	"github.com/jeandeaual/tts-deckconverter/plugins/vanguard/cardfightwiki"
 )
 
@@ -63,3 +64,30 @@ func buildCardDescription(card cardfightwiki.Card) string {
 
 	return sb.String()
 }
+
+// cardMetadata returns the metadata of the card, with the set and number of
+// its first printing in the language of its image (e.g. "BT01/001EN" for an
+// English card).
+func cardMetadata(card cardfightwiki.Card, language string) map[string]string {
+	if len(card.Sets) == 0 {
+		return nil
+	}
+
+	code := card.Sets[0]
+	for _, set := range card.Sets {
+		if strings.HasSuffix(set, "EN") == (language == "en") {
+			code = set
+			break
+		}
+	}
+
+	split := strings.SplitN(code, "/", 2)
+	if len(split) != 2 {
+		return nil
+	}
+
+	return map[string]string{
+		plugins.MetadataSet:    split[0],
+		plugins.MetadataNumber: split[1],
+	}
+}
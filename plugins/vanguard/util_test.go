@@ -5,6 +5,7 @@ import (
 	"testing"
 	"unicode"
 
+	"github.com/jeandeaual/tts-deckconverter/plugins"
 	"github.com/jeandeaual/tts-deckconverter/plugins/vanguard/cardfightwiki"
 	"github.com/stretchr/testify/assert"
 )
@@ -33,3 +34,17 @@ func TestBuildCardDescription(t *testing.T) {
 		Formats: []string{"Premium Standard"},
 	}))
 }
+
+func TestCardMetadata(t *testing.T) {
+	card := cardfightwiki.Card{Sets: []string{"BT01/001", "BT01/001EN", "V-BT01/SP01"}}
+
+	assert.Equal(t, map[string]string{
+		plugins.MetadataSet:    "BT01",
+		plugins.MetadataNumber: "001EN",
+	}, cardMetadata(card, "en"))
+	assert.Equal(t, map[string]string{
+		plugins.MetadataSet:    "BT01",
+		plugins.MetadataNumber: "001",
+	}, cardMetadata(card, "ja"))
+	assert.Nil(t, cardMetadata(cardfightwiki.Card{}, "en"))
+}
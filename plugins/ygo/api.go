@@ -1,20 +1,43 @@
 package ygo
 
 import (
+	"strconv"
 	"time"
 
+	"github.com/jeandeaual/tts-deckconverter/plugins"
 	"github.com/jeandeaual/tts-deckconverter/plugins/ygo/api"
 )
 
 // See https://db.ygoprodeck.com/api-guide/
 var rateLimiter = time.NewTicker(50 * time.Millisecond)
 
+// lookupCache keeps the cards retrieved from YGOPRODeck.
+var lookupCache = plugins.NewLookupCache()
+
 func queryID(id int64, format api.Format) (api.Data, error) {
-	<-rateLimiter.C
-	return api.QueryID(id, format)
+	return query(plugins.LookupKey("id", strconv.FormatInt(id, 10), string(format)), func() (api.Data, error) {
+		return api.QueryID(id, format)
+	})
 }
 
 func queryName(name string, format api.Format) (api.Data, error) {
+	return query(plugins.LookupKey("name", name, string(format)), func() (api.Data, error) {
+		return api.QueryName(name, format)
+	})
+}
+
+// query returns the cached result of key, or waits for the rate limiter
+// and calls the API.
+func query(key string, call func() (api.Data, error)) (api.Data, error) {
+	if data, found := lookupCache.Get(key); found {
+		return data.(api.Data), nil
+	}
+
 	<-rateLimiter.C
-	return api.QueryName(name, format)
+	data, err := call()
+	if err == nil {
+		lookupCache.Set(key, data)
+	}
+
+	return data, err
 }
@@ -0,0 +1,138 @@
+package ygo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+const (
+	extraSuffix  = " - Extra"
+	sideSuffix   = " - Side"
+	tokensSuffix = " - Tokens"
+)
+
+// imageIDRegex extracts the card ID (passcode) from the name of a card image
+// (e.g. https://images.ygoprodeck.com/images/cards/46986414.jpg).
+var imageIDRegex = regexp.MustCompile(`^(\d+)\.[A-Za-z]+$`)
+
+// cardID returns the ID of a card, found in the URL of its image, or in its
+// metadata (e.g. when its image is part of a template).
+func cardID(card plugins.CardInfo) (string, error) {
+	u, err := url.Parse(card.ImageURL)
+	if err == nil {
+		if matches := imageIDRegex.FindStringSubmatch(path.Base(u.Path)); matches != nil {
+			return matches[1], nil
+		}
+	}
+
+	if id := card.Metadata[plugins.MetadataID]; len(id) > 0 {
+		return id, nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("invalid image URL for card %s: %w", card.Name, err)
+	}
+
+	return "", fmt.Errorf("couldn't find the ID of card %s in %s", card.Name, card.ImageURL)
+}
+
+func writeCardIDs(w *bufio.Writer, cards []plugins.CardInfo) error {
+	for _, card := range cards {
+		id, err := cardID(card)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < card.Count; i++ {
+			if _, err := w.WriteString(id + "\n"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// splitDecks returns the main, extra and side deck cards, based on the names
+// of the decks generated by the plugin. Tokens are skipped.
+func splitDecks(decks []*plugins.Deck) (main, extra, side []plugins.CardInfo) {
+	for _, deck := range decks {
+		switch {
+		case strings.HasSuffix(deck.Name, extraSuffix):
+			extra = append(extra, deck.Cards...)
+		case strings.HasSuffix(deck.Name, sideSuffix):
+			side = append(side, deck.Cards...)
+		case strings.HasSuffix(deck.Name, tokensSuffix):
+			continue
+		default:
+			main = append(main, deck.Cards...)
+		}
+	}
+
+	return
+}
+
+// exportYDK writes the decks in the YGOPro format, with one card ID per
+// copy of the card. Tokens are skipped.
+func exportYDK(w io.Writer, decks []*plugins.Deck) error {
+	main, extra, side := splitDecks(decks)
+	bw := bufio.NewWriter(w)
+
+	sections := []struct {
+		header string
+		cards  []plugins.CardInfo
+	}{
+		{"#created by tts-deckconverter\n#main\n", main},
+		{"#extra\n", extra},
+		{"!side\n", side},
+	}
+	for _, section := range sections {
+		if _, err := bw.WriteString(section.header); err != nil {
+			return err
+		}
+		if err := writeCardIDs(bw, section.cards); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// exportNames writes the decks as a list of card names, split in "Main:",
+// "Extra:" and "Side:" sections. Tokens are skipped.
+func exportNames(w io.Writer, decks []*plugins.Deck) error {
+	main, extra, side := splitDecks(decks)
+	bw := bufio.NewWriter(w)
+
+	sections := []struct {
+		header string
+		cards  []plugins.CardInfo
+	}{
+		{"Main:\n", main},
+		{"\nExtra:\n", extra},
+		{"\nSide:\n", side},
+	}
+	for _, section := range sections {
+		if _, err := bw.WriteString(section.header); err != nil {
+			return err
+		}
+		for _, card := range section.cards {
+			name := plugins.BaseCardName(card.Name)
+			if len(name) == 0 {
+				return errors.New("found a card without a name")
+			}
+			if _, err := fmt.Fprintf(bw, "%d %s\n", card.Count, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
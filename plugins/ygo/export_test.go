@@ -0,0 +1,114 @@
+package ygo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+func TestExportYDK(t *testing.T) {
+	var sb strings.Builder
+
+	err := exportYDK(&sb, []*plugins.Deck{
+		{
+			Name: "Test",
+			Cards: []plugins.CardInfo{
+				{Name: "Dark Magician", ImageURL: "https://images.ygoprodeck.com/images/cards/46986414.jpg", Count: 2},
+			},
+		},
+		{
+			Name: "Test" + extraSuffix,
+			Cards: []plugins.CardInfo{
+				{Name: "Dark Paladin", ImageURL: "https://images.ygoprodeck.com/images/cards/98502113.jpg", Count: 1},
+			},
+		},
+		{
+			Name: "Test" + sideSuffix,
+			Cards: []plugins.CardInfo{
+				{Name: "Ash Blossom", ImageURL: "https://images.ygoprodeck.com/images/cards/14558127.jpg?v=1", Count: 1},
+			},
+		},
+		{
+			Name: "Test" + tokensSuffix,
+			Cards: []plugins.CardInfo{
+				{Name: "Token", ImageURL: "https://images.ygoprodeck.com/images/cards/not-an-id.jpg", Count: 1},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `#created by tts-deckconverter
+#main
+46986414
+46986414
+#extra
+98502113
+!side
+14558127
+`, sb.String())
+
+	sb.Reset()
+	err = exportYDK(&sb, []*plugins.Deck{
+		{
+			Name:  "Test",
+			Cards: []plugins.CardInfo{{Name: "Card", ImageURL: "https://example.com/card.png", Count: 1}},
+		},
+	})
+	assert.NotNil(t, err)
+
+	// The cards of the template decks are identified by their metadata
+	sb.Reset()
+	err = exportYDK(&sb, []*plugins.Deck{
+		{
+			Name: "Test",
+			Cards: []plugins.CardInfo{
+				{
+					Name:     "Dark Magician",
+					ImageURL: "https://i.imgur.com/template.jpg#0",
+					Count:    1,
+					Metadata: map[string]string{plugins.MetadataID: "46986414"},
+				},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "#created by tts-deckconverter\n#main\n46986414\n#extra\n!side\n", sb.String())
+}
+
+func TestExportNames(t *testing.T) {
+	var sb strings.Builder
+
+	err := exportNames(&sb, []*plugins.Deck{
+		{
+			Name:  "Test",
+			Cards: []plugins.CardInfo{{Name: "Dark Magician\nSpellcaster", Count: 2}},
+		},
+		{
+			Name:  "Test" + extraSuffix,
+			Cards: []plugins.CardInfo{{Name: "Dark Paladin", Count: 1}},
+		},
+		{
+			Name:  "Test" + tokensSuffix,
+			Cards: []plugins.CardInfo{{Name: "Token", Count: 1}},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `Main:
+2 Dark Magician
+
+Extra:
+1 Dark Paladin
+
+Side:
+`, sb.String())
+
+	main, extra, side, err := parseDeckFile(strings.NewReader(sb.String()))
+	assert.Nil(t, err)
+	if assert.NotNil(t, main) && assert.NotNil(t, extra) {
+		assert.Equal(t, map[string]int{"Dark Magician": 2}, main.Counts)
+		assert.Equal(t, map[string]int{"Dark Paladin": 1}, extra.Counts)
+	}
+	assert.Nil(t, side)
+}
@@ -2,6 +2,7 @@ package ygo
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,6 +19,9 @@ import (
 	"github.com/jeandeaual/tts-deckconverter/plugins/ygo/api"
 )
 
+// ydkDeckType is the deck type of the YDK files used by YGOPro.
+const ydkDeckType = "YGOPRODeck"
+
 const (
 	// Credit to https://www.deviantart.com/holycrapwhitedragon/art/Yu-Gi-Oh-Back-Card-Template-695173962
 	defaultBackURL           = "http://cloud-3.steamusercontent.com/ugc/998016607072069584/863E293843E7DB475380CA7D024416AA684C6167/"
@@ -34,6 +38,7 @@ var cardLineRegexps = []*regexp.Regexp{
 	regexp.MustCompile(`^(?P<Count>[1-4])[xX]?\s+(?P<Name>.+)$`),
 	regexp.MustCompile(`^(?P<Name>.+)\s+[xX]?(?P<Count>[1-4])$`),
 }
+var ydkSectionRegex = regexp.MustCompile(`^(#main|#extra|!side)$`)
 var mainRegex = regexp.MustCompile(`^Main:?$`)
 var extraRegex = regexp.MustCompile(`^Extra:?$`)
 var sideRegex = regexp.MustCompile(`^Side:?$`)
@@ -180,6 +185,7 @@ func cardIDsToDeck(cards *CardIDs, deckName string, format api.Format) (*plugins
 					Description: buildDescription(resp),
 					ImageURL:    resp.Images[0].URL,
 					Count:       count,
+					Metadata:    cardMetadata(resp),
 				})
 			} else {
 				// Iterate through each token image
@@ -189,6 +195,7 @@ func cardIDsToDeck(cards *CardIDs, deckName string, format api.Format) (*plugins
 						Description: buildDescription(resp),
 						ImageURL:    resp.Images[i%len(resp.Images)].URL,
 						Count:       1,
+						Metadata:    cardMetadata(resp),
 					})
 				}
 			}
@@ -198,6 +205,7 @@ func cardIDsToDeck(cards *CardIDs, deckName string, format api.Format) (*plugins
 				Description: buildDescription(resp),
 				ImageURL:    resp.Images[0].URL,
 				Count:       count,
+				Metadata:    cardMetadata(resp),
 			})
 		}
 
@@ -235,6 +243,7 @@ func cardNamesToDeck(cards *CardNames, deckName string, format api.Format) (*plu
 					Description: buildDescription(resp),
 					ImageURL:    resp.Images[0].URL,
 					Count:       count,
+					Metadata:    cardMetadata(resp),
 				})
 			} else {
 				// Iterate through each token image
@@ -244,6 +253,7 @@ func cardNamesToDeck(cards *CardNames, deckName string, format api.Format) (*plu
 						Description: buildDescription(resp),
 						ImageURL:    resp.Images[i%len(resp.Images)].URL,
 						Count:       1,
+						Metadata:    cardMetadata(resp),
 					})
 				}
 			}
@@ -253,6 +263,7 @@ func cardNamesToDeck(cards *CardNames, deckName string, format api.Format) (*plu
 				Description: buildDescription(resp),
 				ImageURL:    resp.Images[0].URL,
 				Count:       count,
+				Metadata:    cardMetadata(resp),
 			})
 		}
 
@@ -485,17 +496,60 @@ func parseDeckFile(file io.Reader) (*CardNames, *CardNames, *CardNames, error) {
 	return main, extra, side, nil
 }
 
+// detectYDKFile returns how confident we are that content is a YDK file.
+func detectYDKFile(content []byte) float64 {
+	lines := plugins.DecklistLines(content)
+	if plugins.CountMatches(lines, ydkSectionRegex) == 0 {
+		return 0
+	}
+
+	main, extra, side, err := parseYDKFile(bytes.NewReader(content))
+	if err != nil || (main == nil && extra == nil && side == nil) {
+		return 0
+	}
+
+	return 1
+}
+
+// detectDeckFile returns how confident we are that content is a Yu-Gi-Oh!
+// decklist.
+func detectDeckFile(content []byte) float64 {
+	lines := plugins.DecklistLines(content)
+	if len(lines) == 0 {
+		return 0
+	}
+
+	main, extra, side, err := parseDeckFile(bytes.NewReader(content))
+	if err != nil || (main == nil && extra == nil && side == nil) {
+		return 0
+	}
+
+	sectionLines := plugins.CountMatches(lines, mainRegex, extraRegex, sideRegex)
+	coverage := float64(plugins.CountMatches(lines, cardLineRegexps...)+sectionLines) / float64(len(lines))
+
+	if sectionLines == 0 {
+		// Lines like "3 Blue-Eyes White Dragon" are used by the decklists
+		// of most card games
+		return 0.3 * coverage
+	}
+
+	return 0.9 * coverage
+}
+
 func fromYDKFile(file io.Reader, name string, options map[string]string) ([]*plugins.Deck, error) {
-	main, extra, side, err := parseYDKFile(file)
+	// Check the options
+	validatedOptions, err := YGOPlugin.AvailableOptions().ValidateNormalize(options)
 	if err != nil {
 		return nil, err
 	}
 
-	duelFormat := api.Format(YGOPlugin.AvailableOptions()["format"].DefaultValue.(string))
-	if format, found := options["format"]; found {
-		duelFormat = api.Format(format)
+	main, extra, side, err := parseYDKFile(file)
+	if err != nil {
+		return nil, err
 	}
 
+	duelFormat := api.Format(validatedOptions.String("format"))
+
 	var (
 		decks  []*plugins.Deck
 		tokens []plugins.CardInfo
@@ -512,7 +566,7 @@ func fromYDKFile(file io.Reader, name string, options map[string]string) ([]*plu
 	}
 
 	if extra != nil {
-		extraDeck, extraTokens, err := cardIDsToDeck(extra, name+" - Extra", duelFormat)
+		extraDeck, extraTokens, err := cardIDsToDeck(extra, name+extraSuffix, duelFormat)
 		if err != nil {
 			return nil, err
 		}
@@ -522,7 +576,7 @@ func fromYDKFile(file io.Reader, name string, options map[string]string) ([]*plu
 	}
 
 	if side != nil {
-		sideDeck, sideTokens, err := cardIDsToDeck(side, name+" - Side", duelFormat)
+		sideDeck, sideTokens, err := cardIDsToDeck(side, name+sideSuffix, duelFormat)
 		if err != nil {
 			return nil, err
 		}
@@ -533,7 +587,7 @@ func fromYDKFile(file io.Reader, name string, options map[string]string) ([]*plu
 
 	if len(tokens) > 0 {
 		decks = append(decks, &plugins.Deck{
-			Name:     name + " - Tokens",
+			Name:     name + tokensSuffix,
 			BackURL:  YGOPlugin.AvailableBacks()[plugins.DefaultBackKey].URL,
 			CardSize: plugins.CardSizeSmall,
 			Rounded:  false,
@@ -545,16 +599,19 @@ func fromYDKFile(file io.Reader, name string, options map[string]string) ([]*plu
 }
 
 func fromDeckFile(file io.Reader, name string, options map[string]string) ([]*plugins.Deck, error) {
-	main, extra, side, err := parseDeckFile(file)
+	// Check the options
+	validatedOptions, err := YGOPlugin.AvailableOptions().ValidateNormalize(options)
 	if err != nil {
 		return nil, err
 	}
 
-	duelFormat := api.Format(YGOPlugin.AvailableOptions()["format"].DefaultValue.(string))
-	if format, found := options["format"]; found {
-		duelFormat = api.Format(format)
+	main, extra, side, err := parseDeckFile(file)
+	if err != nil {
+		return nil, err
 	}
 
+	duelFormat := api.Format(validatedOptions.String("format"))
+
 	var (
 		decks  []*plugins.Deck
 		tokens []plugins.CardInfo
@@ -571,7 +628,7 @@ func fromDeckFile(file io.Reader, name string, options map[string]string) ([]*pl
 	}
 
 	if extra != nil {
-		extraDeck, extraTokens, err := cardNamesToDeck(extra, name+" - Extra", duelFormat)
+		extraDeck, extraTokens, err := cardNamesToDeck(extra, name+extraSuffix, duelFormat)
 		if err != nil {
 			return nil, err
 		}
@@ -581,7 +638,7 @@ func fromDeckFile(file io.Reader, name string, options map[string]string) ([]*pl
 	}
 
 	if side != nil {
-		sideDeck, sideTokens, err := cardNamesToDeck(side, name+" - Side", duelFormat)
+		sideDeck, sideTokens, err := cardNamesToDeck(side, name+sideSuffix, duelFormat)
 		if err != nil {
 			return nil, err
 		}
@@ -592,7 +649,7 @@ func fromDeckFile(file io.Reader, name string, options map[string]string) ([]*pl
 
 	if len(tokens) > 0 {
 		decks = append(decks, &plugins.Deck{
-			Name:     name + " - Tokens",
+			Name:     name + tokensSuffix,
 			BackURL:  YGOPlugin.AvailableBacks()[plugins.DefaultBackKey].URL,
 			CardSize: plugins.CardSizeSmall,
 			Rounded:  false,
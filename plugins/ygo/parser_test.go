@@ -71,3 +71,20 @@ Extra:
 	assert.Nil(t, extra)
 	assert.Nil(t, err)
 }
+
+func TestDetectDeckType(t *testing.T) {
+	format, confidence := YGOPlugin.DetectDeckType([]byte(YGOPlugin.DeckTypeHandlers()[ydkDeckType].Example))
+	assert.Equal(t, ydkDeckType, format)
+	assert.Equal(t, 1.0, confidence)
+
+	format, confidence = YGOPlugin.DetectDeckType([]byte("Main:\n3 Blue-Eyes White Dragon\n2 Polymerization\n\nExtra:\n1 Blue-Eyes Ultimate Dragon"))
+	assert.Equal(t, "", format)
+	assert.InDelta(t, 0.9, confidence, 0.001)
+
+	// Plain card lines are used by other card games too
+	_, confidence = YGOPlugin.DetectDeckType([]byte("3 Blue-Eyes White Dragon\n2 Polymerization"))
+	assert.InDelta(t, 0.3, confidence, 0.001)
+
+	_, confidence = YGOPlugin.DetectDeckType(nil)
+	assert.Equal(t, 0.0, confidence)
+}
@@ -101,9 +101,20 @@ func (p ygoPlugin) FileExtHandlers() map[string]plugins.FileHandler {
 	}
 }
 
+func (p ygoPlugin) ExportHandlers() map[string]plugins.ExportHandler {
+	return map[string]plugins.ExportHandler{
+		"ydk":   exportYDK,
+		"names": exportNames,
+	}
+}
+
+func (p ygoPlugin) GenericExportHandler() plugins.ExportHandler {
+	return exportNames
+}
+
 func (p ygoPlugin) DeckTypeHandlers() map[string]plugins.DeckType {
 	return map[string]plugins.DeckType{
-		"YGOPRODeck": {
+		ydkDeckType: {
 			FileHandler: fromYDKFile,
 			Example: `#main
 32295838
@@ -138,6 +149,14 @@ Side:`,
 	}
 }
 
+func (p ygoPlugin) DetectDeckType(content []byte) (string, float64) {
+	if confidence := detectYDKFile(content); confidence > 0 {
+		return ydkDeckType, confidence
+	}
+
+	return "", detectDeckFile(content)
+}
+
 func (p ygoPlugin) AvailableBacks() map[string]plugins.Back {
 	// Card backs created using https://www.deviantart.com/holycrapwhitedragon/art/Yu-Gi-Oh-Back-Card-Template-695173962 (© 2017 - 2020 HolyCrapWhiteDragon)
 	return map[string]plugins.Back{
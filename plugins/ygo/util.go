@@ -4,9 +4,18 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/jeandeaual/tts-deckconverter/plugins"
 	"github.com/jeandeaual/tts-deckconverter/plugins/ygo/api"
 )
 
+// cardMetadata records the YGOProDeck ID (the passcode) of a card, since
+// the deck lists don't specify which set the card was printed in.
+func cardMetadata(apiResponse api.Data) map[string]string {
+	return map[string]string{
+		plugins.MetadataID: strconv.FormatInt(apiResponse.YGOProID, 10),
+	}
+}
+
 func buildDescription(apiResponse api.Data) string {
 	var sb strings.Builder
 
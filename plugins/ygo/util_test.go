@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/jeandeaual/tts-deckconverter/plugins"
 	"github.com/jeandeaual/tts-deckconverter/plugins/ygo/api"
 )
 
@@ -26,3 +27,12 @@ func TestBuildCardDescription(t *testing.T) {
 		Type:        api.TypeNormalMonster,
 	}))
 }
+
+func TestCardMetadata(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		plugins.MetadataID: "89631139",
+	}, cardMetadata(api.Data{
+		YGOProID: 89631139,
+		Name:     "Blue-Eyes White Dragon",
+	}))
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts"
+	"github.com/jeandeaual/tts-deckconverter/tts/upload"
+)
+
+// JobRequest is the body of a job submission.
+// Either URL or Decklist must be set.
+type JobRequest struct {
+	// URL of the deck.
+	URL string `json:"url,omitempty"`
+	// Decklist is the content of a deck file.
+	Decklist string `json:"decklist,omitempty"`
+	// Mode is the ID of the plugin used to parse the deck (required with
+	// Decklist).
+	Mode string `json:"mode,omitempty"`
+	// Name of the deck (required with Decklist).
+	Name string `json:"name,omitempty"`
+	// Format of the decklist (the generic format of the plugin by default).
+	Format string `json:"format,omitempty"`
+	// Back is the key of one of the backs of the plugin.
+	Back string `json:"back,omitempty"`
+	// BackURL is a custom card back (cannot be used with Back).
+	BackURL string `json:"backURL,omitempty"`
+	// Options are the plugin options.
+	Options map[string]string `json:"options,omitempty"`
+	// Template is the ID of the uploader used to create deck templates
+	// (except manual).
+	Template string `json:"template,omitempty"`
+	// Compact disables the indentation of the generated files.
+	Compact bool `json:"compact,omitempty"`
+}
+
+// validate checks the request, and sets the mode from the URL if needed.
+func (r *JobRequest) validate(options *serverOptions) error {
+	checker := newURLChecker(options)
+
+	switch {
+	case len(r.URL) > 0 && len(r.Decklist) > 0:
+		return fmt.Errorf("url and decklist cannot be used at the same time")
+	case len(r.URL) > 0:
+		if !checker.isRemoteURL(r.URL) {
+			return fmt.Errorf("invalid URL: %s", r.URL)
+		}
+		if len(r.Mode) == 0 {
+			pluginID, found := dc.FindPlugin(r.URL)
+			if !found {
+				return fmt.Errorf("unsupported URL: %s", r.URL)
+			}
+			r.Mode = pluginID
+		}
+	case len(r.Decklist) > 0:
+		if len(r.Mode) == 0 {
+			return fmt.Errorf("mode is required with decklist")
+		}
+		if len(r.Name) == 0 {
+			return fmt.Errorf("name is required with decklist")
+		}
+	default:
+		return fmt.Errorf("url or decklist is required")
+	}
+
+	plugin, found := dc.Plugins[r.Mode]
+	if !found {
+		return fmt.Errorf("invalid mode: %s", r.Mode)
+	}
+
+	if len(r.Format) > 0 {
+		if _, found = plugin.DeckTypeHandlers()[r.Format]; !found {
+			return fmt.Errorf("invalid format for %s: %s", r.Mode, r.Format)
+		}
+	}
+
+	if len(r.Back) > 0 && len(r.BackURL) > 0 {
+		return fmt.Errorf("back and backURL cannot be used at the same time")
+	}
+	if len(r.Back) > 0 {
+		back, backFound := plugin.AvailableBacks()[r.Back]
+		if !backFound {
+			return fmt.Errorf("invalid back for %s: %s", r.Mode, r.Back)
+		}
+		r.BackURL = back.URL
+	}
+	if len(r.BackURL) > 0 && !checker.isRemoteURL(r.BackURL) {
+		return fmt.Errorf("invalid back URL: %s", r.BackURL)
+	}
+
+	if _, err := plugin.AvailableOptions().ValidateNormalize(r.Options); err != nil {
+		return err
+	}
+
+	if len(r.Template) > 0 {
+		if _, found = upload.TemplateUploaders[r.Template]; !found {
+			return fmt.Errorf("invalid template uploader: %s", r.Template)
+		}
+		// The templates would refer to the files of the server
+		if r.Template == "manual" {
+			return fmt.Errorf("the %s template uploader cannot be used with the server", r.Template)
+		}
+	}
+
+	return nil
+}
+
+// JobState is the state of a job.
+type JobState string
+
+const (
+	// JobQueued is the state of the jobs waiting to be processed.
+	JobQueued JobState = "queued"
+	// JobRunning is the state of the jobs being processed.
+	JobRunning JobState = "running"
+	// JobDone is the state of the jobs which generated files.
+	JobDone JobState = "done"
+	// JobFailed is the state of the jobs which didn't generate any file.
+	JobFailed JobState = "failed"
+)
+
+// JobProgress is the progress of a running job.
+type JobProgress struct {
+	// Decks is the number of decks found in the decklist.
+	Decks int `json:"decks"`
+	// Generated is the number of decks already generated.
+	Generated int `json:"generated"`
+}
+
+// JobStatus is the status of a job, returned by the API.
+type JobStatus struct {
+	ID       string      `json:"id"`
+	State    JobState    `json:"state"`
+	Progress JobProgress `json:"progress"`
+	// Errors are the errors which happened during the conversion. Some
+	// files may still have been generated.
+	Errors []string `json:"errors,omitempty"`
+	// Files are the paths of the generated files, which can be
+	// downloaded from /api/jobs/{id}/files/{path}.
+	Files    []string   `json:"files,omitempty"`
+	Created  time.Time  `json:"created"`
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+// job is a conversion submitted to the server.
+type job struct {
+	request JobRequest
+	mutex   sync.Mutex
+	status  JobStatus
+}
+
+// snapshot returns a copy of the status of the job.
+func (j *job) snapshot() JobStatus {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	status := j.status
+	status.Errors = append([]string(nil), j.status.Errors...)
+	status.Files = append([]string(nil), j.status.Files...)
+
+	return status
+}
+
+// update modifies the status of the job.
+func (j *job) update(modify func(status *JobStatus)) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	modify(&j.status)
+}
+
+// parse returns the decks of the request.
+func (j *job) parse() ([]*plugins.Deck, error) {
+	if len(j.request.URL) > 0 {
+		return dc.Parse(j.request.URL, j.request.Mode, j.request.Options)
+	}
+
+	plugin := dc.Plugins[j.request.Mode]
+	handler := plugin.GenericFileHandler().FileHandler
+	if len(j.request.Format) > 0 {
+		handler = plugin.DeckTypeHandlers()[j.request.Format].FileHandler
+	}
+
+	decks, err := handler(strings.NewReader(j.request.Decklist), j.request.Name, j.request.Options)
+	for _, deck := range decks {
+		deck.Plugin = j.request.Mode
+	}
+
+	return decks, err
+}
+
+// run converts the decks of the job in folder, and returns the paths of the
+// generated files, relative to folder.
+func (j *job) run(folder string, options *serverOptions) ([]string, []error) {
+	decks, err := j.parse()
+	if err != nil {
+		return nil, []error{fmt.Errorf("couldn't parse the deck: %w", err)}
+	}
+	if err = newURLChecker(options).checkRemoteImages(decks); err != nil {
+		return nil, []error{err}
+	}
+
+	j.update(func(status *JobStatus) {
+		status.Progress.Decks = len(decks)
+	})
+
+	if err = os.MkdirAll(folder, 0o755); err != nil {
+		return nil, []error{err}
+	}
+
+	errs := []error{}
+
+	if len(j.request.Template) > 0 {
+		templateErrs, ok := generateTemplates(decks, folder, *upload.TemplateUploaders[j.request.Template])
+		errs = append(errs, templateErrs...)
+		if !ok {
+			return listFiles(folder), errs
+		}
+	}
+
+	for i, deck := range decks {
+		generateErrs := tts.Generate(
+			[]*plugins.Deck{deck},
+			j.request.BackURL,
+			folder,
+			!j.request.Compact,
+			tts.WithConflictPolicy(tts.ConflictRename),
+		)
+		errs = append(errs, generateErrs...)
+
+		j.update(func(status *JobStatus) {
+			status.Progress.Generated = i + 1
+		})
+	}
+
+	return listFiles(folder), errs
+}
+
+// listFiles returns the paths of the files found in folder, relative to
+// folder and using slashes.
+func listFiles(folder string) []string {
+	files := []string{}
+
+	_ = filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if rel, relErr := filepath.Rel(folder, path); relErr == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+
+	sort.Strings(files)
+
+	return files
+}
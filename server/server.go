@@ -0,0 +1,519 @@
+// Package server exposes the deck conversion through a REST API, used to
+// convert decks from other services (e.g. chat bots or web sites).
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts"
+	"github.com/jeandeaual/tts-deckconverter/tts/upload"
+)
+
+// apiPrefix is the prefix of all the API endpoints.
+const apiPrefix = "/api/"
+
+// cleanupInterval is the interval at which the expired jobs are removed.
+const cleanupInterval = time.Minute
+
+// maxRequestSize is the maximum size of the body of a job submission.
+const maxRequestSize = 1 << 20
+
+// Server is an http.Handler serving the REST API.
+// The submitted jobs are queued, and processed by a fixed number of workers.
+type Server struct {
+	folder  string
+	options *serverOptions
+	queue   chan *job
+	mutex   sync.RWMutex
+	jobs    map[string]*job
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+type serverOptions struct {
+	concurrency       int
+	queueSize         int
+	ttl               time.Duration
+	allowPrivateHosts bool
+}
+
+// Option is an option of New.
+type Option func(*serverOptions)
+
+// WithConcurrency sets the number of jobs processed at the same time
+// (2 by default).
+func WithConcurrency(concurrency int) Option {
+	return func(o *serverOptions) {
+		if concurrency > 0 {
+			o.concurrency = concurrency
+		}
+	}
+}
+
+// WithQueueSize sets the number of jobs which can wait to be processed
+// (100 by default). The submissions are rejected when the queue is full.
+func WithQueueSize(size int) Option {
+	return func(o *serverOptions) {
+		if size > 0 {
+			o.queueSize = size
+		}
+	}
+}
+
+// WithTTL sets how long the jobs and their files are kept after they finish
+// (1 hour by default).
+func WithTTL(ttl time.Duration) Option {
+	return func(o *serverOptions) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithPrivateHosts allows the jobs to refer to the loopback and private
+// hosts (e.g. an image server of the local network). They are rejected by
+// default, so that the server cannot be used to reach its own network.
+func WithPrivateHosts() Option {
+	return func(o *serverOptions) {
+		o.allowPrivateHosts = true
+	}
+}
+
+// New creates a server writing the files of the jobs in folder, and starts
+// its workers. Close should be called to stop them.
+func New(folder string, options ...Option) *Server {
+	opts := &serverOptions{
+		concurrency: 2,
+		queueSize:   100,
+		ttl:         time.Hour,
+	}
+	for _, option := range options {
+		option(opts)
+	}
+
+	s := &Server{
+		folder:  folder,
+		options: opts,
+		queue:   make(chan *job, opts.queueSize),
+		jobs:    make(map[string]*job),
+		done:    make(chan struct{}),
+	}
+
+	for i := 0; i < opts.concurrency; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+
+	s.wg.Add(1)
+	go s.cleanup()
+
+	return s
+}
+
+// Close stops the workers once the running jobs are finished.
+// The queued jobs aren't processed.
+func (s *Server) Close() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+// ServeHTTP routes the requests to the API endpoints:
+//
+//	GET  /api/plugins
+//	GET  /api/plugins/{id}
+//	GET  /api/uploaders
+//	POST /api/jobs
+//	GET  /api/jobs/{id}
+//	GET  /api/jobs/{id}/files/{path}
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, apiPrefix) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, apiPrefix), "/", 4)
+
+	switch {
+	case parts[0] == "plugins" && len(parts) == 1:
+		s.onlyGet(w, r, s.listPlugins)
+	case parts[0] == "plugins" && len(parts) == 2:
+		s.onlyGet(w, r, func(w http.ResponseWriter, r *http.Request) {
+			s.getPlugin(w, parts[1])
+		})
+	case parts[0] == "uploaders" && len(parts) == 1:
+		s.onlyGet(w, r, s.listUploaders)
+	case parts[0] == "jobs" && len(parts) == 1:
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.submitJob(w, r)
+	case parts[0] == "jobs" && len(parts) == 2:
+		s.onlyGet(w, r, func(w http.ResponseWriter, r *http.Request) {
+			s.getJob(w, parts[1])
+		})
+	case parts[0] == "jobs" && len(parts) == 4 && parts[2] == "files":
+		s.onlyGet(w, r, func(w http.ResponseWriter, r *http.Request) {
+			s.getJobFile(w, r, parts[1], parts[3])
+		})
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// onlyGet calls handler if the request method is GET.
+func (s *Server) onlyGet(w http.ResponseWriter, r *http.Request, handler http.HandlerFunc) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	handler(w, r)
+}
+
+func (s *Server) listPlugins(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, dc.DescribePlugins())
+}
+
+func (s *Server) getPlugin(w http.ResponseWriter, pluginID string) {
+	plugin, found := dc.Plugins[pluginID]
+	if !found {
+		writeError(w, http.StatusNotFound, "plugin "+pluginID+" not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dc.DescribePlugin(plugin))
+}
+
+func (s *Server) listUploaders(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, dc.DescribeUploaders())
+}
+
+func (s *Server) submitJob(w http.ResponseWriter, r *http.Request) {
+	var request JobRequest
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+
+	if err := request.validate(s.options); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	j := &job{
+		request: request,
+		status: JobStatus{
+			ID:      id,
+			State:   JobQueued,
+			Created: time.Now().UTC(),
+		},
+	}
+
+	select {
+	case s.queue <- j:
+	default:
+		writeError(w, http.StatusServiceUnavailable, "too many jobs are waiting, try again later")
+		return
+	}
+
+	s.mutex.Lock()
+	s.jobs[id] = j
+	s.mutex.Unlock()
+
+	log.Infof("Job %s queued", id)
+
+	w.Header().Set("Location", apiPrefix+"jobs/"+id)
+	writeJSON(w, http.StatusAccepted, j.snapshot())
+}
+
+// findJob returns the job with the given ID, if it exists.
+func (s *Server) findJob(id string) (*job, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	j, found := s.jobs[id]
+
+	return j, found
+}
+
+func (s *Server) getJob(w http.ResponseWriter, id string) {
+	j, found := s.findJob(id)
+	if !found {
+		writeError(w, http.StatusNotFound, "job "+id+" not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, j.snapshot())
+}
+
+func (s *Server) getJobFile(w http.ResponseWriter, r *http.Request, id, name string) {
+	j, found := s.findJob(id)
+	if !found {
+		writeError(w, http.StatusNotFound, "job "+id+" not found")
+		return
+	}
+
+	for _, file := range j.snapshot().Files {
+		if file == name {
+			http.ServeFile(w, r, filepath.Join(s.jobFolder(id), filepath.FromSlash(name)))
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "file "+name+" not found")
+}
+
+// jobFolder returns the folder containing the files generated by a job.
+func (s *Server) jobFolder(id string) string {
+	return filepath.Join(s.folder, id)
+}
+
+// work processes the queued jobs until the server is closed.
+func (s *Server) work() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case j := <-s.queue:
+			s.process(j)
+		}
+	}
+}
+
+// process converts the decks of a job.
+func (s *Server) process(j *job) {
+	id := j.snapshot().ID
+	log.Infof("Job %s started", id)
+
+	j.update(func(status *JobStatus) {
+		status.State = JobRunning
+	})
+
+	files, errs := j.run(s.jobFolder(id), s.options)
+
+	j.update(func(status *JobStatus) {
+		status.Files = files
+		finished := time.Now().UTC()
+		status.Finished = &finished
+		status.State = JobDone
+		for _, err := range errs {
+			status.Errors = append(status.Errors, err.Error())
+		}
+		if len(files) == 0 {
+			status.State = JobFailed
+		}
+	})
+
+	log.Infof("Job %s finished with %d errors", id, len(errs))
+}
+
+// cleanup removes the jobs finished for longer than the TTL, and their
+// files, until the server is closed.
+func (s *Server) cleanup() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.removeExpiredJobs(time.Now())
+		}
+	}
+}
+
+// removeExpiredJobs removes the jobs finished for longer than the TTL at
+// the time now.
+func (s *Server) removeExpiredJobs(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id, j := range s.jobs {
+		finished := j.snapshot().Finished
+		if finished == nil || now.Sub(*finished) < s.options.ttl {
+			continue
+		}
+
+		if err := os.RemoveAll(s.jobFolder(id)); err != nil {
+			log.Errorf("Couldn't remove the files of job %s: %v", id, err)
+			continue
+		}
+		delete(s.jobs, id)
+		log.Debugf("Job %s expired", id)
+	}
+}
+
+// newJobID returns a random job ID.
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// privateNetworks are the address ranges of the private networks.
+var privateNetworks = func() []*net.IPNet {
+	networks := []*net.IPNet{}
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7"} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// isPrivateIP returns true if ip is a loopback, link-local, unspecified or
+// private address.
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// urlChecker checks that the URLs submitted to the server point to remote
+// hosts, so that the server cannot be used to reach its own files or the
+// hosts of its network.
+type urlChecker struct {
+	allowPrivateHosts bool
+	// privateHosts caches the result of isPrivateHost
+	privateHosts map[string]bool
+}
+
+func newURLChecker(options *serverOptions) *urlChecker {
+	return &urlChecker{
+		allowPrivateHosts: options.allowPrivateHosts,
+		privateHosts:      make(map[string]bool),
+	}
+}
+
+// isPrivateHost returns true if host is, or resolves to, a private address.
+// The hosts which cannot be resolved aren't considered private, since
+// nothing can be downloaded from them anyway.
+func (c *urlChecker) isPrivateHost(host string) bool {
+	if private, found := c.privateHosts[host]; found {
+		return private
+	}
+
+	private := false
+	if ip := net.ParseIP(host); ip != nil {
+		private = isPrivateIP(ip)
+	} else if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		private = true
+	} else if ips, err := net.LookupIP(host); err == nil {
+		for _, ip := range ips {
+			if isPrivateIP(ip) {
+				private = true
+				break
+			}
+		}
+	}
+	c.privateHosts[host] = private
+
+	return private
+}
+
+// isRemoteURL returns true if location is an HTTP URL, whose host isn't
+// private (unless WithPrivateHosts is used).
+func (c *urlChecker) isRemoteURL(location string) bool {
+	u, err := url.Parse(location)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Hostname()) == 0 {
+		return false
+	}
+
+	return c.allowPrivateHosts || !c.isPrivateHost(strings.ToLower(u.Hostname()))
+}
+
+// checkRemoteImages returns an error if a deck refers to a local file or a
+// private host, since the files and network of the server must not be
+// reached.
+func (c *urlChecker) checkRemoteImages(decks []*plugins.Deck) error {
+	for _, deck := range decks {
+		if len(deck.BackURL) > 0 && !c.isRemoteURL(deck.BackURL) {
+			return fmt.Errorf("deck %s: only HTTP URLs can be used as images", deck.Name)
+		}
+		for i := range deck.Cards {
+			for card := &deck.Cards[i]; card != nil; card = card.AlternativeState {
+				for _, location := range []string{card.ImageURL, card.BackImageURL} {
+					if len(location) > 0 && !c.isRemoteURL(location) {
+						return fmt.Errorf("card %s: only HTTP URLs can be used as images", card.Name)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// generateTemplates generates the templates of the decks.
+// ok is false if the decks can't be generated because of the errors. The
+// templates too large to be uploaded are still available in the files of
+// the job.
+func generateTemplates(decks []*plugins.Deck, folder string, uploader upload.TemplateUploader) (errs []error, ok bool) {
+	errs = tts.GenerateTemplates([][]*plugins.Deck{decks}, folder, uploader)
+
+	for _, err := range errs {
+		if !errors.Is(err, upload.ErrUploadSize) {
+			return errs, false
+		}
+	}
+
+	return errs, true
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		log.Errorf("Couldn't write the response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, map[string]string{"error": message})
+}
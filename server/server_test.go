@@ -0,0 +1,270 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	dc "github.com/jeandeaual/tts-deckconverter"
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/tts"
+)
+
+func init() {
+	logger := zap.NewExample()
+	log.SetLogger(logger.Sugar())
+}
+
+// newTestServer returns an API server and a server serving a card image.
+// The test servers listen on the loopback interface, so the private hosts
+// are allowed unless other options are given.
+func newTestServer(t *testing.T, options ...Option) (api *httptest.Server, images *httptest.Server) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	var buf bytes.Buffer
+	if !assert.Nil(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 14)))) {
+		t.FailNow()
+	}
+	images = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(buf.Bytes())
+	}))
+
+	if len(options) == 0 {
+		options = []Option{WithPrivateHosts()}
+	}
+	s := New(tmpDir, append([]Option{WithConcurrency(1), WithQueueSize(1)}, options...)...)
+	api = httptest.NewServer(s)
+
+	t.Cleanup(func() {
+		api.Close()
+		images.Close()
+		s.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	return api, images
+}
+
+func getJSON(t *testing.T, url string, value interface{}) int {
+	resp, err := http.Get(url)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(value))
+
+	return resp.StatusCode
+}
+
+func postJob(t *testing.T, url string, request string) (int, JobStatus) {
+	resp, err := http.Post(url+"/api/jobs", "application/json", strings.NewReader(request))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	var status JobStatus
+	_ = json.NewDecoder(resp.Body).Decode(&status)
+
+	return resp.StatusCode, status
+}
+
+func TestListPlugins(t *testing.T) {
+	api, _ := newTestServer(t)
+
+	var plugins []dc.PluginInfo
+	assert.Equal(t, http.StatusOK, getJSON(t, api.URL+"/api/plugins", &plugins))
+	assert.Equal(t, "mtg", plugins[0].ID)
+	assert.Equal(t, "detailed_description", plugins[0].Options[0].Name)
+	assert.Equal(t, "bool", plugins[0].Options[0].Type)
+	assert.Contains(t, plugins[0].Backs, "default")
+
+	var plugin dc.PluginInfo
+	assert.Equal(t, http.StatusOK, getJSON(t, api.URL+"/api/plugins/ygo", &plugin))
+	assert.Equal(t, "ygo", plugin.ID)
+	assert.Contains(t, plugin.Backs, "ocg")
+
+	var apiErr map[string]string
+	assert.Equal(t, http.StatusNotFound, getJSON(t, api.URL+"/api/plugins/hearthstone", &apiErr))
+	assert.NotEmpty(t, apiErr["error"])
+
+	var uploaders []dc.UploaderInfo
+	assert.Equal(t, http.StatusOK, getJSON(t, api.URL+"/api/uploaders", &uploaders))
+	assert.Contains(t, uploaders, dc.UploaderInfo{
+		ID:          "manual",
+		Name:        "Manual",
+		Description: "Let the user manually upload the template.",
+	})
+}
+
+func TestSubmitInvalidJob(t *testing.T) {
+	api, _ := newTestServer(t)
+
+	for _, request := range []string{
+		`{}`,
+		`{"url": "https://example.com/deck"}`,
+		`{"url": "/etc/passwd", "mode": "custom"}`,
+		`{"decklist": "1 Black Lotus", "name": "Test"}`,
+		`{"decklist": "1 Black Lotus", "mode": "mtg"}`,
+		`{"decklist": "1 Black Lotus", "mode": "hearthstone", "name": "Test"}`,
+		`{"decklist": "1 Black Lotus", "mode": "mtg", "name": "Test", "back": "ocg"}`,
+		`{"decklist": "1 Black Lotus", "mode": "mtg", "name": "Test", "options": {"quality": "huge"}}`,
+		`{"decklist": "1 Black Lotus", "mode": "mtg", "name": "Test", "template": "dropbox"}`,
+		`{"decklist": "1 Black Lotus", "mode": "mtg", "name": "Test", "template": "manual"}`,
+		`{"decklist": "1 Black Lotus", "mode": "mtg", "name": "Test", "foil": true}`,
+	} {
+		statusCode, _ := postJob(t, api.URL, request)
+		assert.Equal(t, http.StatusBadRequest, statusCode, request)
+	}
+
+	// The size of the submissions is limited
+	request := `{"decklist": "` + strings.Repeat("1 Black Lotus\\n", maxRequestSize/len("1 Black Lotus\\n")+1) + `", "mode": "mtg", "name": "Test"}`
+	statusCode, _ := postJob(t, api.URL, request)
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+}
+
+// waitForJob polls a job until it is finished.
+func waitForJob(t *testing.T, url, id string) JobStatus {
+	var status JobStatus
+
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, http.StatusOK, getJSON(t, url+"/api/jobs/"+id, &status))
+		if status.State == JobDone || status.State == JobFailed {
+			return status
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Fatalf("Job %s didn't finish", id)
+
+	return status
+}
+
+func TestJob(t *testing.T) {
+	api, images := newTestServer(t)
+
+	request, err := json.Marshal(JobRequest{
+		Decklist: "2 " + images.URL + "/card1.png (Card 1)\n1 " + images.URL + "/card2.png (Card 2)",
+		Mode:     "custom",
+		Name:     "Test Deck",
+		BackURL:  images.URL + "/back.png",
+	})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	statusCode, status := postJob(t, api.URL, string(request))
+	assert.Equal(t, http.StatusAccepted, statusCode)
+	assert.Len(t, status.ID, 32)
+
+	status = waitForJob(t, api.URL, status.ID)
+	assert.Equal(t, JobDone, status.State)
+	assert.Empty(t, status.Errors)
+	assert.Equal(t, JobProgress{Decks: 1, Generated: 1}, status.Progress)
+	assert.Equal(t, []string{"Test Deck.json", "Test Deck.png"}, status.Files)
+
+	resp, err := http.Get(api.URL + "/api/jobs/" + status.ID + "/files/Test%20Deck.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var object tts.SavedObject
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&object))
+	assert.Len(t, object.ObjectStates[0].DeckIDs, 3)
+
+	var apiErr map[string]string
+	assert.Equal(t, http.StatusNotFound, getJSON(t, api.URL+"/api/jobs/"+status.ID+"/files/..%2F..%2Fetc%2Fpasswd", &apiErr))
+	assert.Equal(t, http.StatusNotFound, getJSON(t, api.URL+"/api/jobs/unknown", &apiErr))
+}
+
+func TestJobLocalImages(t *testing.T) {
+	api, _ := newTestServer(t)
+
+	statusCode, status := postJob(t, api.URL, `{"decklist": "1 /etc/passwd", "mode": "custom", "name": "Test"}`)
+	assert.Equal(t, http.StatusAccepted, statusCode)
+
+	status = waitForJob(t, api.URL, status.ID)
+	assert.Equal(t, JobFailed, status.State)
+	assert.Len(t, status.Errors, 1)
+	assert.Empty(t, status.Files)
+}
+
+func TestJobPrivateHosts(t *testing.T) {
+	api, images := newTestServer(t, WithTTL(time.Hour))
+
+	for _, request := range []string{
+		`{"url": "` + images.URL + `/deck", "mode": "custom"}`,
+		`{"url": "http://localhost/deck", "mode": "custom"}`,
+		`{"url": "http://192.168.1.1/deck", "mode": "custom"}`,
+		`{"url": "http://[::1]/deck", "mode": "custom"}`,
+		`{"decklist": "1 Black Lotus", "mode": "mtg", "name": "Test", "backURL": "http://10.0.0.1/back.png"}`,
+	} {
+		statusCode, _ := postJob(t, api.URL, request)
+		assert.Equal(t, http.StatusBadRequest, statusCode, request)
+	}
+
+	// The images are checked once the deck is parsed
+	statusCode, status := postJob(t, api.URL, `{"decklist": "1 `+images.URL+`/card.png", "mode": "custom", "name": "Test"}`)
+	assert.Equal(t, http.StatusAccepted, statusCode)
+
+	status = waitForJob(t, api.URL, status.ID)
+	assert.Equal(t, JobFailed, status.State)
+	assert.Len(t, status.Errors, 1)
+	assert.Empty(t, status.Files)
+}
+
+func TestIsPrivateIP(t *testing.T) {
+	for address, expected := range map[string]bool{
+		"127.0.0.1":       true,
+		"10.1.2.3":        true,
+		"172.16.0.1":      true,
+		"172.32.0.1":      false,
+		"192.168.0.10":    true,
+		"169.254.169.254": true,
+		"0.0.0.0":         true,
+		"::1":             true,
+		"fd00::1":         true,
+		"8.8.8.8":         false,
+		"2001:4860::8888": false,
+	} {
+		assert.Equal(t, expected, isPrivateIP(net.ParseIP(address)), address)
+	}
+}
+
+func TestRemoveExpiredJobs(t *testing.T) {
+	api, images := newTestServer(t)
+
+	_, status := postJob(t, api.URL, `{"decklist": "1 `+images.URL+`/card.png", "mode": "custom", "name": "Test"}`)
+	status = waitForJob(t, api.URL, status.ID)
+
+	s := api.Config.Handler.(*Server)
+	s.removeExpiredJobs(status.Finished.Add(time.Minute))
+
+	var apiErr map[string]string
+	assert.Equal(t, http.StatusOK, getJSON(t, api.URL+"/api/jobs/"+status.ID, &status))
+
+	s.removeExpiredJobs(status.Finished.Add(time.Hour))
+	assert.Equal(t, http.StatusNotFound, getJSON(t, api.URL+"/api/jobs/"+status.ID, &apiErr))
+	_, err := os.Stat(s.jobFolder(status.ID))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -3,10 +3,10 @@ package tts
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"path/filepath"
+	"io"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jeandeaual/tts-deckconverter/log"
 	"github.com/jeandeaual/tts-deckconverter/plugins"
@@ -24,8 +24,31 @@ const (
 	// to get the correct size (59×86mm)
 	smallScaleX = 59.0 / 58
 	smallScaleZ = 86.0 / 80
+	// Tarot cards are 70×120mm
+	tarotScaleX = 70.0 / 56
+	tarotScaleZ = 120.0 / 80
+	// Mini Euro cards are 44×68mm
+	miniScaleX = 44.0 / 56
+	miniScaleZ = 68.0 / 80
+	// Square cards are 70×70mm
+	squareScaleX = 70.0 / 56
+	squareScaleZ = 70.0 / 80
+	// Oversized cards are 5×7 inches (127×177.8mm)
+	oversizedScaleX = 127.0 / 56
+	oversizedScaleZ = 177.8 / 80
 )
 
+// saveDateFormat is the format used by TTS for the date of the saved objects.
+const saveDateFormat = "1/2/2006 3:04:05 PM"
+
+// saveVersionNumber is the version of TTS the saved objects are generated
+// for. It doesn't depend on the generation, so it is kept in reproducible
+// mode.
+const saveVersionNumber = "v13.1.1"
+
+// now returns the current time. Overridden in tests.
+var now = time.Now
+
 var filepathReplacer = strings.NewReplacer(
 	// Illegal on Linux/Unix and Windows
 	"/", "-",
@@ -40,12 +63,111 @@ var filepathReplacer = strings.NewReplacer(
 	"|", "-",
 )
 
+// cardScale returns the scale to apply to a card or deck object so that it
+// has the right size in TTS.
+func cardScale(cardSize plugins.CardSize, oversized bool) (scaleX, scaleY, scaleZ float64) {
+	scaleX = 1.0
+	scaleY = 1.0
+	scaleZ = 1.0
+
+	switch cardSize {
+	case plugins.CardSizeStandard:
+		scaleX = standardScaleX
+		scaleZ = standardScaleZ
+
+		if oversized {
+			scaleX *= standardOversizedScale
+			scaleY *= standardOversizedScale
+			scaleZ *= standardOversizedScale
+		}
+	case plugins.CardSizeSmall:
+		scaleX = smallScaleX
+		scaleZ = smallScaleZ
+	case plugins.CardSizeTarot:
+		scaleX = tarotScaleX
+		scaleZ = tarotScaleZ
+	case plugins.CardSizeMini:
+		scaleX = miniScaleX
+		scaleZ = miniScaleZ
+	case plugins.CardSizeSquare:
+		scaleX = squareScaleX
+		scaleZ = squareScaleZ
+	case plugins.CardSizeOversized:
+		scaleX = oversizedScaleX
+		scaleZ = oversizedScaleZ
+	}
+
+	return
+}
+
+// deckShape returns the TTS shape of the cards of a deck.
+func deckShape(deck *plugins.Deck) DeckShape {
+	switch deck.Shape {
+	case plugins.CardShapeHex:
+		if deck.Rounded {
+			return DeckShapeHexRounded
+		}
+		return DeckShapeHex
+	case plugins.CardShapeCircle:
+		return DeckShapeCircle
+	default:
+		if deck.Rounded {
+			return DeckShapeRectangleRounded
+		}
+		return DeckShapeRectangle
+	}
+}
+
+// newCardCustomDeck creates the custom deck of a single card image.
+// The back of the card is used if set, otherwise the back of all the cards
+// will be backURL.
+func newCardCustomDeck(card plugins.CardInfo, backURL string) CustomDeck {
+	customDeck := CustomDeck{
+		FaceURL:      card.ImageURL,
+		BackURL:      backURL,
+		NumWidth:     1,
+		NumHeight:    1,
+		BackIsHidden: true,
+		UniqueBack:   false,
+	}
+
+	if len(card.BackImageURL) > 0 {
+		customDeck.BackURL = card.BackImageURL
+		customDeck.UniqueBack = true
+	}
+
+	return customDeck
+}
+
+// newTemplateCustomDeck creates the custom deck of a template.
+// If a back sheet has been generated for the template, each card uses its
+// own back from the sheet, otherwise the back of all the cards will be
+// backURL.
+func newTemplateCustomDeck(template *plugins.Template, backURL string) CustomDeck {
+	customDeck := CustomDeck{
+		FaceURL:      template.URL,
+		BackURL:      backURL,
+		NumWidth:     template.NumCols,
+		NumHeight:    template.NumRows,
+		BackIsHidden: true,
+		UniqueBack:   false,
+	}
+
+	if len(template.BackURL) > 0 {
+		customDeck.BackURL = template.BackURL
+		customDeck.UniqueBack = true
+	}
+
+	return customDeck
+}
+
 func createDeck(deck *plugins.Deck) (SavedObject, string) {
 	object := createDefaultDeck()
 	count := 1
 	thumbnailSource := deck.ThumbnailURL
 	deckObject := &object.ObjectStates[0]
 	oversizedDeck := true
+	sidewaysDeck := true
 
 	for _, card := range deck.Cards {
 		var (
@@ -54,24 +176,14 @@ func createDeck(deck *plugins.Deck) (SavedObject, string) {
 		)
 
 		if deck.TemplateInfo == nil {
-			customDeck = CustomDeck{
-				FaceURL:      card.ImageURL,
-				BackURL:      deck.BackURL,
-				NumWidth:     1,
-				NumHeight:    1,
-				BackIsHidden: true,
-				UniqueBack:   false,
-				Type:         DeckShapeRectangleRounded,
-			}
-			if !deck.Rounded {
-				customDeck.Type = DeckShapeRectangle
-			}
+			customDeck = newCardCustomDeck(card, deck.BackURL)
+			customDeck.Type = deckShape(deck)
 		} else {
 			var (
 				templateID int
 				err        error
 			)
-			cardID, found := deck.TemplateInfo.ImageURLCardIDMap[card.ImageURL]
+			cardID, found := deck.TemplateInfo.ImageURLCardIDMap[plugins.TemplateImageKey(card.ImageURL, card.BackImageURL)]
 			if !found {
 				log.Errorw(
 					"Image ID for not found for URL",
@@ -89,14 +201,8 @@ func createDeck(deck *plugins.Deck) (SavedObject, string) {
 					"urlIDMap", deck.TemplateInfo.ImageURLCardIDMap,
 				)
 			}
-			customDeck = CustomDeck{
-				FaceURL:      template.URL,
-				BackURL:      deck.BackURL,
-				NumWidth:     template.NumCols,
-				NumHeight:    template.NumRows,
-				BackIsHidden: true,
-				UniqueBack:   false,
-			}
+			customDeck = newTemplateCustomDeck(template, deck.BackURL)
+			customDeck.Type = deckShape(deck)
 			for i := 0; i < card.Count; i++ {
 				deckObject.DeckIDs = append(deckObject.DeckIDs, cardID)
 			}
@@ -115,7 +221,7 @@ func createDeck(deck *plugins.Deck) (SavedObject, string) {
 
 			deckObject.ContainedObjects = append(
 				deckObject.ContainedObjects,
-				createCard(card, count, customDeck, deck.TemplateInfo, deck.CardSize),
+				createCard(card, count, customDeck, deck.TemplateInfo, deck.CardSize, deck.BackURL),
 			)
 
 			if deck.TemplateInfo == nil {
@@ -126,48 +232,54 @@ func createDeck(deck *plugins.Deck) (SavedObject, string) {
 		if oversizedDeck && !card.Oversized {
 			oversizedDeck = false
 		}
+		if sidewaysDeck && !card.Sideways {
+			sidewaysDeck = false
+		}
 	}
 
-	switch deck.CardSize {
-	case plugins.CardSizeStandard:
-		deckObject.Transform.ScaleX = standardScaleX
-		deckObject.Transform.ScaleZ = standardScaleZ
+	deckObject.SidewaysCard = sidewaysDeck
 
-		if oversizedDeck {
-			deckObject.Transform.ScaleX *= standardOversizedScale
-			deckObject.Transform.ScaleY *= standardOversizedScale
-			deckObject.Transform.ScaleZ *= standardOversizedScale
-		}
-	case plugins.CardSizeSmall:
-		deckObject.Transform.ScaleX = smallScaleX
-		deckObject.Transform.ScaleZ = smallScaleZ
-	}
+	deckObject.Transform.ScaleX, deckObject.Transform.ScaleY, deckObject.Transform.ScaleZ = cardScale(
+		deck.CardSize,
+		oversizedDeck,
+	)
 
 	return object, thumbnailSource
 }
 
+// encodeMetadata returns the metadata of a card as a JSON object, to be saved
+// in its GM notes. An empty string is returned if there is no metadata.
+func encodeMetadata(metadata map[string]string) string {
+	if len(metadata) == 0 {
+		return ""
+	}
+
+	// Maps are marshalled with sorted keys, so the output is stable
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		log.Errorf("Couldn't encode the card metadata %v: %v", metadata, err)
+		return ""
+	}
+
+	return string(data)
+}
+
 func createCard(
 	card plugins.CardInfo,
 	count int,
 	customDeck CustomDeck,
 	templateInfo *plugins.TemplateInfo,
 	cardSize plugins.CardSize,
+	backURL string,
 ) Object {
-	var states map[string]Object
+	var states StatesMap
 
 	if card.AlternativeState != nil {
 		var alternateCustomDeck CustomDeck
 		if templateInfo == nil {
-			alternateCustomDeck = CustomDeck{
-				FaceURL:      card.AlternativeState.ImageURL,
-				BackURL:      customDeck.BackURL,
-				NumWidth:     1,
-				NumHeight:    1,
-				BackIsHidden: true,
-				UniqueBack:   false,
-			}
+			alternateCustomDeck = newCardCustomDeck(*card.AlternativeState, backURL)
 		} else {
-			cardID, found := templateInfo.ImageURLCardIDMap[card.AlternativeState.ImageURL]
+			cardID, found := templateInfo.ImageURLCardIDMap[plugins.TemplateImageKey(card.AlternativeState.ImageURL, card.AlternativeState.BackImageURL)]
 			if !found {
 				log.Errorw(
 					"Image ID for not found for URL",
@@ -183,17 +295,11 @@ func createCard(
 					"urlIDMap", templateInfo.ImageURLCardIDMap,
 				)
 			}
-			alternateCustomDeck = CustomDeck{
-				FaceURL:      template.URL,
-				BackURL:      customDeck.BackURL,
-				NumWidth:     template.NumCols,
-				NumHeight:    template.NumRows,
-				BackIsHidden: true,
-				UniqueBack:   false,
-			}
+			alternateCustomDeck = newTemplateCustomDeck(template, backURL)
 		}
-		alternateState := createCard(*card.AlternativeState, 1, alternateCustomDeck, templateInfo, cardSize)
-		states = map[string]Object{
+		alternateCustomDeck.Type = customDeck.Type
+		alternateState := createCard(*card.AlternativeState, 1, alternateCustomDeck, templateInfo, cardSize, backURL)
+		states = StatesMap{
 			"2": alternateState,
 		}
 	}
@@ -208,7 +314,7 @@ func createCard(
 	} else {
 		var found bool
 
-		cardID, found = templateInfo.ImageURLCardIDMap[card.ImageURL]
+		cardID, found = templateInfo.ImageURLCardIDMap[plugins.TemplateImageKey(card.ImageURL, card.BackImageURL)]
 		if !found {
 			log.Errorw(
 				"Image ID for not found for URL",
@@ -227,29 +333,13 @@ func createCard(
 		customDeckID = strconv.Itoa(templateID)
 	}
 
-	scaleX := 1.0
-	scaleY := 1.0
-	scaleZ := 1.0
-
-	switch cardSize {
-	case plugins.CardSizeStandard:
-		scaleX = standardScaleX
-		scaleZ = standardScaleZ
-
-		if card.Oversized {
-			scaleX *= standardOversizedScale
-			scaleY *= standardOversizedScale
-			scaleZ *= standardOversizedScale
-		}
-	case plugins.CardSizeSmall:
-		scaleX = smallScaleX
-		scaleZ = smallScaleZ
-	}
+	scaleX, scaleY, scaleZ := cardScale(cardSize, card.Oversized)
 
 	return Object{
 		ObjectType:  CardCustomObject,
 		Nickname:    card.Name,
 		Description: card.Description,
+		GMNotes:     encodeMetadata(card.Metadata),
 		Transform: Transform{
 			PosX:   0,
 			PosY:   0,
@@ -275,7 +365,7 @@ func createCard(
 		HideWhenFaceDown: true,
 		Hands:            true,
 		CardID:           cardID,
-		SidewaysCard:     false,
+		SidewaysCard:     card.Sideways,
 		CustomDeck: map[string]CustomDeck{
 			customDeckID: customDeck,
 		},
@@ -283,7 +373,143 @@ func createCard(
 	}
 }
 
-func create(deck *plugins.Deck, outputFolder string, indent bool) error {
+type generateOptions struct {
+	reproducible    bool
+	thumbnailCards  []string
+	thumbnailBanner bool
+	modCache        *ModCache
+	conflictPolicy  ConflictPolicy
+	// filenameTemplate is the template of the path of the deck files,
+	// relative to the output folder (see FilenameTemplateFields)
+	filenameTemplate string
+	onGenerated      func(GeneratedDeck)
+	// sink receives the generated files (the output folder by default)
+	sink         Sink
+	bundleImages bool
+}
+
+// GeneratedDeck describes the files written for a deck.
+type GeneratedDeck struct {
+	Deck *plugins.Deck
+	// Files are the paths of the saved object and of its thumbnail.
+	Files []string
+}
+
+// GenerateOption configures the generation of the deck files.
+type GenerateOption func(*generateOptions)
+
+// WithReproducible returns an option which leaves the date out of the
+// generated files, so that generating the same decks always results in the
+// same output.
+func WithReproducible() GenerateOption {
+	return func(o *generateOptions) {
+		o.reproducible = true
+	}
+}
+
+// WithThumbnailCards returns an option which fans out the cards with the
+// given names in the deck thumbnails, instead of the cards chosen by the
+// plugin.
+func WithThumbnailCards(names ...string) GenerateOption {
+	return func(o *generateOptions) {
+		o.thumbnailCards = names
+	}
+}
+
+// WithThumbnailBanner returns an option which writes the name of the deck at
+// the bottom of its thumbnail.
+func WithThumbnailBanner() GenerateOption {
+	return func(o *generateOptions) {
+		o.thumbnailBanner = true
+	}
+}
+
+// WithModCache returns an option which writes the images of the decks
+// (including the templates) to the TTS mod cache, so that the decks can be
+// loaded without downloading anything.
+func WithModCache(cache *ModCache) GenerateOption {
+	return func(o *generateOptions) {
+		o.modCache = cache
+	}
+}
+
+// WithConflictPolicy returns an option which sets what to do when the files
+// of a deck already exist (they are overwritten by default).
+func WithConflictPolicy(policy ConflictPolicy) GenerateOption {
+	return func(o *generateOptions) {
+		o.conflictPolicy = policy
+	}
+}
+
+// WithFilenameTemplate returns an option which names the deck files after
+// template (e.g. "{plugin}/{site}/{name} - {board}") instead of the deck
+// name. Subfolders are created for each "/".
+// The template should be checked with ValidateFilenameTemplate.
+func WithFilenameTemplate(template string) GenerateOption {
+	return func(o *generateOptions) {
+		o.filenameTemplate = template
+	}
+}
+
+// WithOnGenerated returns an option which calls onGenerated after the files
+// of each deck are written. The decks which are skipped aren't reported.
+func WithOnGenerated(onGenerated func(GeneratedDeck)) GenerateOption {
+	return func(o *generateOptions) {
+		o.onGenerated = onGenerated
+	}
+}
+
+// WithSink returns an option which writes the generated files to sink
+// instead of the output folder.
+func WithSink(sink Sink) GenerateOption {
+	return func(o *generateOptions) {
+		o.sink = sink
+	}
+}
+
+// WithBundledImages returns an option which writes the card images and the
+// uploaded templates to the sink along with the decks, so that they can be
+// shared offline.
+func WithBundledImages() GenerateOption {
+	return func(o *generateOptions) {
+		o.bundleImages = true
+	}
+}
+
+// thumbnailSources returns the images used to generate the thumbnail of a
+// deck: the cards chosen by the user, the representative cards set by the
+// plugin, or defaultSource.
+func thumbnailSources(deck *plugins.Deck, defaultSource string, options *generateOptions) []string {
+	if len(options.thumbnailCards) > 0 {
+		sources := make([]string, 0, len(options.thumbnailCards))
+		for _, name := range options.thumbnailCards {
+			for _, card := range deck.Cards {
+				if strings.EqualFold(plugins.BaseCardName(card.Name), strings.TrimSpace(name)) {
+					sources = append(sources, card.ImageURL)
+					break
+				}
+			}
+		}
+		if len(sources) > 0 {
+			return sources
+		}
+		log.Debugf("None of the thumbnail cards found in deck %s", deck.Name)
+	}
+
+	if len(deck.ThumbnailURLs) > 1 {
+		return deck.ThumbnailURLs
+	}
+
+	if len(defaultSource) > 0 {
+		return []string{defaultSource}
+	}
+
+	return nil
+}
+
+// buildSavedObject creates the saved object of a deck, and returns the image
+// to use for its thumbnail.
+func buildSavedObject(deck *plugins.Deck, options *generateOptions) (SavedObject, string) {
 	var (
 		object          SavedObject
 		thumbnailSource string
@@ -294,16 +520,9 @@ func create(deck *plugins.Deck, outputFolder string, indent bool) error {
 		card := deck.Cards[0]
 		var customDeck CustomDeck
 		if deck.TemplateInfo == nil {
-			customDeck = CustomDeck{
-				FaceURL:      card.ImageURL,
-				BackURL:      deck.BackURL,
-				NumWidth:     1,
-				NumHeight:    1,
-				BackIsHidden: true,
-				UniqueBack:   false,
-			}
+			customDeck = newCardCustomDeck(card, deck.BackURL)
 		} else {
-			cardID, found := deck.TemplateInfo.ImageURLCardIDMap[card.ImageURL]
+			cardID, found := deck.TemplateInfo.ImageURLCardIDMap[plugins.TemplateImageKey(card.ImageURL, card.BackImageURL)]
 			if !found {
 				log.Errorw(
 					"Image ID for not found for URL",
@@ -319,17 +538,11 @@ func create(deck *plugins.Deck, outputFolder string, indent bool) error {
 					"urlIDMap", deck.TemplateInfo.ImageURLCardIDMap,
 				)
 			}
-			customDeck = CustomDeck{
-				FaceURL:      template.URL,
-				BackURL:      deck.BackURL,
-				NumWidth:     template.NumCols,
-				NumHeight:    template.NumRows,
-				BackIsHidden: true,
-				UniqueBack:   false,
-			}
+			customDeck = newTemplateCustomDeck(template, deck.BackURL)
 		}
+		customDeck.Type = deckShape(deck)
 		object = createSavedObject([]Object{
-			createCard(card, 1, customDeck, deck.TemplateInfo, deck.CardSize),
+			createCard(card, 1, customDeck, deck.TemplateInfo, deck.CardSize, deck.BackURL),
 		})
 		if len(deck.ThumbnailURL) > 0 {
 			thumbnailSource = deck.ThumbnailURL
@@ -340,6 +553,42 @@ func create(deck *plugins.Deck, outputFolder string, indent bool) error {
 		object, thumbnailSource = createDeck(deck)
 	}
 
+	object.VersionNumber = saveVersionNumber
+	if !options.reproducible {
+		object.Date = now().Format(saveDateFormat)
+	}
+	assignGUIDs(&object, deck.Name)
+
+	return object, thumbnailSource
+}
+
+func create(deck *plugins.Deck, sink Sink, indent bool, options *generateOptions) error {
+	name, err := resolveDeckName(deck, sink, options)
+	if err != nil {
+		return err
+	}
+	if len(name) == 0 {
+		// Skipped
+		return nil
+	}
+	defer releaseName(sink, name)
+
+	object, thumbnailSource := buildSavedObject(deck, options)
+
+	return save(object, deck, thumbnailSource, sink, name, indent, options)
+}
+
+// save writes a saved object to name.json in sink, and its thumbnail to
+// name.png.
+func save(
+	object SavedObject,
+	deck *plugins.Deck,
+	thumbnailSource string,
+	sink Sink,
+	name string,
+	indent bool,
+	options *generateOptions,
+) error {
 	var (
 		data []byte
 		err  error
@@ -354,29 +603,73 @@ func create(deck *plugins.Deck, outputFolder string, indent bool) error {
 		return fmt.Errorf("couldn't marshall data: %w", err)
 	}
 
-	deckName := filepathReplacer.Replace(deck.Name)
-
-	filename := filepath.Join(outputFolder, deckName+".json")
-	log.Infof("Generating %s", filename)
+	log.Infof("Generating %s.json", name)
 
-	err = ioutil.WriteFile(filename, data, 0644)
+	location, err := sink.WriteFile(name+".json", writeData(data))
 	if err != nil {
-		return fmt.Errorf("couldn't write file %s: %w", filename, err)
+		return err
 	}
 
-	if len(thumbnailSource) > 0 {
-		err = downloadAndCreateThumbnail(thumbnailSource, filepath.Join(outputFolder, deckName+".png"))
+	files := []string{location}
+
+	var cached map[string]string
+	if options.modCache != nil {
+		cached = options.modCache.cacheImages(object)
+	}
+	if options.bundleImages {
+		bundleImages(sink, object, cached)
+	}
+
+	if sources := thumbnailSources(deck, thumbnailSource, options); len(sources) > 0 {
+		// Use the cached images instead of downloading them again
+		for i, source := range sources {
+			if path, found := cached[source]; found {
+				sources[i] = path
+			} else if options.modCache != nil {
+				// The card images of the templates are cached too
+				if path, found = options.modCache.lookup(source); found {
+					sources[i] = path
+				}
+			}
+		}
+		var banner string
+		if options.thumbnailBanner {
+			banner = deck.Name
+		}
+		location, err = sink.WriteFile(name+".png", func(w io.Writer) error {
+			return createThumbnail(sources, banner, w)
+		})
 		if err != nil {
-			log.Error("Couldn't generate the thumbnail for %s: %v", deckName, err)
+			log.Errorf("Couldn't generate the thumbnail for %s: %v", deck.Name, err)
+		} else if len(location) > 0 {
+			files = append(files, location)
 		}
 	}
 
+	if options.onGenerated != nil {
+		options.onGenerated(GeneratedDeck{Deck: deck, Files: files})
+	}
+
 	return nil
 }
 
-// Generate deck files inside outputFolder.
-func Generate(decks []*plugins.Deck, backURL, outputFolder string, indent bool) []error {
-	log.Infof("Generating %d decks in %s", len(decks), outputFolder)
+// Generate deck files inside outputFolder, or in the sink set with WithSink.
+// The GUIDs of the objects are derived from the name of the deck and cards,
+// so generating the same deck twice gives the same GUIDs.
+func Generate(decks []*plugins.Deck, backURL, outputFolder string, indent bool, options ...GenerateOption) []error {
+	// Default options
+	opts := &generateOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	sink := opts.sink
+	if sink == nil {
+		log.Infof("Generating %d decks in %s", len(decks), outputFolder)
+		sink = NewFolderSink(outputFolder)
+	} else {
+		log.Infof("Generating %d decks", len(decks))
+	}
 
 	errs := []error{}
 
@@ -388,7 +681,7 @@ func Generate(decks []*plugins.Deck, backURL, outputFolder string, indent bool)
 			log.Infof("Deck %s is empty, skipping", deck.Name)
 			continue
 		}
-		err := create(deck, outputFolder, indent)
+		err := create(deck, sink, indent, opts)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("couldn't generate deck %s: %w", deck.Name, err))
 		}
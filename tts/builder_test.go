@@ -0,0 +1,212 @@
+package tts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+func TestDeckShape(t *testing.T) {
+	assert.Equal(t, DeckShapeRectangleRounded, deckShape(&plugins.Deck{Rounded: true}))
+	assert.Equal(t, DeckShapeRectangle, deckShape(&plugins.Deck{Rounded: false}))
+	assert.Equal(t, DeckShapeHexRounded, deckShape(&plugins.Deck{Shape: plugins.CardShapeHex, Rounded: true}))
+	assert.Equal(t, DeckShapeHex, deckShape(&plugins.Deck{Shape: plugins.CardShapeHex}))
+	assert.Equal(t, DeckShapeCircle, deckShape(&plugins.Deck{Shape: plugins.CardShapeCircle, Rounded: true}))
+}
+
+func TestCardScale(t *testing.T) {
+	scaleX, scaleY, scaleZ := cardScale(plugins.CardSizeStandard, false)
+	assert.Equal(t, standardScaleX, scaleX)
+	assert.Equal(t, 1.0, scaleY)
+	assert.Equal(t, standardScaleZ, scaleZ)
+
+	scaleX, scaleY, scaleZ = cardScale(plugins.CardSizeStandard, true)
+	assert.Equal(t, standardScaleX*standardOversizedScale, scaleX)
+	assert.Equal(t, standardOversizedScale, scaleY)
+	assert.Equal(t, standardScaleZ*standardOversizedScale, scaleZ)
+
+	// The oversized flag only applies to standard cards
+	scaleX, scaleY, scaleZ = cardScale(plugins.CardSizeTarot, true)
+	assert.Equal(t, tarotScaleX, scaleX)
+	assert.Equal(t, 1.0, scaleY)
+	assert.Equal(t, tarotScaleZ, scaleZ)
+
+	scaleX, _, scaleZ = cardScale(plugins.CardSizeSquare, false)
+	assert.Equal(t, 70.0, scaleX*56)
+	assert.Equal(t, 70.0, scaleZ*80)
+}
+
+func TestStatesMapMarshalJSON(t *testing.T) {
+	states := StatesMap{}
+	for _, key := range []string{"10", "2", "1"} {
+		states[key] = Object{Nickname: key}
+	}
+
+	data, err := json.Marshal(states)
+	assert.Nil(t, err)
+
+	keys := []int{
+		strings.Index(string(data), `"1":`),
+		strings.Index(string(data), `"2":`),
+		strings.Index(string(data), `"10":`),
+	}
+	for _, key := range keys {
+		assert.GreaterOrEqual(t, key, 0)
+	}
+	assert.Less(t, keys[0], keys[1])
+	assert.Less(t, keys[1], keys[2])
+}
+
+func TestCreateDeterministic(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	createTestImage(t, filepath.Join(tmpDir, "card.png"), 10, 14)
+	server := httptest.NewServer(http.FileServer(http.Dir(tmpDir)))
+	defer server.Close()
+
+	imageURL := server.URL + "/card.png"
+	deck := &plugins.Deck{
+		Name: "Test",
+		Cards: []plugins.CardInfo{
+			{
+				Name:     "Card 1",
+				ImageURL: imageURL + "?1",
+				Count:    2,
+			},
+			{
+				Name:     "Card 2",
+				ImageURL: imageURL + "?2",
+				Count:    1,
+				AlternativeState: &plugins.CardInfo{
+					Name:     "Card 2 Back",
+					ImageURL: imageURL + "?3",
+					Count:    1,
+				},
+			},
+		},
+		BackURL: imageURL,
+	}
+
+	now = func() time.Time {
+		return time.Date(2020, 4, 1, 13, 5, 0, 0, time.UTC)
+	}
+	defer func() {
+		now = time.Now
+	}()
+
+	outputs := make([][]byte, 0, 2)
+	for i := 0; i < 2; i++ {
+		outputFolder := filepath.Join(tmpDir, string(rune('a'+i)))
+		if !assert.Nil(t, os.Mkdir(outputFolder, 0755)) {
+			t.FailNow()
+		}
+		assert.Nil(t, create(deck, NewFolderSink(outputFolder), true, &generateOptions{reproducible: true}))
+		data, err := ioutil.ReadFile(filepath.Join(outputFolder, "Test.json"))
+		assert.Nil(t, err)
+		outputs = append(outputs, data)
+	}
+	assert.Equal(t, string(outputs[0]), string(outputs[1]))
+
+	var object SavedObject
+	assert.Nil(t, json.Unmarshal(outputs[0], &object))
+	assert.Empty(t, object.Date)
+	assert.Equal(t, saveVersionNumber, object.VersionNumber)
+
+	guids := map[string]bool{}
+	deckObject := object.ObjectStates[0]
+	objects := append([]Object{deckObject}, deckObject.ContainedObjects...)
+	objects = append(objects, deckObject.ContainedObjects[2].States["2"])
+	for _, o := range objects {
+		assert.Regexp(t, guidRegex, o.GUID)
+		assert.False(t, guids[o.GUID], "duplicate GUID %s", o.GUID)
+		guids[o.GUID] = true
+	}
+
+	assert.Nil(t, create(deck, NewFolderSink(tmpDir), true, &generateOptions{}))
+	data, err := ioutil.ReadFile(filepath.Join(tmpDir, "Test.json"))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &object))
+	assert.Equal(t, "4/1/2020 1:05:00 PM", object.Date)
+	assert.Equal(t, saveVersionNumber, object.VersionNumber)
+}
+
+func TestGenerateOnGenerated(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	createTestImage(t, filepath.Join(tmpDir, "card.png"), 10, 14)
+	server := httptest.NewServer(http.FileServer(http.Dir(tmpDir)))
+	defer server.Close()
+
+	decks := []*plugins.Deck{
+		{
+			Name: "Test",
+			Cards: []plugins.CardInfo{
+				{
+					Name:     "Card",
+					ImageURL: server.URL + "/card.png",
+					Count:    1,
+				},
+			},
+			BackURL: server.URL + "/card.png",
+		},
+		{
+			Name: "Empty",
+		},
+	}
+
+	var generated []GeneratedDeck
+	errs := Generate(decks, "", tmpDir, true, WithOnGenerated(func(deck GeneratedDeck) {
+		generated = append(generated, deck)
+	}))
+	assert.Empty(t, errs)
+	if assert.Len(t, generated, 1) {
+		assert.Equal(t, decks[0], generated[0].Deck)
+		assert.Equal(t, []string{
+			filepath.Join(tmpDir, "Test.json"),
+			filepath.Join(tmpDir, "Test.png"),
+		}, generated[0].Files)
+	}
+}
+
+func TestThumbnailSources(t *testing.T) {
+	deck := &plugins.Deck{
+		Cards: []plugins.CardInfo{
+			{Name: "Card 1\n[b]Creature[/b]", ImageURL: "1"},
+			{Name: "Card 2", ImageURL: "2"},
+			{Name: "Card 3", ImageURL: "3"},
+		},
+	}
+
+	assert.Equal(t, []string{"1"}, thumbnailSources(deck, "1", &generateOptions{}))
+	assert.Nil(t, thumbnailSources(&plugins.Deck{}, "", &generateOptions{}))
+
+	deck.ThumbnailURLs = []string{"3", "2"}
+	assert.Equal(t, []string{"3", "2"}, thumbnailSources(deck, "1", &generateOptions{}))
+
+	// Cards chosen by the user take precedence
+	assert.Equal(t, []string{"2", "1"}, thumbnailSources(deck, "1", &generateOptions{
+		thumbnailCards: []string{"card 2", "Card 1", "Card 4"},
+	}))
+	// Fall back to the plugin choice if none of the cards are found
+	assert.Equal(t, []string{"3", "2"}, thumbnailSources(deck, "1", &generateOptions{
+		thumbnailCards: []string{"Card 4"},
+	}))
+}
@@ -0,0 +1,432 @@
+package tts
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+)
+
+const (
+	// DefaultCheckConcurrency is the default number of links checked at the
+	// same time.
+	DefaultCheckConcurrency = 8
+	// DefaultSlowThreshold is the default duration after which a link is
+	// considered slow.
+	DefaultSlowThreshold = 3 * time.Second
+	// DefaultCheckTimeout is the default timeout of each request.
+	DefaultCheckTimeout = 30 * time.Second
+)
+
+const (
+	// With scale 1.0, cards are approximately 56×80mm
+	baseCardWidth  = 56.0
+	baseCardHeight = 80.0
+	// templateRatioTolerance is the maximum relative difference between the
+	// aspect ratio of the cells of a template sheet and the one of the cards.
+	templateRatioTolerance = 0.1
+)
+
+// LinkProblem is the type of problem found with a link.
+type LinkProblem string
+
+const (
+	// LinkBroken is reported for links which can't be retrieved.
+	LinkBroken LinkProblem = "broken"
+	// LinkSlow is reported for links which take too long to respond.
+	LinkSlow LinkProblem = "slow"
+	// LinkTemplateSize is reported for template sheets whose size doesn't
+	// match their number of columns and rows.
+	LinkTemplateSize LinkProblem = "template_size"
+)
+
+// LinkReport describes a problem found with an image link.
+type LinkReport struct {
+	// URL of the image.
+	URL string `json:"url"`
+	// Problem found with the image.
+	Problem LinkProblem `json:"problem"`
+	// Detail of the problem (e.g. the HTTP status).
+	Detail string `json:"detail"`
+	// Duration of the request, in milliseconds.
+	Duration int64 `json:"duration_ms"`
+	// Files are the saved objects referring to the image.
+	Files []string `json:"files"`
+}
+
+// CheckResult is the result of a link check.
+type CheckResult struct {
+	// Files is the number of saved objects checked.
+	Files int `json:"files"`
+	// Links is the number of distinct links checked.
+	Links int `json:"links"`
+	// Problems found, sorted by URL.
+	Problems []LinkReport `json:"problems"`
+}
+
+// CheckOption configures the link check.
+type CheckOption func(*checkOptions)
+
+type checkOptions struct {
+	concurrency   int
+	slowThreshold time.Duration
+	timeout       time.Duration
+}
+
+// WithConcurrency returns an option setting the number of links checked at
+// the same time.
+func WithConcurrency(concurrency int) CheckOption {
+	return func(o *checkOptions) {
+		if concurrency > 0 {
+			o.concurrency = concurrency
+		}
+	}
+}
+
+// WithSlowThreshold returns an option setting the duration after which a link
+// is reported as slow.
+func WithSlowThreshold(threshold time.Duration) CheckOption {
+	return func(o *checkOptions) {
+		o.slowThreshold = threshold
+	}
+}
+
+// WithTimeout returns an option setting the timeout of each request.
+func WithTimeout(timeout time.Duration) CheckOption {
+	return func(o *checkOptions) {
+		o.timeout = timeout
+	}
+}
+
+// link is an image referred to by one or several saved objects.
+type link struct {
+	location string
+	files    []string
+	// template is set for template sheets.
+	template *templateSheet
+}
+
+// templateSheet is the expected layout of a template sheet.
+type templateSheet struct {
+	numCols int
+	numRows int
+	// ratio is the expected height/width ratio of the cards.
+	ratio float64
+}
+
+// CheckLinks checks the card images of the saved objects found at paths,
+// which can be saved object files or folders (searched recursively).
+// Each distinct image is only checked once. Only the links having a problem
+// are reported. The errors are the saved objects which couldn't be read.
+func CheckLinks(paths []string, options ...CheckOption) (CheckResult, []error) {
+	// Default options
+	opts := &checkOptions{
+		concurrency:   DefaultCheckConcurrency,
+		slowThreshold: DefaultSlowThreshold,
+		timeout:       DefaultCheckTimeout,
+	}
+	for _, option := range options {
+		option(opts)
+	}
+
+	var (
+		result CheckResult
+		errs   []error
+	)
+
+	files, err := savedObjectFiles(paths)
+	if err != nil {
+		return result, []error{err}
+	}
+
+	links := make(map[string]*link)
+	for _, file := range files {
+		object, err := ReadSavedObjectFile(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Files++
+		for _, objectState := range object.ObjectStates {
+			collectLinks(objectState, file, links)
+		}
+	}
+	result.Links = len(links)
+
+	log.Infof("Checking %d links from %d saved objects", result.Links, result.Files)
+
+	result.Problems = checkLinks(links, opts)
+
+	return result, errs
+}
+
+// savedObjectFiles returns the JSON files found at paths.
+func savedObjectFiles(paths []string) ([]string, error) {
+	var files []string
+
+	for _, path := range paths {
+		err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			// Explicitly given files are always checked
+			if file == path || strings.EqualFold(filepath.Ext(file), ".json") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// collectLinks adds the images used by an object, its states and the
+// objects it contains to links.
+func collectLinks(object Object, file string, links map[string]*link) {
+	for _, customDeck := range object.CustomDeck {
+		var template *templateSheet
+		// The cells of hex and circle templates don't have the proportions
+		// of the cards
+		rectangle := customDeck.Type == DeckShapeRectangleRounded || customDeck.Type == DeckShapeRectangle
+		if customDeck.NumWidth*customDeck.NumHeight > 1 && rectangle {
+			template = &templateSheet{
+				numCols: customDeck.NumWidth,
+				numRows: customDeck.NumHeight,
+				ratio:   cardRatio(object),
+			}
+		}
+		addLink(links, customDeck.FaceURL, file, template)
+		addLink(links, customDeck.BackURL, file, nil)
+	}
+
+	for _, contained := range object.ContainedObjects {
+		collectLinks(contained, file, links)
+	}
+	for _, state := range object.States {
+		collectLinks(state, file, links)
+	}
+}
+
+func addLink(links map[string]*link, location, file string, template *templateSheet) {
+	if len(location) == 0 {
+		return
+	}
+
+	l, found := links[location]
+	if !found {
+		l = &link{location: location}
+		links[location] = l
+	}
+	if len(l.files) == 0 || l.files[len(l.files)-1] != file {
+		l.files = append(l.files, file)
+	}
+	if l.template == nil {
+		l.template = template
+	}
+}
+
+// cardRatio returns the height/width ratio of the image of a card, based on
+// the scale of its object.
+func cardRatio(object Object) float64 {
+	scaleX := object.Transform.ScaleX
+	scaleZ := object.Transform.ScaleZ
+	if scaleX == 0 || scaleZ == 0 {
+		scaleX = 1
+		scaleZ = 1
+	}
+
+	ratio := (baseCardHeight * scaleZ) / (baseCardWidth * scaleX)
+	if object.SidewaysCard {
+		return 1 / ratio
+	}
+
+	return ratio
+}
+
+// checkLinks checks the links concurrently, and returns the problems found.
+func checkLinks(links map[string]*link, opts *checkOptions) []LinkReport {
+	client := &http.Client{Timeout: opts.timeout}
+	jobs := make(chan *link)
+	reports := make(chan LinkReport)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for l := range jobs {
+				if report, found := checkLink(client, l, opts.slowThreshold); found {
+					reports <- report
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for _, l := range links {
+			jobs <- l
+		}
+		close(jobs)
+		wg.Wait()
+		close(reports)
+	}()
+
+	problems := []LinkReport{}
+	for report := range reports {
+		problems = append(problems, report)
+	}
+
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].URL < problems[j].URL
+	})
+
+	return problems
+}
+
+// checkLink checks a single link, and returns the problem found, if any.
+func checkLink(client *http.Client, l *link, slowThreshold time.Duration) (LinkReport, bool) {
+	report := LinkReport{
+		URL:   l.location,
+		Files: l.files,
+	}
+
+	log.Debugf("Checking %s", l.location)
+
+	start := time.Now()
+	size, err := fetchImage(client, parseImageSource(l.location), l.template != nil)
+	duration := time.Since(start)
+	report.Duration = duration.Milliseconds()
+
+	switch {
+	case err != nil:
+		report.Problem = LinkBroken
+		report.Detail = err.Error()
+	case l.template != nil && !l.template.matches(size):
+		report.Problem = LinkTemplateSize
+		report.Detail = fmt.Sprintf(
+			"%d×%d pixels sheet for %d×%d cards, expected cards with a height/width ratio of %.2f",
+			size.X, size.Y, l.template.numCols, l.template.numRows, l.template.ratio,
+		)
+	case slowThreshold > 0 && duration > slowThreshold:
+		report.Problem = LinkSlow
+		report.Detail = fmt.Sprintf("responded in %s", duration.Round(time.Millisecond))
+	default:
+		return report, false
+	}
+
+	return report, true
+}
+
+// fetchImage checks that an image can be retrieved. If decode is set, the
+// image is downloaded and its size returned.
+func fetchImage(client *http.Client, source imageSource, decode bool) (size image.Point, err error) {
+	if !decode {
+		return size, checkImageExists(client, source)
+	}
+
+	var reader io.ReadCloser
+	if source.sourceType == sourceHTTP {
+		reader, err = getImage(client, source.location)
+	} else {
+		reader, err = source.open()
+	}
+	if err != nil {
+		return
+	}
+	defer func() {
+		if cerr := reader.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	config, _, err := image.DecodeConfig(reader)
+	if err != nil {
+		return size, fmt.Errorf("invalid image: %w", err)
+	}
+
+	return image.Pt(config.Width, config.Height), nil
+}
+
+// checkImageExists checks that an image can be retrieved, without
+// downloading it.
+func checkImageExists(client *http.Client, source imageSource) error {
+	switch source.sourceType {
+	case sourceHTTP:
+		return headImage(client, source.location)
+	case sourceData:
+		_, err := decodeDataURI(source.location)
+		return err
+	default:
+		_, err := os.Stat(source.path)
+		return err
+	}
+}
+
+// headImage sends a HEAD request to url, or a GET request if the server
+// doesn't support HEAD requests.
+func headImage(client *http.Client, url string) error {
+	resp, err := client.Head(url)
+	if err != nil {
+		return err
+	}
+	// The body isn't used, so the close error can be ignored
+	_ = resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		reader, err := getImage(client, url)
+		if err != nil {
+			return err
+		}
+		_ = reader.Close()
+		return nil
+	default:
+		return fmt.Errorf("bad status: %s", resp.Status)
+	}
+}
+
+// getImage sends a GET request to url.
+func getImage(client *http.Client, url string) (io.ReadCloser, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// The body isn't used, so the close error can be ignored
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// matches returns whether or not a template sheet of the given size can hold
+// cards with the expected ratio.
+func (t templateSheet) matches(size image.Point) bool {
+	if size.X == 0 || size.Y == 0 {
+		return false
+	}
+
+	cellWidth := float64(size.X) / float64(t.numCols)
+	cellHeight := float64(size.Y) / float64(t.numRows)
+
+	return math.Abs(cellHeight/cellWidth-t.ratio)/t.ratio <= templateRatioTolerance
+}
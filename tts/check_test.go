@@ -0,0 +1,122 @@
+package tts
+
+import (
+	"encoding/json"
+	"image"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLinks(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	imageDir := filepath.Join(tmpDir, "images")
+	chestDir := filepath.Join(tmpDir, "chest", "folder")
+	for _, dir := range []string{imageDir, chestDir} {
+		if !assert.Nil(t, os.MkdirAll(dir, 0755)) {
+			t.FailNow()
+		}
+	}
+
+	createTestImage(t, filepath.Join(imageDir, "card.png"), 50, 70)
+	// 2×1 sheet of 50×70 cards
+	createTestImage(t, filepath.Join(imageDir, "template.png"), 100, 70)
+	// 2×1 sheet of 50×140 cells
+	createTestImage(t, filepath.Join(imageDir, "stretched.png"), 100, 140)
+
+	files := http.FileServer(http.Dir(imageDir))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.png" {
+			time.Sleep(100 * time.Millisecond)
+			r.URL.Path = "/card.png"
+		}
+		files.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	deck := func(customDeck CustomDeck) Object {
+		return Object{
+			ObjectType: DeckCustomObject,
+			Transform:  Transform{ScaleX: 1, ScaleY: 1, ScaleZ: 1},
+			CustomDeck: CustomDeckMap{"1": customDeck},
+		}
+	}
+	object := createSavedObject([]Object{
+		deck(CustomDeck{
+			FaceURL:   server.URL + "/card.png",
+			BackURL:   server.URL + "/missing.png",
+			NumWidth:  1,
+			NumHeight: 1,
+		}),
+		deck(CustomDeck{
+			FaceURL:   server.URL + "/template.png",
+			BackURL:   server.URL + "/card.png",
+			NumWidth:  2,
+			NumHeight: 1,
+		}),
+		deck(CustomDeck{
+			FaceURL:   server.URL + "/stretched.png",
+			BackURL:   server.URL + "/slow.png",
+			NumWidth:  2,
+			NumHeight: 1,
+		}),
+	})
+	data, err := json.Marshal(object)
+	assert.Nil(t, err)
+	path := filepath.Join(chestDir, "Deck.json")
+	assert.Nil(t, ioutil.WriteFile(path, data, 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(chestDir, "Deck.png"), nil, 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(chestDir, "Invalid.json"), []byte("{"), 0644))
+
+	result, errs := CheckLinks(
+		[]string{filepath.Join(tmpDir, "chest")},
+		WithConcurrency(2),
+		WithSlowThreshold(50*time.Millisecond),
+	)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, 1, result.Files)
+	assert.Equal(t, 5, result.Links)
+	if assert.Len(t, result.Problems, 3) {
+		assert.Equal(t, server.URL+"/missing.png", result.Problems[0].URL)
+		assert.Equal(t, LinkBroken, result.Problems[0].Problem)
+		assert.Equal(t, "bad status: 404 Not Found", result.Problems[0].Detail)
+		assert.Equal(t, []string{path}, result.Problems[0].Files)
+
+		assert.Equal(t, server.URL+"/slow.png", result.Problems[1].URL)
+		assert.Equal(t, LinkSlow, result.Problems[1].Problem)
+		assert.GreaterOrEqual(t, result.Problems[1].Duration, int64(100))
+
+		assert.Equal(t, server.URL+"/stretched.png", result.Problems[2].URL)
+		assert.Equal(t, LinkTemplateSize, result.Problems[2].Problem)
+	}
+}
+
+func TestCardRatio(t *testing.T) {
+	assert.InDelta(t, 80.0/56, cardRatio(Object{}), 0.001)
+
+	scaleX, scaleY, scaleZ := cardScale(0, false)
+	object := Object{Transform: Transform{ScaleX: scaleX, ScaleY: scaleY, ScaleZ: scaleZ}}
+	assert.InDelta(t, 88.9/63.5, cardRatio(object), 0.001)
+
+	object.SidewaysCard = true
+	assert.InDelta(t, 63.5/88.9, cardRatio(object), 0.001)
+}
+
+func TestTemplateSheetMatches(t *testing.T) {
+	template := templateSheet{numCols: 10, numRows: 7, ratio: 1.4}
+
+	assert.True(t, template.matches(image.Pt(4096, 4014)))
+	assert.False(t, template.matches(image.Pt(4096, 2048)))
+	assert.False(t, template.matches(image.Pt(0, 0)))
+}
@@ -4,42 +4,178 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
 
 	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
 )
 
-// FindChestPath tries to the find TableTop Simulator check folder
-// (where the saved objects are located).
+// FindChestPath tries to the find TableTop Simulator chest folder
+// (where the saved objects are located), inside the TTS data folder (see
+// FindDataDir).
 func FindChestPath() (string, error) {
-	var chestPath string
-
-	home, err := os.UserHomeDir()
+	dataDir, err := FindDataDir()
 	if err != nil {
 		return "", err
 	}
 
-	switch runtime.GOOS {
-	case "windows":
-		// On some Windows machines `os.UserHomeDir()` seems to return the OneDrive folder
-		home = strings.TrimSuffix(home, `\OneDrive`)
-		chestPath = filepath.Join(home, `\Documents\My Games\Tabletop Simulator\Saves\Saved Objects`)
-	case "darwin":
-		chestPath = filepath.Join(home, "/Library/Tabletop Simulator/Saves/Saved Objects")
-	default:
-		chestPath = filepath.Join(home, "/.local/share/Tabletop Simulator/Saves/Saved Objects")
-	}
+	chestPath := filepath.Join(dataDir, "Saves", "Saved Objects")
 
 	log.Debugf("Chest path: \"%s\"", chestPath)
 
-	if stat, err := os.Stat(chestPath); os.IsNotExist(err) {
-		return "", fmt.Errorf("chest path \"%s\" doesn't exist", chestPath)
-	} else if err != nil {
-		return "", err
-	} else if !stat.IsDir() {
-		return "", fmt.Errorf("chest path \"%s\" is not a directory", chestPath)
+	if err := checkDir(chestPath); err != nil {
+		return "", fmt.Errorf("chest path %w", err)
 	}
 
 	return chestPath, nil
 }
+
+// ChestEntry is a saved object found in the chest.
+type ChestEntry struct {
+	// Path of the saved object JSON file.
+	Path string
+	// Name of the saved object.
+	Name string
+	// Thumbnail is the path of the thumbnail of the saved object, if it
+	// exists.
+	Thumbnail string
+	// DeckNames are the names of the decks contained in the saved object.
+	DeckNames []string
+	// CardNames are the names of the cards contained in the saved object.
+	CardNames []string
+}
+
+// ListChest returns the saved objects found in folder and its subfolders.
+// Files which can't be read as saved objects are ignored.
+func ListChest(folder string) ([]ChestEntry, error) {
+	var entries []ChestEntry
+
+	err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.EqualFold(filepath.Ext(path), ".json") {
+			return nil
+		}
+
+		entry, err := readChestEntry(path)
+		if err != nil {
+			log.Debugf("Ignoring %s: %v", path, err)
+			return nil
+		}
+		entries = append(entries, entry)
+
+		return nil
+	})
+
+	return entries, err
+}
+
+func readChestEntry(path string) (ChestEntry, error) {
+	entry := ChestEntry{
+		Path: path,
+		Name: SavedObjectName(path),
+	}
+
+	object, err := ReadSavedObjectFile(path)
+	if err != nil {
+		return entry, err
+	}
+
+	if thumbnail := thumbnailPath(path); fileExists(thumbnail) {
+		entry.Thumbnail = thumbnail
+	}
+
+	for _, deck := range ExtractDecks(object, entry.Name) {
+		entry.DeckNames = append(entry.DeckNames, deck.Name)
+		for _, card := range deck.Cards {
+			entry.CardNames = append(entry.CardNames, plugins.BaseCardName(card.Name))
+		}
+	}
+
+	return entry, nil
+}
+
+// Matches returns whether or not the name of the saved object, or the name
+// of one of its decks or cards, contains query (case-insensitive).
+func (e ChestEntry) Matches(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if len(query) == 0 {
+		return true
+	}
+
+	names := append([]string{e.Name}, e.DeckNames...)
+	names = append(names, e.CardNames...)
+	for _, name := range names {
+		if strings.Contains(strings.ToLower(name), query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RenameSavedObject renames the saved object found at path, along with its
+// thumbnail, and returns its new path.
+func RenameSavedObject(path, name string) (string, error) {
+	if len(name) == 0 || name != filepathReplacer.Replace(name) {
+		return "", fmt.Errorf("invalid name: %s", name)
+	}
+
+	return moveSavedObject(path, filepath.Join(filepath.Dir(path), name+filepath.Ext(path)))
+}
+
+// MoveSavedObject moves the saved object found at path, along with its
+// thumbnail, to folder, which is created if it doesn't exist. The new path is
+// returned.
+func MoveSavedObject(path, folder string) (string, error) {
+	if err := os.MkdirAll(folder, 0o755); err != nil {
+		return "", err
+	}
+
+	return moveSavedObject(path, filepath.Join(folder, filepath.Base(path)))
+}
+
+func moveSavedObject(path, newPath string) (string, error) {
+	if fileExists(newPath) {
+		return "", fmt.Errorf("%s already exists", newPath)
+	}
+
+	if err := os.Rename(path, newPath); err != nil {
+		return "", err
+	}
+
+	thumbnail := thumbnailPath(path)
+	if fileExists(thumbnail) {
+		if err := os.Rename(thumbnail, thumbnailPath(newPath)); err != nil {
+			return newPath, fmt.Errorf("couldn't move the thumbnail of %s: %w", newPath, err)
+		}
+	}
+
+	return newPath, nil
+}
+
+// DeleteSavedObject deletes the saved object found at path, along with its
+// thumbnail.
+func DeleteSavedObject(path string) error {
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	thumbnail := thumbnailPath(path)
+	if fileExists(thumbnail) {
+		return os.Remove(thumbnail)
+	}
+
+	return nil
+}
+
+// thumbnailPath returns the path of the thumbnail of a saved object.
+func thumbnailPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".png"
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
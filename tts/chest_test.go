@@ -0,0 +1,83 @@
+package tts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+func writeTestSavedObject(t *testing.T, path string, deck *plugins.Deck) {
+	object, _ := buildSavedObject(deck, &generateOptions{reproducible: true})
+	data, err := json.Marshal(object)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	if !assert.Nil(t, ioutil.WriteFile(path, data, 0644)) {
+		t.FailNow()
+	}
+}
+
+func TestChest(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if !assert.Nil(t, os.Mkdir(filepath.Join(tmpDir, "MTG"), 0755)) {
+		t.FailNow()
+	}
+	path := filepath.Join(tmpDir, "MTG", "Burn.json")
+	writeTestSavedObject(t, path, &plugins.Deck{
+		Name: "Burn",
+		Cards: []plugins.CardInfo{
+			{Name: "Lightning Bolt\n[b]Instant[/b]", ImageURL: "https://example.com/1.png", Count: 4},
+			{Name: "Goblin Guide", ImageURL: "https://example.com/2.png", Count: 4},
+		},
+		BackURL: "https://example.com/back.png",
+	})
+	createTestImage(t, filepath.Join(tmpDir, "MTG", "Burn.png"), 10, 10)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(tmpDir, "Invalid.json"), []byte("{"), 0644))
+
+	entries, err := ListChest(tmpDir)
+	assert.Nil(t, err)
+	if !assert.Len(t, entries, 1) {
+		t.FailNow()
+	}
+	entry := entries[0]
+	assert.Equal(t, path, entry.Path)
+	assert.Equal(t, "Burn", entry.Name)
+	assert.Equal(t, filepath.Join(tmpDir, "MTG", "Burn.png"), entry.Thumbnail)
+	assert.Equal(t, []string{"Burn"}, entry.DeckNames)
+	assert.Equal(t, []string{"Lightning Bolt", "Goblin Guide"}, entry.CardNames)
+
+	assert.True(t, entry.Matches(""))
+	assert.True(t, entry.Matches("burn"))
+	assert.True(t, entry.Matches("lightning"))
+	assert.False(t, entry.Matches("Instant"))
+	assert.False(t, entry.Matches("Counterspell"))
+
+	_, err = RenameSavedObject(path, "Red/Burn")
+	assert.NotNil(t, err)
+
+	path, err = RenameSavedObject(path, "Red Burn")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "MTG", "Red Burn.json"), path)
+	assert.FileExists(t, filepath.Join(tmpDir, "MTG", "Red Burn.png"))
+
+	path, err = MoveSavedObject(path, filepath.Join(tmpDir, "MTG", "Modern"))
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "MTG", "Modern", "Red Burn.json"), path)
+	assert.FileExists(t, filepath.Join(tmpDir, "MTG", "Modern", "Red Burn.png"))
+	assert.NoFileExists(t, filepath.Join(tmpDir, "MTG", "Red Burn.json"))
+
+	assert.Nil(t, DeleteSavedObject(path))
+	assert.NoFileExists(t, path)
+	assert.NoFileExists(t, filepath.Join(tmpDir, "MTG", "Modern", "Red Burn.png"))
+}
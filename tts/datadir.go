@@ -0,0 +1,224 @@
+package tts
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+)
+
+// DataDirEnv is the environment variable which can be used to set the TTS
+// data folder (the folder containing the Saves and Mods folders).
+const DataDirEnv = "TTS_DATA_DIR"
+
+// ttsAppID is the Steam application ID of TTS.
+const ttsAppID = "286160"
+
+// dataDirOverride is the TTS data folder set by SetDataDir.
+var dataDirOverride string
+
+// SetDataDir sets the TTS data folder, e.g. from a configuration file.
+// It takes precedence over DataDirEnv and the automatic discovery.
+func SetDataDir(path string) {
+	dataDirOverride = path
+}
+
+// FindDataDir tries to find the TTS data folder (the folder containing the
+// Saves and Mods folders).
+// The folder set with SetDataDir or DataDirEnv is used if there is one.
+// Otherwise, the default location of the current platform is checked, as
+// well as the Flatpak version of Steam and the Proton prefixes of the Steam
+// libraries.
+func FindDataDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return findDataDir(runtime.GOOS, home, os.Getenv)
+}
+
+func findDataDir(goos, home string, getenv func(string) string) (string, error) {
+	for _, path := range []string{dataDirOverride, getenv(DataDirEnv)} {
+		if len(path) == 0 {
+			continue
+		}
+		// The folder has been set explicitly, so don't look elsewhere
+		log.Debugf("TTS data folder: \"%s\"", path)
+		if err := checkDir(path); err != nil {
+			return "", fmt.Errorf("TTS data folder %w", err)
+		}
+		return path, nil
+	}
+
+	candidates := dataDirCandidates(goos, home, getenv)
+	for _, path := range candidates {
+		log.Debugf("Looking for the TTS data folder in \"%s\"", path)
+		if checkDir(filepath.Join(path, "Saves")) == nil {
+			log.Debugf("TTS data folder: \"%s\"", path)
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf(
+		"couldn't find the Tabletop Simulator data folder (tried \"%s\"), set it with %s",
+		strings.Join(candidates, "\", \""),
+		DataDirEnv,
+	)
+}
+
+// dataDirCandidates returns the folders where the TTS data folder can be
+// found, in order of preference.
+func dataDirCandidates(goos, home string, getenv func(string) string) []string {
+	var candidates []string
+
+	switch goos {
+	case "windows":
+		// On some Windows machines `os.UserHomeDir()` seems to return the OneDrive folder
+		home = strings.TrimSuffix(home, `\OneDrive`)
+		candidates = append(
+			candidates,
+			filepath.Join(home, "Documents", "My Games", "Tabletop Simulator"),
+			// The Documents folder can be synchronized with OneDrive
+			filepath.Join(home, "OneDrive", "Documents", "My Games", "Tabletop Simulator"),
+		)
+	case "darwin":
+		candidates = append(candidates, filepath.Join(home, "Library", "Tabletop Simulator"))
+	default:
+		if xdgDataHome := getenv("XDG_DATA_HOME"); len(xdgDataHome) > 0 {
+			candidates = append(candidates, filepath.Join(xdgDataHome, "Tabletop Simulator"))
+		}
+		candidates = append(
+			candidates,
+			filepath.Join(home, ".local", "share", "Tabletop Simulator"),
+			// Flatpak version of Steam
+			filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Tabletop Simulator"),
+		)
+		// Windows version of TTS running with Proton
+		for _, library := range steamLibraries(steamRoots(goos, home, getenv)) {
+			candidates = append(candidates, filepath.Join(
+				library, "steamapps", "compatdata", ttsAppID, "pfx", "drive_c",
+				"users", "steamuser", "Documents", "My Games", "Tabletop Simulator",
+			))
+		}
+	}
+
+	return candidates
+}
+
+// steamRoots returns the folders where Steam can be installed.
+func steamRoots(goos, home string, getenv func(string) string) []string {
+	switch goos {
+	case "windows":
+		var roots []string
+		for _, env := range []string{"ProgramFiles(x86)", "ProgramFiles"} {
+			if programFiles := getenv(env); len(programFiles) > 0 {
+				roots = append(roots, filepath.Join(programFiles, "Steam"))
+			}
+		}
+		return roots
+	case "darwin":
+		return []string{filepath.Join(home, "Library", "Application Support", "Steam")}
+	default:
+		return []string{
+			filepath.Join(home, ".steam", "steam"),
+			filepath.Join(home, ".local", "share", "Steam"),
+			filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+			filepath.Join(home, "snap", "steam", "common", ".local", "share", "Steam"),
+		}
+	}
+}
+
+// steamLibraries returns the Steam library folders of the Steam
+// installations found in roots (including the roots themselves).
+func steamLibraries(roots []string) []string {
+	var libraries []string
+	seen := make(map[string]bool)
+
+	add := func(library string) {
+		// ~/.steam/steam is usually a symbolic link to another root
+		if resolved, err := filepath.EvalSymlinks(library); err == nil {
+			library = resolved
+		}
+		if !seen[library] {
+			seen[library] = true
+			libraries = append(libraries, library)
+		}
+	}
+
+	for _, root := range roots {
+		if checkDir(root) != nil {
+			continue
+		}
+		add(root)
+
+		folders, err := readLibraryFolders(filepath.Join(root, "steamapps", "libraryfolders.vdf"))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.Warnf("Couldn't read the Steam library folders of %s: %v", root, err)
+			}
+			continue
+		}
+		for _, folder := range folders {
+			add(folder)
+		}
+	}
+
+	return libraries
+}
+
+var (
+	// libraryPathRegex matches the path of a library in libraryfolders.vdf
+	libraryPathRegex = regexp.MustCompile(`^\s*"path"\s+"(.*)"\s*$`)
+	// oldLibraryPathRegex matches the path of a library in the old format of
+	// libraryfolders.vdf (`"1" "D:\\SteamLibrary"`)
+	oldLibraryPathRegex = regexp.MustCompile(`^\s*"\d+"\s+"(.*[^\d].*)"\s*$`)
+)
+
+// readLibraryFolders returns the library folders listed in a Steam
+// libraryfolders.vdf file.
+func readLibraryFolders(path string) (folders []string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	unescape := strings.NewReplacer(`\\`, `\`, `\"`, `"`)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		matches := libraryPathRegex.FindStringSubmatch(line)
+		if matches == nil {
+			matches = oldLibraryPathRegex.FindStringSubmatch(line)
+		}
+		if matches != nil {
+			folders = append(folders, unescape.Replace(matches[1]))
+		}
+	}
+
+	return folders, scanner.Err()
+}
+
+// checkDir returns an error if path isn't an existing folder.
+func checkDir(path string) error {
+	if stat, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("\"%s\" doesn't exist", path)
+	} else if err != nil {
+		return err
+	} else if !stat.IsDir() {
+		return fmt.Errorf("\"%s\" is not a directory", path)
+	}
+
+	return nil
+}
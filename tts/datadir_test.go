@@ -0,0 +1,156 @@
+package tts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadLibraryFolders(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "libraryfolders.vdf")
+
+	err = ioutil.WriteFile(path, []byte(`"libraryfolders"
+{
+	"0"
+	{
+		"path"		"C:\\Program Files (x86)\\Steam"
+		"label"		""
+		"apps"
+		{
+			"228980"		"1234"
+		}
+	}
+	"1"
+	{
+		"path"		"/mnt/games/SteamLibrary"
+		"apps"
+		{
+			"286160"		"5678"
+		}
+	}
+}
+`), 0644)
+	assert.Nil(t, err)
+	folders, err := readLibraryFolders(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`C:\Program Files (x86)\Steam`, "/mnt/games/SteamLibrary"}, folders)
+
+	// Old format
+	err = ioutil.WriteFile(path, []byte(`"LibraryFolders"
+{
+	"TimeNextStatsReport"		"1600000000"
+	"ContentStatsID"		"-1234"
+	"1"		"D:\\SteamLibrary"
+}
+`), 0644)
+	assert.Nil(t, err)
+	folders, err = readLibraryFolders(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`D:\SteamLibrary`}, folders)
+}
+
+func TestFindDataDir(t *testing.T) {
+	home, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(home)
+	// The Steam libraries are resolved
+	home, err = filepath.EvalSymlinks(home)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	env := map[string]string{}
+	getenv := func(key string) string {
+		return env[key]
+	}
+
+	_, err = findDataDir("linux", home, getenv)
+	assert.NotNil(t, err)
+
+	// Proton prefix in a secondary Steam library
+	library := filepath.Join(home, "SteamLibrary")
+	protonDir := filepath.Join(
+		library, "steamapps", "compatdata", "286160", "pfx", "drive_c",
+		"users", "steamuser", "Documents", "My Games", "Tabletop Simulator",
+	)
+	assert.Nil(t, os.MkdirAll(filepath.Join(protonDir, "Saves"), 0755))
+	steamApps := filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam", "steamapps")
+	assert.Nil(t, os.MkdirAll(steamApps, 0755))
+	err = ioutil.WriteFile(
+		filepath.Join(steamApps, "libraryfolders.vdf"),
+		[]byte("\"libraryfolders\"\n{\n\t\"1\"\n\t{\n\t\t\"path\"\t\t\""+library+"\"\n\t}\n}\n"),
+		0644,
+	)
+	assert.Nil(t, err)
+
+	path, err := findDataDir("linux", home, getenv)
+	assert.Nil(t, err)
+	assert.Equal(t, protonDir, path)
+
+	// Flatpak
+	flatpakDir := filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Tabletop Simulator")
+	assert.Nil(t, os.MkdirAll(filepath.Join(flatpakDir, "Saves"), 0755))
+
+	path, err = findDataDir("linux", home, getenv)
+	assert.Nil(t, err)
+	assert.Equal(t, flatpakDir, path)
+
+	// Native
+	nativeDir := filepath.Join(home, ".local", "share", "Tabletop Simulator")
+	assert.Nil(t, os.MkdirAll(filepath.Join(nativeDir, "Saves"), 0755))
+
+	path, err = findDataDir("linux", home, getenv)
+	assert.Nil(t, err)
+	assert.Equal(t, nativeDir, path)
+
+	// Environment variable
+	env[DataDirEnv] = protonDir
+
+	path, err = findDataDir("linux", home, getenv)
+	assert.Nil(t, err)
+	assert.Equal(t, protonDir, path)
+
+	env[DataDirEnv] = filepath.Join(home, "missing")
+
+	_, err = findDataDir("linux", home, getenv)
+	assert.NotNil(t, err)
+
+	// Configuration
+	SetDataDir(flatpakDir)
+	defer SetDataDir("")
+
+	path, err = findDataDir("linux", home, getenv)
+	assert.Nil(t, err)
+	assert.Equal(t, flatpakDir, path)
+}
+
+func TestDataDirCandidates(t *testing.T) {
+	getenv := func(string) string {
+		return ""
+	}
+
+	assert.Equal(
+		t,
+		[]string{
+			filepath.Join("home", "Documents", "My Games", "Tabletop Simulator"),
+			filepath.Join("home", "OneDrive", "Documents", "My Games", "Tabletop Simulator"),
+		},
+		dataDirCandidates("windows", "home", getenv),
+	)
+	assert.Equal(
+		t,
+		[]string{filepath.Join("home", "Library", "Tabletop Simulator")},
+		dataDirCandidates("darwin", "home", getenv),
+	)
+}
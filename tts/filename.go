@@ -0,0 +1,284 @@
+package tts
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+// ConflictPolicy is what to do when the file of a generated deck already
+// exists.
+type ConflictPolicy string
+
+const (
+	// ConflictOverwrite overwrites the existing files.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictSkip keeps the existing files, and doesn't generate the deck.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictRename generates the deck under a new name, by appending a
+	// number to it (e.g. "Burn (2)").
+	ConflictRename ConflictPolicy = "rename"
+	// ConflictBackup renames the existing files with a timestamp before
+	// overwriting them.
+	ConflictBackup ConflictPolicy = "backup"
+)
+
+// ConflictPolicies are the available conflict policies.
+var ConflictPolicies = []ConflictPolicy{ConflictOverwrite, ConflictSkip, ConflictRename, ConflictBackup}
+
+// ParseConflictPolicy returns the conflict policy named s.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	for _, policy := range ConflictPolicies {
+		if string(policy) == s {
+			return policy, nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid conflict policy: %s", s)
+}
+
+// backupDateFormat is the format of the timestamp of the backups.
+const backupDateFormat = "20060102-150405"
+
+// filenameTemplateRegex matches the fields of a filename template.
+var filenameTemplateRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// FilenameTemplateFields are the fields which can be used in a filename
+// template, with their description.
+var FilenameTemplateFields = map[string]string{
+	"deck":   "full name of the deck (e.g. \"Burn - Sideboard\")",
+	"name":   "name of the decklist, without the board (e.g. \"Burn\")",
+	"board":  "board of the deck (e.g. \"Sideboard\", or \"" + plugins.MainBoard + "\")",
+	"plugin": "ID of the plugin used to parse the deck (e.g. \"mtg\")",
+	"site":   "website the deck comes from (e.g. \"mtggoldfish.com\"), or \"local\"",
+}
+
+// ValidateFilenameTemplate checks that a filename template only contains
+// known fields.
+func ValidateFilenameTemplate(template string) error {
+	if len(strings.TrimSpace(template)) == 0 {
+		return fmt.Errorf("empty filename template")
+	}
+
+	for _, match := range filenameTemplateRegex.FindAllStringSubmatch(template, -1) {
+		if _, found := FilenameTemplateFields[match[1]]; !found {
+			return fmt.Errorf("unknown field {%s} in filename template %s", match[1], template)
+		}
+	}
+
+	return nil
+}
+
+// deckFilename returns the path of the files of a deck (without extension),
+// relative to the output folder.
+// Each "/" of the template creates a subfolder.
+func deckFilename(deck *plugins.Deck, template string) string {
+	if len(template) == 0 {
+		return filepathReplacer.Replace(deck.Name)
+	}
+
+	name, board := plugins.SplitBoard(deck.Name)
+	fields := map[string]string{
+		"deck":   deck.Name,
+		"name":   name,
+		"board":  board,
+		"plugin": deck.Plugin,
+		"site":   deckSite(deck.Source),
+	}
+	if len(fields["plugin"]) == 0 {
+		fields["plugin"] = "unknown"
+	}
+
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		segment = filenameTemplateRegex.ReplaceAllStringFunc(segment, func(field string) string {
+			return filepathReplacer.Replace(fields[strings.Trim(field, "{}")])
+		})
+		segment = strings.TrimSpace(segment)
+		if len(segment) == 0 {
+			segment = "_"
+		}
+		segments[i] = segment
+	}
+
+	return filepath.Join(segments...)
+}
+
+// deckSite returns the host of the website a deck comes from, or "local"
+// for files.
+func deckSite(source string) string {
+	u, err := url.Parse(source)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Hostname()) == 0 {
+		return "local"
+	}
+
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// resolveConflict returns the name (without extension) under which the
+// files of a deck should be written to sink, according to policy, or an
+// empty string if the deck shouldn't be written.
+// Only the folders keep backups, the files of the other sinks are
+// overwritten.
+// The name is reserved until releaseName is called, so that it isn't chosen
+// again in the meantime.
+func resolveConflict(sink Sink, name string, policy ConflictPolicy) (string, error) {
+	reservedNames.Lock()
+	defer reservedNames.Unlock()
+
+	if !isTaken(sink, name) {
+		reserveName(sink, name)
+		return name, nil
+	}
+
+	switch policy {
+	case ConflictSkip:
+		log.Infof("%s.json already exists, skipping", name)
+		return "", nil
+	case ConflictRename:
+		for i := 2; ; i++ {
+			newName := name + " (" + strconv.Itoa(i) + ")"
+			if !isTaken(sink, newName) {
+				log.Infof("%s.json already exists, using %s.json", name, newName)
+				reserveName(sink, newName)
+				return newName, nil
+			}
+		}
+	case ConflictBackup:
+		reserveName(sink, name)
+		if folder, ok := sink.(*FolderSink); ok {
+			return name, backupSavedObject(folder.path(name))
+		}
+		return name, nil
+	default:
+		reserveName(sink, name)
+		return name, nil
+	}
+}
+
+// resolveDeckName returns the name (without extension) under which the
+// files of a deck should be written to sink, or an empty string if the deck
+// shouldn't be written. The name chosen when generating the templates of the
+// deck is reused, so that the deck refers to its own templates.
+func resolveDeckName(deck *plugins.Deck, sink Sink, options *generateOptions) (string, error) {
+	if deck.TemplateInfo == nil || len(deck.TemplateInfo.FileName) == 0 {
+		return resolveConflict(sink, deckFilename(deck, options.filenameTemplate), options.conflictPolicy)
+	}
+
+	// The name has already been reserved by resolveTemplateNames
+	name := deck.TemplateInfo.FileName
+	if options.conflictPolicy == ConflictBackup {
+		if folder, ok := sink.(*FolderSink); ok {
+			return name, backupSavedObject(folder.path(name))
+		}
+	}
+
+	return name, nil
+}
+
+// resolveTemplateNames chooses the names of the files of decks before their
+// templates are generated, since the templates are named after them. The
+// backups are made when the decks are saved. The names of the skipped decks
+// are empty.
+func resolveTemplateNames(decks []*plugins.Deck, sink Sink, options *generateOptions) []string {
+	policy := options.conflictPolicy
+	if policy == ConflictBackup {
+		policy = ConflictOverwrite
+	}
+
+	names := make([]string, 0, len(decks))
+	for _, deck := range decks {
+		// Only ConflictBackup returns an error
+		name, _ := resolveConflict(sink, deckFilename(deck, options.filenameTemplate), policy)
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// keepTemplateNames records the names chosen by resolveTemplateNames in the
+// decks whose templates have been generated, and releases the other names.
+func keepTemplateNames(decks []*plugins.Deck, names []string, sink Sink) {
+	for i, deck := range decks {
+		if len(names[i]) == 0 {
+			continue
+		}
+		if deck.TemplateInfo == nil {
+			releaseName(sink, names[i])
+			continue
+		}
+		deck.TemplateInfo.FileName = names[i]
+	}
+}
+
+// reservedNames are the names chosen by resolveConflict whose files haven't
+// been written yet, so that the decks converted concurrently don't pick the
+// same name.
+var reservedNames = struct {
+	sync.Mutex
+	names map[string]struct{}
+}{names: make(map[string]struct{})}
+
+// reservedNameKey returns the key of the file name of sink in reservedNames.
+// The folder sinks are identified by their path, since several sinks can
+// write to the same folder.
+func reservedNameKey(sink Sink, name string) string {
+	if folder, ok := sink.(*FolderSink); ok {
+		return folder.path(name)
+	}
+
+	return fmt.Sprintf("%p:%s", sink, name)
+}
+
+// isTaken returns true if the saved object name already exists in sink, or
+// has been reserved. reservedNames has to be locked.
+func isTaken(sink Sink, name string) bool {
+	if _, found := reservedNames.names[reservedNameKey(sink, name)]; found {
+		return true
+	}
+
+	return sink.Exists(name + ".json")
+}
+
+// reserveName reserves the saved object name in sink until releaseName is
+// called. reservedNames has to be locked.
+func reserveName(sink Sink, name string) {
+	reservedNames.names[reservedNameKey(sink, name)] = struct{}{}
+}
+
+// releaseName releases a name reserved by resolveConflict, once its file has
+// been written.
+func releaseName(sink Sink, name string) {
+	reservedNames.Lock()
+	defer reservedNames.Unlock()
+
+	delete(reservedNames.names, reservedNameKey(sink, name))
+}
+
+// backupSavedObject renames the JSON file and the thumbnail found at
+// basePath, by appending a timestamp and a .bak extension to them (so that
+// TTS doesn't show the backups in the chest).
+func backupSavedObject(basePath string) error {
+	suffix := "." + now().Format(backupDateFormat) + ".bak"
+
+	for _, ext := range []string{".json", ".png"} {
+		path := basePath + ext
+		if !fileExists(path) {
+			continue
+		}
+		log.Infof("Backing up %s to %s", path, path+suffix)
+		if err := os.Rename(path, path+suffix); err != nil {
+			return fmt.Errorf("couldn't back up %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package tts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+func TestDeckFilename(t *testing.T) {
+	deck := &plugins.Deck{
+		Name:   "Burn: RDW - Sideboard",
+		Plugin: "mtg",
+		Source: "https://www.mtggoldfish.com/deck/2062036#paper",
+	}
+
+	assert.Equal(t, "Burn- RDW - Sideboard", deckFilename(deck, ""))
+	assert.Equal(
+		t,
+		filepath.Join("mtg", "mtggoldfish.com", "Burn- RDW - Sideboard"),
+		deckFilename(deck, "{plugin}/{site}/{name} - {board}"),
+	)
+	assert.Equal(t, filepath.Join("local", "Burn- RDW - Sideboard"), deckFilename(&plugins.Deck{
+		Name:   "Burn: RDW - Sideboard",
+		Source: "decks/burn.txt",
+	}, "{site}/{deck}"))
+	assert.Equal(t, filepath.Join("unknown", "Burn - Main"), deckFilename(&plugins.Deck{
+		Name: "Burn",
+	}, "{plugin}/{name} - {board}"))
+	// Empty folders aren't created
+	assert.Equal(t, filepath.Join("_", "Burn"), deckFilename(&plugins.Deck{
+		Name: "Burn",
+	}, " /{name}"))
+}
+
+func TestValidateFilenameTemplate(t *testing.T) {
+	assert.Nil(t, ValidateFilenameTemplate("{plugin}/{site}/{name} - {board}"))
+	assert.Nil(t, ValidateFilenameTemplate("Decks/{deck}"))
+	assert.NotNil(t, ValidateFilenameTemplate("{plugin}/{format}"))
+	assert.NotNil(t, ValidateFilenameTemplate(""))
+}
+
+func TestConflictPolicies(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	now = func() time.Time {
+		return time.Date(2020, 5, 17, 12, 0, 0, 0, time.UTC)
+	}
+	defer func() {
+		now = time.Now
+	}()
+
+	deck := func(description string) *plugins.Deck {
+		return &plugins.Deck{
+			Name:    "Burn",
+			Plugin:  "mtg",
+			Cards:   []plugins.CardInfo{{Name: "Lightning Bolt", Description: description, Count: 1}},
+			BackURL: "https://example.com/back.png",
+		}
+	}
+	readDescription := func(path string) string {
+		object, err := ReadSavedObjectFile(path)
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		return object.ObjectStates[0].Description
+	}
+
+	path := filepath.Join(tmpDir, "mtg", "Burn.json")
+	template := WithFilenameTemplate("{plugin}/{deck}")
+
+	errs := Generate([]*plugins.Deck{deck("1")}, "", tmpDir, false, template)
+	assert.Len(t, errs, 0)
+	assert.Equal(t, "1", readDescription(path))
+
+	errs = Generate([]*plugins.Deck{deck("2")}, "", tmpDir, false, template, WithConflictPolicy(ConflictSkip))
+	assert.Len(t, errs, 0)
+	assert.Equal(t, "1", readDescription(path))
+
+	errs = Generate([]*plugins.Deck{deck("3"), deck("4")}, "", tmpDir, false, template, WithConflictPolicy(ConflictRename))
+	assert.Len(t, errs, 0)
+	assert.Equal(t, "1", readDescription(path))
+	assert.Equal(t, "3", readDescription(filepath.Join(tmpDir, "mtg", "Burn (2).json")))
+	assert.Equal(t, "4", readDescription(filepath.Join(tmpDir, "mtg", "Burn (3).json")))
+
+	errs = Generate([]*plugins.Deck{deck("5")}, "", tmpDir, false, template, WithConflictPolicy(ConflictBackup))
+	assert.Len(t, errs, 0)
+	assert.Equal(t, "5", readDescription(path))
+	assert.Equal(t, "1", readDescription(path+".20200517-120000.bak"))
+
+	errs = Generate([]*plugins.Deck{deck("6")}, "", tmpDir, false, template, WithConflictPolicy(ConflictOverwrite))
+	assert.Len(t, errs, 0)
+	assert.Equal(t, "6", readDescription(path))
+
+	// The backups aren't listed in the chest
+	entries, err := ListChest(tmpDir)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func TestConflictReservedNames(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Concurrent conversions to the same folder use their own sink, and
+	// choose their names before writing their files
+	first, err := resolveConflict(NewFolderSink(tmpDir), "Burn", ConflictRename)
+	assert.Nil(t, err)
+	assert.Equal(t, "Burn", first)
+
+	second, err := resolveConflict(NewFolderSink(tmpDir), "Burn", ConflictRename)
+	assert.Nil(t, err)
+	assert.Equal(t, "Burn (2)", second)
+
+	releaseName(NewFolderSink(tmpDir), first)
+	releaseName(NewFolderSink(tmpDir), second)
+
+	name, err := resolveConflict(NewFolderSink(tmpDir), "Burn", ConflictRename)
+	assert.Nil(t, err)
+	assert.Equal(t, "Burn", name)
+	releaseName(NewFolderSink(tmpDir), name)
+}
@@ -0,0 +1,100 @@
+package tts
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+	"strconv"
+)
+
+// guidLength is the number of hexadecimal digits in a TTS GUID.
+const guidLength = 6
+
+// guidGenerator generates stable GUIDs, derived from the identity of the
+// objects instead of being random, so that converting the same deck twice
+// results in the same GUIDs.
+type guidGenerator struct {
+	// seed is included in every GUID, so that objects from different decks
+	// get different GUIDs.
+	seed string
+	// occurrences counts the objects sharing the same identity (e.g. multiple
+	// copies of a card).
+	occurrences map[string]int
+	// used contains the GUIDs which have already been generated.
+	used map[string]bool
+}
+
+func newGUIDGenerator(seed string) *guidGenerator {
+	return &guidGenerator{
+		seed:        seed,
+		occurrences: make(map[string]int),
+		used:        make(map[string]bool),
+	}
+}
+
+// generate returns a GUID for the object with the given identity.
+// Multiple objects can share the same identity, each of them will get its own
+// GUID.
+func (g *guidGenerator) generate(identity string) string {
+	occurrence := g.occurrences[identity]
+	g.occurrences[identity]++
+
+	for salt := 0; ; salt++ {
+		hash := sha1.Sum([]byte(
+			g.seed + "\x00" + identity + "\x00" + strconv.Itoa(occurrence) + "\x00" + strconv.Itoa(salt),
+		))
+		guid := hex.EncodeToString(hash[:])[:guidLength]
+		if !g.used[guid] {
+			g.used[guid] = true
+			return guid
+		}
+	}
+}
+
+// assign sets the GUID of an object, its states and the objects it
+// contains.
+func (g *guidGenerator) assign(object *Object) {
+	object.GUID = g.generate(objectIdentity(object))
+
+	for i := range object.ContainedObjects {
+		g.assign(&object.ContainedObjects[i])
+	}
+
+	// Go through the states in order so that the GUIDs don't depend on the
+	// map iteration order
+	keys := make([]string, 0, len(object.States))
+	for key := range object.States {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		state := object.States[key]
+		g.assign(&state)
+		object.States[key] = state
+	}
+}
+
+// objectIdentity returns a string identifying an object, based on its type,
+// name and image.
+// The position of the object isn't part of its identity, so adding or removing
+// a card from a deck doesn't change the GUIDs of the other cards.
+func objectIdentity(object *Object) string {
+	identity := string(object.ObjectType) + "\x00" + object.Nickname
+
+	if object.CardID != 0 {
+		if customDeck, found := object.CustomDeck[strconv.Itoa(object.CardID/100)]; found {
+			identity += "\x00" + customDeck.FaceURL + "\x00" + strconv.Itoa(object.CardID%100)
+		}
+	}
+
+	return identity
+}
+
+// assignGUIDs sets stable GUIDs to all the objects of a saved object.
+func assignGUIDs(object *SavedObject, seed string) {
+	g := newGUIDGenerator(seed)
+
+	for i := range object.ObjectStates {
+		g.assign(&object.ObjectStates[i])
+	}
+}
@@ -0,0 +1,43 @@
+package tts
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var guidRegex = regexp.MustCompile(`^[0-9a-f]{6}$`)
+
+func TestGUIDGenerator(t *testing.T) {
+	g := newGUIDGenerator("Deck")
+
+	first := g.generate("Card")
+	second := g.generate("Card")
+	other := g.generate("Other Card")
+
+	assert.Regexp(t, guidRegex, first)
+	assert.Regexp(t, guidRegex, second)
+	assert.Regexp(t, guidRegex, other)
+	assert.NotEqual(t, first, second)
+	assert.NotEqual(t, first, other)
+
+	// The same sequence of identities gives the same GUIDs
+	g = newGUIDGenerator("Deck")
+	assert.Equal(t, first, g.generate("Card"))
+	assert.Equal(t, second, g.generate("Card"))
+	assert.Equal(t, other, g.generate("Other Card"))
+
+	// The seed changes the GUIDs
+	g = newGUIDGenerator("Other Deck")
+	assert.NotEqual(t, first, g.generate("Card"))
+}
+
+func TestGUIDGeneratorCollision(t *testing.T) {
+	g := newGUIDGenerator("Deck")
+	guid := g.generate("Card")
+
+	g = newGUIDGenerator("Deck")
+	g.used[guid] = true
+	assert.NotEqual(t, guid, g.generate("Card"))
+}
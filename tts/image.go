@@ -1,13 +1,17 @@
 package tts
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"io"
-	"net/http"
+	"math"
 
 	"github.com/disintegration/imaging"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
 
 	"github.com/jeandeaual/tts-deckconverter/log"
 )
@@ -17,50 +21,95 @@ const (
 	// We want 11 pixel margins on the top and bottom
 	topBottomMargin  = 11
 	innerImageHeight = 256 - topBottomMargin*2
+	// Height of each card when several cards are fanned out
+	fanCardHeight = 150
+	// Horizontal distance between the centers of two fanned out cards
+	fanOffset = 24
+	// Rotation between two fanned out cards, in degrees
+	fanAngle = 10
+	// Vertical drop of the cards on the edges of the fan, per card
+	fanDrop = 6
+	// Height of the deck name banner
+	bannerHeight = 20
+	// Horizontal padding of the deck name in the banner
+	bannerPadding = 4
 )
 
 var (
 	transparent color.Color = color.NRGBA{0, 0, 0, 0}
 	white       color.Color = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	bannerColor color.Color = color.NRGBA{0, 0, 0, 0xb0}
 )
 
-func downloadAndCreateThumbnail(url, filename string) (err error) {
-	log.Debugf("Querying %s", url)
+// loadImage decodes the image found at location (see parseImageSource).
+func loadImage(location string) (img image.Image, err error) {
+	source := parseImageSource(location)
 
-	// Build the request
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		err = fmt.Errorf("couldn't create request for %s: %w", url, err)
-		return
-	}
+	log.Debugf("Loading %s", source)
 
-	client := &http.Client{}
-
-	// Send the request
-	resp, err := client.Do(req)
+	reader, err := source.open()
 	if err != nil {
-		err = fmt.Errorf("couldn't query %s: %w", url, err)
 		return
 	}
 	defer func() {
-		if cerr := resp.Body.Close(); cerr != nil && err == nil {
+		if cerr := reader.Close(); cerr != nil && err == nil {
 			err = cerr
 		}
 	}()
 
-	err = generateThumbnail(resp.Body, filename)
+	img, err = imaging.Decode(reader)
+	if err != nil {
+		err = fmt.Errorf("failed to decode image %s: %w", source, err)
+	}
 
 	return
 }
 
-func generateThumbnail(source io.Reader, filename string) error {
-	// Open the source image
-	cardThumb, err := imaging.Decode(source)
+// createThumbnail generates the thumbnail of a deck from the images of its
+// representative cards, and writes it to w as PNG. If banner is set, it is
+// written at the bottom of the thumbnail.
+// The cards whose image cannot be loaded are left out, an error is only
+// returned if none can be loaded.
+func createThumbnail(sources []string, banner string, w io.Writer) error {
+	var err error
+	cards := make([]image.Image, 0, len(sources))
+
+	for _, source := range sources {
+		card, loadErr := loadImage(source)
+		if loadErr != nil {
+			log.Warnf("Leaving %s out of the thumbnail: %v", source, loadErr)
+			err = loadErr
+			continue
+		}
+		cards = append(cards, card)
+	}
+
+	if len(cards) == 0 {
+		if err == nil {
+			err = errors.New("no card image to generate the thumbnail from")
+		}
+		return err
+	}
+
+	// Save the resulting image as PNG
+	err = imaging.Encode(w, generateThumbnail(cards, banner), imaging.PNG)
 	if err != nil {
-		return fmt.Errorf("failed to open image: %w", err)
+		return fmt.Errorf("failed to save image: %w", err)
 	}
 
-	cardThumb = imaging.Resize(cardThumb, 0, innerImageHeight, imaging.Lanczos)
+	return nil
+}
+
+// generateThumbnail renders a single card, or fans out several cards, on a
+// square transparent background.
+func generateThumbnail(cards []image.Image, banner string) *image.NRGBA {
+	var cardThumb image.Image
+
+	if len(cards) == 1 {
+		cardThumb = imaging.Resize(cards[0], 0, innerImageHeight, imaging.Lanczos)
+	} else {
+		cardThumb = imaging.Fit(fanOut(cards), thumbnailSize, innerImageHeight, imaging.Lanczos)
+	}
 
 	background := imaging.New(thumbnailSize, thumbnailSize, transparent)
 	cardThumbSize := cardThumb.Bounds().Size()
@@ -73,11 +122,81 @@ func generateThumbnail(source io.Reader, filename string) error {
 		),
 	)
 
-	// Save the resulting image as PNG
-	err = imaging.Save(background, filename)
-	if err != nil {
-		return fmt.Errorf("failed to save image: %w", err)
+	if len(banner) > 0 {
+		drawBanner(background, banner)
 	}
 
-	return nil
+	return background
+}
+
+// fanOut spreads the cards like a hand of cards, the first card being on the
+// left and below the others.
+func fanOut(cards []image.Image) *image.NRGBA {
+	rotated := make([]*image.NRGBA, 0, len(cards))
+	middle := float64(len(cards)-1) / 2
+	maxWidth := 0
+	maxHeight := 0
+
+	for i, card := range cards {
+		resized := imaging.Resize(card, 0, fanCardHeight, imaging.Lanczos)
+		// Rotate the cards on the left counter-clockwise
+		angle := (middle - float64(i)) * fanAngle
+		rotatedCard := imaging.Rotate(resized, angle, transparent)
+		rotated = append(rotated, rotatedCard)
+
+		size := rotatedCard.Bounds().Size()
+		if size.X > maxWidth {
+			maxWidth = size.X
+		}
+		if size.Y > maxHeight {
+			maxHeight = size.Y
+		}
+	}
+
+	maxDrop := int(math.Ceil(middle * fanDrop))
+	fan := imaging.New((len(cards)-1)*fanOffset+maxWidth, maxHeight+maxDrop, transparent)
+
+	for i, card := range rotated {
+		size := card.Bounds().Size()
+		centerX := maxWidth/2 + i*fanOffset
+		centerY := maxHeight/2 + int(math.Abs(float64(i)-middle)*fanDrop)
+		fan = imaging.Overlay(fan, card, image.Pt(centerX-size.X/2, centerY-size.Y/2), 1.0)
+	}
+
+	return fan
+}
+
+// drawBanner writes text in a dark banner at the bottom of img.
+// The text is truncated if it doesn't fit.
+func drawBanner(img *image.NRGBA, text string) {
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+	top := height - bannerHeight
+
+	banner := imaging.New(width, bannerHeight, bannerColor)
+	*img = *imaging.Overlay(img, banner, image.Pt(0, top), 1.0)
+
+	face := basicfont.Face7x13
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(white),
+		Face: face,
+	}
+
+	maxWidth := fixed.I(width - bannerPadding*2)
+	if drawer.MeasureString(text) > maxWidth {
+		runes := []rune(text)
+		for len(runes) > 0 && drawer.MeasureString(string(runes)+"...") > maxWidth {
+			runes = runes[:len(runes)-1]
+		}
+		text = string(runes) + "..."
+	}
+
+	metrics := face.Metrics()
+	textHeight := metrics.Ascent + metrics.Descent
+	drawer.Dot = fixed.Point26_6{
+		X: (fixed.I(width) - drawer.MeasureString(text)) / 2,
+		Y: fixed.I(top) + (fixed.I(bannerHeight)-textHeight)/2 + metrics.Ascent,
+	}
+	drawer.DrawString(text)
 }
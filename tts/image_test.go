@@ -0,0 +1,74 @@
+package tts
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateThumbnail(t *testing.T) {
+	card := imaging.New(63, 88, white)
+
+	thumbnail := generateThumbnail([]image.Image{card}, "")
+	assert.Equal(t, image.Pt(thumbnailSize, thumbnailSize), thumbnail.Bounds().Size())
+	// The card is centered
+	assert.Equal(t, white, thumbnail.At(thumbnailSize/2, thumbnailSize/2))
+	assert.Equal(t, transparent, thumbnail.At(0, thumbnailSize/2))
+
+	cards := []image.Image{card, card, card, card, card}
+	thumbnail = generateThumbnail(cards, "")
+	assert.Equal(t, image.Pt(thumbnailSize, thumbnailSize), thumbnail.Bounds().Size())
+	assert.Equal(t, white, thumbnail.At(thumbnailSize/2, thumbnailSize/2))
+	// The fan is wider than a single card
+	assert.Equal(t, transparent, thumbnail.At(0, thumbnailSize/2))
+	assert.NotEqual(t, transparent, thumbnail.At(thumbnailSize/2-70, thumbnailSize/2))
+}
+
+func TestGenerateThumbnailBanner(t *testing.T) {
+	card := imaging.New(63, 88, white)
+
+	thumbnail := generateThumbnail([]image.Image{card}, "A very long deck name that doesn't fit in the banner")
+	assert.Equal(t, image.Pt(thumbnailSize, thumbnailSize), thumbnail.Bounds().Size())
+
+	// The banner is drawn at the bottom, over the transparent background
+	r, g, b, a := thumbnail.At(0, thumbnailSize-1).RGBA()
+	assert.Zero(t, r)
+	assert.Zero(t, g)
+	assert.Zero(t, b)
+	assert.NotZero(t, a)
+
+	// The top of the thumbnail is left untouched
+	assert.Equal(t, transparent, thumbnail.At(0, 0))
+}
+
+func TestCreateThumbnailMissingImages(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	card := filepath.Join(tmpDir, "card.png")
+	missing := filepath.Join(tmpDir, "missing.png")
+	createTestImage(t, card, 63, 88)
+
+	// The cards which cannot be loaded are left out
+	var buf bytes.Buffer
+	err = createThumbnail([]string{missing, card}, "", &buf)
+	assert.Nil(t, err)
+	thumbnail, err := imaging.Decode(&buf)
+	if assert.Nil(t, err) {
+		assert.Equal(t, image.Pt(thumbnailSize, thumbnailSize), thumbnail.Bounds().Size())
+	}
+
+	buf.Reset()
+	err = createThumbnail([]string{missing}, "", &buf)
+	assert.NotNil(t, err)
+	assert.Zero(t, buf.Len())
+}
@@ -0,0 +1,159 @@
+package tts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+)
+
+// modImageExtensions are the extensions used by TTS for the cached images.
+var modImageExtensions = []string{".png", ".jpg"}
+
+// ModCache writes images to the TTS mod cache (the Mods/Images folder), so
+// that TTS doesn't need to download them when loading a deck.
+type ModCache struct {
+	folder string
+}
+
+// NewModCache returns a cache writing images in folder.
+func NewModCache(folder string) *ModCache {
+	return &ModCache{folder: folder}
+}
+
+// FindModImagesPath returns the folder where TTS caches the mod images,
+// inside the TTS data folder (see FindDataDir).
+func FindModImagesPath() (string, error) {
+	dataDir, err := FindDataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDir, "Mods", "Images"), nil
+}
+
+// modCacheName returns the name under which TTS caches the image found at
+// url (without extension): the URL without its non alphanumeric characters.
+func modCacheName(url string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, url)
+}
+
+// imageExtension returns the extension used by TTS for the image data.
+func imageExtension(data []byte) (string, error) {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	if format == "jpeg" {
+		return ".jpg", nil
+	}
+
+	return ".png", nil
+}
+
+// lookup returns the path of the cached image of url, if it exists.
+func (c *ModCache) lookup(url string) (string, bool) {
+	name := modCacheName(url)
+
+	for _, ext := range modImageExtensions {
+		path := filepath.Join(c.folder, name+ext)
+		if fileExists(path) {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+// add writes the image data retrieved from url to the cache, and returns
+// its path.
+func (c *ModCache) add(url string, data []byte) (string, error) {
+	ext, err := imageExtension(data)
+	if err != nil {
+		return "", fmt.Errorf("invalid image %s: %w", url, err)
+	}
+
+	if err := os.MkdirAll(c.folder, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(c.folder, modCacheName(url)+ext)
+	log.Debugf("Caching %s to %s", url, path)
+
+	return path, ioutil.WriteFile(path, data, 0o644)
+}
+
+// addFile writes a local copy of the image found at url (e.g. a template
+// which has been uploaded) to the cache.
+func (c *ModCache) addFile(url, path string) error {
+	if _, found := c.lookup(url); found {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.add(url, data)
+
+	return err
+}
+
+// fetch downloads the image found at location to the cache, unless it is
+// already there. Only remote images are cached, TTS reads local files
+// directly. The path of the cached image is returned.
+func (c *ModCache) fetch(location string) (string, error) {
+	source := parseImageSource(location)
+	if source.sourceType != sourceHTTP {
+		return "", nil
+	}
+
+	if path, found := c.lookup(location); found {
+		return path, nil
+	}
+
+	data, err := readImage(source, "")
+	if err != nil {
+		return "", err
+	}
+
+	return c.add(location, data)
+}
+
+// cacheImages writes all the images of a saved object to the cache.
+// The images which are already cached (e.g. downloaded or uploaded while
+// generating the templates) are reused.
+// It returns a map of the cached URLs to the path of their local copy.
+func (c *ModCache) cacheImages(object SavedObject) map[string]string {
+	links := make(map[string]*link)
+	for _, objectState := range object.ObjectStates {
+		collectLinks(objectState, "", links)
+	}
+
+	cached := make(map[string]string, len(links))
+	for location := range links {
+		path, err := c.fetch(location)
+		if err != nil {
+			log.Warnf("Couldn't cache %s: %v", location, err)
+			continue
+		}
+		if len(path) > 0 {
+			cached[location] = path
+		}
+	}
+
+	return cached
+}
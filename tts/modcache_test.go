@@ -0,0 +1,143 @@
+package tts
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts/upload"
+)
+
+func TestModCacheName(t *testing.T) {
+	assert.Equal(
+		t,
+		"httpcloud3steamusercontentcomugc1016065307516013346F5A9A86BF1D3BB5A4D4C2A9E8F27ED42A64D2A8E",
+		modCacheName("http://cloud-3.steamusercontent.com/ugc/1016065307516013346/F5A9A86BF1D3BB5A4D4C2A9E8F27ED42A64D2A8E/"),
+	)
+	assert.Equal(
+		t,
+		"httpsc1scryfallcomfilecardsnormalfront6d6dab0b1jpg1562895476",
+		modCacheName("https://c1.scryfall.com/file/cards/normal/front/6/d/6dab0b1é.jpg?1562895476"),
+	)
+}
+
+func TestModCache(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	imageDir := filepath.Join(tmpDir, "images")
+	cacheDir := filepath.Join(tmpDir, "Mods", "Images")
+	outputDir := filepath.Join(tmpDir, "output")
+	for _, dir := range []string{imageDir, outputDir} {
+		if !assert.Nil(t, os.Mkdir(dir, 0755)) {
+			t.FailNow()
+		}
+	}
+
+	createTestImage(t, filepath.Join(imageDir, "card.png"), 10, 14)
+	err = imaging.Save(imaging.New(10, 14, white), filepath.Join(imageDir, "back.jpg"))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	requests := 0
+	files := http.FileServer(http.Dir(imageDir))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		files.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	deck := &plugins.Deck{
+		Name: "Test",
+		Cards: []plugins.CardInfo{
+			{Name: "Card 1", ImageURL: server.URL + "/card.png", Count: 2},
+			{Name: "Card 2", ImageURL: filepath.Join(imageDir, "card.png"), Count: 1},
+		},
+		BackURL: server.URL + "/back.jpg",
+	}
+
+	cache := NewModCache(cacheDir)
+	assert.Nil(t, create(deck, NewFolderSink(outputDir), false, &generateOptions{modCache: cache}))
+
+	cardPath := filepath.Join(cacheDir, modCacheName(server.URL+"/card.png")+".png")
+	backPath := filepath.Join(cacheDir, modCacheName(server.URL+"/back.jpg")+".jpg")
+	assert.FileExists(t, cardPath)
+	assert.FileExists(t, backPath)
+	// Local images are not cached
+	entries, err := ioutil.ReadDir(cacheDir)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+	// The thumbnail uses the cached image
+	assert.Equal(t, 2, requests)
+	assert.FileExists(t, filepath.Join(outputDir, "Test.png"))
+
+	// Cached images are not downloaded again
+	path, err := cache.fetch(server.URL + "/card.png")
+	assert.Nil(t, err)
+	assert.Equal(t, cardPath, path)
+	assert.Equal(t, 2, requests)
+
+	_, err = cache.fetch(server.URL + "/missing.png")
+	assert.NotNil(t, err)
+
+	templateURL := "https://example.com/template"
+	assert.Nil(t, cache.addFile(templateURL, filepath.Join(imageDir, "back.jpg")))
+	assert.FileExists(t, filepath.Join(cacheDir, "httpsexamplecomtemplate.jpg"))
+}
+
+func TestModCacheTemplate(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	imageDir := filepath.Join(tmpDir, "images")
+	cacheDir := filepath.Join(tmpDir, "Mods", "Images")
+	outputDir := filepath.Join(tmpDir, "output")
+	for _, dir := range []string{imageDir, outputDir} {
+		if !assert.Nil(t, os.Mkdir(dir, 0755)) {
+			t.FailNow()
+		}
+	}
+
+	createTestImage(t, filepath.Join(imageDir, "card.png"), 10, 14)
+	createTestImage(t, filepath.Join(imageDir, "back.png"), 10, 14)
+
+	requests := map[string]int{}
+	files := http.FileServer(http.Dir(imageDir))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		files.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	deck := &plugins.Deck{
+		Name: "Test",
+		Cards: []plugins.CardInfo{
+			{Name: "Card 1", ImageURL: server.URL + "/card.png", BackImageURL: server.URL + "/back.png", Count: 2},
+		},
+		BackURL: server.URL + "/back.png",
+	}
+
+	cache := NewModCache(cacheDir)
+	errs := GenerateTemplates([][]*plugins.Deck{{deck}}, outputDir, upload.ManualUploader{}, WithModCache(cache))
+	assert.Empty(t, errs)
+	assert.Nil(t, create(deck, NewFolderSink(outputDir), false, &generateOptions{modCache: cache}))
+
+	// The images downloaded for the template are reused for the cache and
+	// the thumbnail
+	assert.Equal(t, map[string]int{"/card.png": 1, "/back.png": 1}, requests)
+	assert.FileExists(t, filepath.Join(outputDir, "Test.png"))
+}
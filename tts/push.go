@@ -0,0 +1,175 @@
+package tts
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+// DefaultEditorAddress is the address on which TTS listens for the messages
+// of the External Editor API.
+// See https://api.tabletopsimulator.com/externaleditorapi/
+const DefaultEditorAddress = "localhost:39999"
+
+// executeLuaMessageID is the ID of the External Editor API message used to
+// execute Lua code in the game.
+const executeLuaMessageID = 3
+
+// editorTimeout is the timeout used when sending a message to TTS.
+const editorTimeout = 5 * time.Second
+
+// spawnSpacing is the distance between the objects spawned side by side.
+const spawnSpacing = 3
+
+// editorMessage is a message sent to the External Editor API.
+type editorMessage struct {
+	MessageID int `json:"messageID"`
+	// GUID of the object executing the script ("-1" for Global).
+	GUID   string `json:"guid"`
+	Script string `json:"script"`
+}
+
+// Push spawns the decks in the running TTS game, next to the pointer of
+// the host, instead of writing them to files.
+// address is the address of the External Editor API (usually
+// DefaultEditorAddress).
+func Push(decks []*plugins.Deck, backURL, address string, options ...GenerateOption) error {
+	// Default options
+	opts := &generateOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	var objects []Object
+
+	for _, deck := range decks {
+		if len(backURL) > 0 {
+			deck.BackURL = backURL
+		}
+		if len(deck.Cards) == 0 {
+			log.Infof("Deck %s is empty, skipping", deck.Name)
+			continue
+		}
+
+		object, _ := buildSavedObject(deck, opts)
+		// The name of a saved object comes from its file, so name the spawned
+		// decks explicitly
+		for i := range object.ObjectStates {
+			if len(object.ObjectStates[i].Nickname) == 0 {
+				object.ObjectStates[i].Nickname = deck.Name
+			}
+		}
+		if opts.modCache != nil {
+			opts.modCache.cacheImages(object)
+		}
+		objects = append(objects, object.ObjectStates...)
+	}
+
+	return pushObjects(objects, address)
+}
+
+// PushSavedObject spawns the objects of a saved object in the running TTS
+// game, next to the pointer of the host.
+func PushSavedObject(object *SavedObject, address string) error {
+	return pushObjects(object.ObjectStates, address)
+}
+
+func pushObjects(objects []Object, address string) error {
+	if len(objects) == 0 {
+		return fmt.Errorf("no object to push")
+	}
+
+	script, err := spawnScript(objects)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Pushing %d objects to %s", len(objects), address)
+
+	return sendEditorMessage(address, editorMessage{
+		MessageID: executeLuaMessageID,
+		GUID:      "-1",
+		Script:    script,
+	})
+}
+
+// spawnScript returns the Lua code spawning the objects side by side, above
+// the pointer of the host (or at the center of the table if it can't be
+// found).
+func spawnScript(objects []Object) (string, error) {
+	var script strings.Builder
+
+	script.WriteString(`local position = {x = 0, y = 2, z = 0}
+for _, player in ipairs(Player.getPlayers()) do
+  if player.host then
+    local pointer = player.getPointerPosition()
+    if pointer then
+      position = {x = pointer.x, y = pointer.y + 2, z = pointer.z}
+    end
+    break
+  end
+end
+local objects = {
+`)
+
+	for _, object := range objects {
+		data, err := json.Marshal(object)
+		if err != nil {
+			return "", fmt.Errorf("couldn't marshall object %s: %w", object.Nickname, err)
+		}
+		// Use a long bracket string, so that the JSON doesn't need to be
+		// escaped
+		equals := strings.Repeat("=", longBracketLevel(string(data)))
+		script.WriteString("  [" + equals + "[" + string(data) + "]" + equals + "],\n")
+	}
+
+	fmt.Fprintf(&script, `}
+for i, json in ipairs(objects) do
+  spawnObjectJSON({
+    json = json,
+    position = {position.x + (i - 1) * %d, position.y, position.z},
+  })
+end
+`, spawnSpacing)
+
+	return script.String(), nil
+}
+
+// longBracketLevel returns the smallest level of Lua long bracket which can
+// enclose s (the number of "=" between the brackets).
+func longBracketLevel(s string) int {
+	level := 0
+	for strings.Contains(s, "]"+strings.Repeat("=", level)+"]") {
+		level++
+	}
+	return level
+}
+
+// sendEditorMessage sends a message to the External Editor API of TTS.
+// TTS reads the message once the connection is closed.
+func sendEditorMessage(address string, message editorMessage) (err error) {
+	conn, err := net.DialTimeout("tcp", address, editorTimeout)
+	if err != nil {
+		return fmt.Errorf("couldn't connect to Tabletop Simulator (is a game running?): %w", err)
+	}
+	defer func() {
+		if cerr := conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	if err = conn.SetDeadline(time.Now().Add(editorTimeout)); err != nil {
+		return err
+	}
+
+	if err = json.NewEncoder(conn).Encode(message); err != nil {
+		return fmt.Errorf("couldn't send the message to Tabletop Simulator: %w", err)
+	}
+
+	return nil
+}
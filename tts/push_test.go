@@ -0,0 +1,114 @@
+package tts
+
+import (
+	"encoding/json"
+	"net"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+// listenEditor starts a stand-in for the External Editor API of TTS, and
+// sends the messages it receives to the returned channel.
+func listenEditor(t *testing.T) (string, <-chan editorMessage) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	messages := make(chan editorMessage, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var message editorMessage
+		if err := json.NewDecoder(conn).Decode(&message); err == nil {
+			messages <- message
+		}
+		close(messages)
+	}()
+
+	return listener.Addr().String(), messages
+}
+
+func TestPush(t *testing.T) {
+	address, messages := listenEditor(t)
+
+	decks := []*plugins.Deck{
+		{
+			Name: "Burn",
+			Cards: []plugins.CardInfo{
+				{Name: "Lightning Bolt", ImageURL: "https://example.com/1.png", Count: 4},
+				{Name: "Goblin Guide", Description: "]]", ImageURL: "https://example.com/2.png", Count: 4},
+			},
+		},
+		{
+			Name: "Burn - Sideboard",
+			Cards: []plugins.CardInfo{
+				{Name: "Smash to Smithereens", ImageURL: "https://example.com/3.png", Count: 1},
+			},
+		},
+		{
+			Name: "Empty",
+		},
+	}
+
+	err := Push(decks, "https://example.com/back.png", address)
+	assert.Nil(t, err)
+
+	message, ok := <-messages
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	assert.Equal(t, executeLuaMessageID, message.MessageID)
+	assert.Equal(t, "-1", message.GUID)
+	assert.Contains(t, message.Script, "spawnObjectJSON")
+
+	// Each object is sent as a JSON string
+	objects := regexp.MustCompile(`(?s)\[(=*)\[(\{.*?\})\](=*)\],`).FindAllStringSubmatch(message.Script, -1)
+	if !assert.Len(t, objects, 2) {
+		t.FailNow()
+	}
+	nicknames := make([]string, 0, len(objects))
+	for _, match := range objects {
+		assert.Equal(t, match[1], match[3])
+		var object Object
+		assert.Nil(t, json.Unmarshal([]byte(match[2]), &object))
+		nicknames = append(nicknames, object.Nickname)
+		for _, customDeck := range object.CustomDeck {
+			assert.Equal(t, "https://example.com/back.png", customDeck.BackURL)
+		}
+	}
+	// Single cards keep their name
+	assert.Equal(t, []string{"Burn", "Smash to Smithereens"}, nicknames)
+}
+
+func TestPushNoGame(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	err = PushSavedObject(&SavedObject{ObjectStates: []Object{{Nickname: "Test"}}}, address)
+	assert.NotNil(t, err)
+
+	err = PushSavedObject(&SavedObject{}, address)
+	assert.NotNil(t, err)
+}
+
+func TestLongBracketLevel(t *testing.T) {
+	assert.Equal(t, 0, longBracketLevel(`{"Nickname":"Test"}`))
+	assert.Equal(t, 1, longBracketLevel(`{"DeckIDs":[[1]]}`))
+	assert.Equal(t, 2, longBracketLevel(`{"Description":"]]]=]"}`))
+}
@@ -0,0 +1,309 @@
+package tts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+// scaleTolerance is the tolerance used when comparing object scales to find
+// the size of the cards.
+const scaleTolerance = 0.01
+
+// ReadSavedObject decodes a TTS saved object (or save file).
+func ReadSavedObject(r io.Reader) (*SavedObject, error) {
+	var object SavedObject
+
+	err := json.NewDecoder(r).Decode(&object)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decode saved object: %w", err)
+	}
+
+	return &object, nil
+}
+
+// ReadSavedObjectFile reads the TTS saved object (or save file) found at
+// path.
+func ReadSavedObjectFile(path string) (object *SavedObject, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	object, err = ReadSavedObject(file)
+	if err != nil {
+		err = fmt.Errorf("couldn't read %s: %w", path, err)
+	}
+
+	return
+}
+
+// ExtractDecks rebuilds the decks contained in a saved object.
+// Each deck object becomes a deck, and each card lying outside of a deck
+// becomes a deck containing a single card. Objects containing other objects
+// (e.g. bags) are searched as well. Decks without a nickname are named after
+// name (e.g. the name of the saved object file).
+// The cards are named after their nickname, their count is based on the deck
+// IDs and their images are taken from the custom decks. Cards coming from a
+// template sheet have a TemplateInfo set, their ImageURL being the URL of the
+// sheet followed by "#" and the position of the card in the sheet.
+func ExtractDecks(object *SavedObject, name string) []*plugins.Deck {
+	var decks []*plugins.Deck
+
+	if len(object.SaveName) > 0 {
+		name = object.SaveName
+	}
+
+	for _, objectState := range object.ObjectStates {
+		decks = append(decks, extractObjectDecks(objectState, name)...)
+	}
+
+	return decks
+}
+
+// SavedObjectName returns the name of a saved object file, based on its
+// path.
+func SavedObjectName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+func extractObjectDecks(object Object, defaultName string) []*plugins.Deck {
+	switch object.ObjectType {
+	case DeckObject, DeckCustomObject:
+		name := object.Nickname
+		if len(name) == 0 {
+			name = defaultName
+		}
+		return []*plugins.Deck{extractDeck(object, name)}
+	case CardObject, CardCustomObject:
+		// The nickname of a card is the name of the card, not of the deck
+		name := defaultName
+		if len(name) == 0 {
+			name = plugins.BaseCardName(object.Nickname)
+		}
+		deck := newExtractedDeck(name, object.CustomDeck)
+		deck.Cards = append(deck.Cards, extractCard(object, deck))
+		deck.CardSize, _ = cardSizeFromScale(object.Transform)
+		return []*plugins.Deck{deck}
+	default:
+		var decks []*plugins.Deck
+		for _, contained := range object.ContainedObjects {
+			decks = append(decks, extractObjectDecks(contained, "")...)
+		}
+		return decks
+	}
+}
+
+func newExtractedDeck(name string, customDecks CustomDeckMap) *plugins.Deck {
+	deck := &plugins.Deck{
+		Name: name,
+	}
+
+	// Use the back and shape of the first custom deck for the whole deck
+	keys := make([]int, 0, len(customDecks))
+	for key := range customDecks {
+		if intKey, err := strconv.Atoi(key); err == nil {
+			keys = append(keys, intKey)
+		}
+	}
+	sort.Ints(keys)
+
+	if len(keys) > 0 {
+		customDeck := customDecks[strconv.Itoa(keys[0])]
+		if !customDeck.UniqueBack {
+			deck.BackURL = customDeck.BackURL
+		}
+		deck.Shape, deck.Rounded = cardShape(customDeck.Type)
+	}
+
+	return deck
+}
+
+func extractDeck(object Object, name string) *plugins.Deck {
+	// Merge the custom decks of the deck with the ones of its cards
+	customDecks := make(CustomDeckMap)
+	for _, card := range object.ContainedObjects {
+		for key, customDeck := range card.CustomDeck {
+			customDecks[key] = customDeck
+		}
+	}
+	for key, customDeck := range object.CustomDeck {
+		customDecks[key] = customDeck
+	}
+
+	deck := newExtractedDeck(name, customDecks)
+	deck.CardSize, _ = cardSizeFromScale(object.Transform)
+
+	// Cards indexed by card ID, in case the deck IDs and the contained
+	// objects are not in the same order
+	cardsByID := make(map[int]Object, len(object.ContainedObjects))
+	for _, card := range object.ContainedObjects {
+		if _, found := cardsByID[card.CardID]; !found {
+			cardsByID[card.CardID] = card
+		}
+	}
+
+	deckIDs := object.DeckIDs
+	if len(deckIDs) == 0 {
+		for _, card := range object.ContainedObjects {
+			deckIDs = append(deckIDs, card.CardID)
+		}
+	}
+
+	cardIndexes := make(map[string]int)
+
+	for i, cardID := range deckIDs {
+		var card Object
+		if i < len(object.ContainedObjects) && object.ContainedObjects[i].CardID == cardID {
+			card = object.ContainedObjects[i]
+		} else if found, ok := cardsByID[cardID]; ok {
+			card = found
+		} else {
+			card = Object{CardID: cardID}
+		}
+		if card.CustomDeck == nil {
+			card.CustomDeck = customDecks
+		}
+
+		cardInfo := extractCard(card, deck)
+
+		// Merge the copies of the same card
+		key := cardInfo.Name + "\x00" + cardInfo.ImageURL + "\x00" + cardInfo.BackImageURL
+		if idx, found := cardIndexes[key]; found {
+			deck.Cards[idx].Count++
+			continue
+		}
+		cardIndexes[key] = len(deck.Cards)
+		deck.Cards = append(deck.Cards, cardInfo)
+	}
+
+	return deck
+}
+
+func extractCard(object Object, deck *plugins.Deck) plugins.CardInfo {
+	card := plugins.CardInfo{
+		Name:        object.Nickname,
+		Description: object.Description,
+		Count:       1,
+		Sideways:    object.SidewaysCard,
+		Metadata:    decodeMetadata(object.GMNotes),
+	}
+
+	customDeckID := object.CardID / 100
+	cardIndex := object.CardID % 100
+	customDeck, found := object.CustomDeck[strconv.Itoa(customDeckID)]
+	if found {
+		if customDeck.NumWidth*customDeck.NumHeight > 1 {
+			// The card is part of a template sheet
+			card.ImageURL = customDeck.FaceURL + "#" + strconv.Itoa(cardIndex)
+
+			if deck.TemplateInfo == nil {
+				deck.TemplateInfo = &plugins.TemplateInfo{
+					ImageURLCardIDMap: make(map[string]int),
+					Templates:         make(map[int]*plugins.Template),
+				}
+			}
+			deck.TemplateInfo.ImageURLCardIDMap[plugins.TemplateImageKey(card.ImageURL, card.BackImageURL)] = object.CardID
+			if _, found := deck.TemplateInfo.Templates[customDeckID]; !found {
+				template := &plugins.Template{
+					URL:     customDeck.FaceURL,
+					NumCols: customDeck.NumWidth,
+					NumRows: customDeck.NumHeight,
+				}
+				if customDeck.UniqueBack {
+					template.BackURL = customDeck.BackURL
+				}
+				deck.TemplateInfo.Templates[customDeckID] = template
+			}
+		} else {
+			card.ImageURL = customDeck.FaceURL
+			if customDeck.UniqueBack || (len(deck.BackURL) > 0 && customDeck.BackURL != deck.BackURL) {
+				card.BackImageURL = customDeck.BackURL
+			}
+		}
+	}
+
+	if _, oversized := cardSizeFromScale(object.Transform); oversized {
+		card.Oversized = true
+	}
+
+	// Only the second state is kept, the plugins only generate two states
+	if state, found := object.States["2"]; found {
+		alternativeState := extractCard(state, deck)
+		card.AlternativeState = &alternativeState
+	}
+
+	return card
+}
+
+// decodeMetadata parses the card metadata saved in the GM notes of a card.
+// GM notes which weren't written by encodeMetadata (e.g. notes added by
+// the user) are ignored.
+func decodeMetadata(gmNotes string) map[string]string {
+	if !strings.HasPrefix(gmNotes, "{") {
+		return nil
+	}
+
+	var metadata map[string]string
+	if err := json.Unmarshal([]byte(gmNotes), &metadata); err != nil || len(metadata) == 0 {
+		return nil
+	}
+
+	return metadata
+}
+
+// cardShape returns the card shape corresponding to a TTS deck shape.
+func cardShape(shape DeckShape) (plugins.CardShape, bool) {
+	switch shape {
+	case DeckShapeRectangle:
+		return plugins.CardShapeRectangle, false
+	case DeckShapeHexRounded:
+		return plugins.CardShapeHex, true
+	case DeckShapeHex:
+		return plugins.CardShapeHex, false
+	case DeckShapeCircle:
+		return plugins.CardShapeCircle, false
+	default:
+		return plugins.CardShapeRectangle, true
+	}
+}
+
+// cardSizeFromScale finds the size of a card from the scale of its object.
+// This is the reverse of cardScale. If the scale doesn't match any card
+// size, the standard size is returned.
+func cardSizeFromScale(transform Transform) (cardSize plugins.CardSize, oversized bool) {
+	for _, size := range []plugins.CardSize{
+		plugins.CardSizeStandard,
+		plugins.CardSizeSmall,
+		plugins.CardSizeTarot,
+		plugins.CardSizeMini,
+		plugins.CardSizeSquare,
+		plugins.CardSizeOversized,
+	} {
+		for _, over := range []bool{false, true} {
+			if over && size != plugins.CardSizeStandard {
+				continue
+			}
+			scaleX, _, scaleZ := cardScale(size, over)
+			if math.Abs(scaleX-transform.ScaleX) < scaleTolerance &&
+				math.Abs(scaleZ-transform.ScaleZ) < scaleTolerance {
+				return size, over
+			}
+		}
+	}
+
+	return plugins.CardSizeStandard, false
+}
@@ -0,0 +1,192 @@
+package tts
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+func TestExtractDecks(t *testing.T) {
+	deck := &plugins.Deck{
+		Name: "Test",
+		Cards: []plugins.CardInfo{
+			{
+				Name:     "Card 1\n[b]Creature[/b]",
+				ImageURL: "https://example.com/1.png",
+				Count:    2,
+			},
+			{
+				Name:         "Card 2",
+				Description:  "Description",
+				ImageURL:     "https://example.com/2.png",
+				BackImageURL: "https://example.com/2-back.png",
+				Count:        1,
+				Sideways:     true,
+			},
+			{
+				// Same card with another back
+				Name:         "Card 2",
+				Description:  "Description",
+				ImageURL:     "https://example.com/2.png",
+				BackImageURL: "https://example.com/2-back-alt.png",
+				Count:        1,
+				Sideways:     true,
+			},
+			{
+				Name:     "Card 3",
+				ImageURL: "https://example.com/3.png",
+				Count:    1,
+				AlternativeState: &plugins.CardInfo{
+					Name:     "Card 3 Back",
+					ImageURL: "https://example.com/3-back.png",
+					Count:    1,
+				},
+			},
+		},
+		BackURL:  "https://example.com/back.png",
+		CardSize: plugins.CardSizeSmall,
+		Shape:    plugins.CardShapeHex,
+	}
+
+	object, _ := createDeck(deck)
+	data, err := json.Marshal(object)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	read, err := ReadSavedObject(bytes.NewReader(data))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	decks := ExtractDecks(read, "File Name")
+	if !assert.Len(t, decks, 1) {
+		t.FailNow()
+	}
+
+	extracted := decks[0]
+	assert.Equal(t, "File Name", extracted.Name)
+	assert.Equal(t, deck.BackURL, extracted.BackURL)
+	assert.Equal(t, plugins.CardSizeSmall, extracted.CardSize)
+	assert.Equal(t, plugins.CardShapeHex, extracted.Shape)
+	assert.False(t, extracted.Rounded)
+	assert.Nil(t, extracted.TemplateInfo)
+	assert.Equal(t, deck.Cards, extracted.Cards)
+}
+
+func TestExtractDecksTemplate(t *testing.T) {
+	deck := &plugins.Deck{
+		Name: "Test",
+		Cards: []plugins.CardInfo{
+			{Name: "Card 1", ImageURL: "1", Count: 3},
+			{Name: "Card 2", ImageURL: "2", Count: 1},
+		},
+		BackURL: "https://example.com/back.png",
+		TemplateInfo: &plugins.TemplateInfo{
+			ImageURLCardIDMap: map[string]int{"1": 100, "2": 101},
+			Templates: map[int]*plugins.Template{
+				1: {URL: "https://example.com/sheet.png", NumCols: 2, NumRows: 1},
+			},
+		},
+	}
+
+	object, _ := createDeck(deck)
+	decks := ExtractDecks(&object, "Test")
+	if !assert.Len(t, decks, 1) {
+		t.FailNow()
+	}
+
+	extracted := decks[0]
+	if assert.Len(t, extracted.Cards, 2) {
+		assert.Equal(t, "https://example.com/sheet.png#0", extracted.Cards[0].ImageURL)
+		assert.Equal(t, 3, extracted.Cards[0].Count)
+		assert.Equal(t, "https://example.com/sheet.png#1", extracted.Cards[1].ImageURL)
+		assert.Equal(t, 1, extracted.Cards[1].Count)
+	}
+	if assert.NotNil(t, extracted.TemplateInfo) {
+		assert.Equal(t, map[string]int{
+			"https://example.com/sheet.png#0": 100,
+			"https://example.com/sheet.png#1": 101,
+		}, extracted.TemplateInfo.ImageURLCardIDMap)
+		assert.Equal(t, deck.TemplateInfo.Templates, extracted.TemplateInfo.Templates)
+	}
+}
+
+func TestExtractDecksBag(t *testing.T) {
+	// Hand-made bag containing a deck with a nickname and a single card
+	read, err := ReadSavedObject(strings.NewReader(`{
+  "SaveName": "",
+  "ObjectStates": [
+    {
+      "Name": "Bag",
+      "ContainedObjects": [
+        {
+          "Name": "DeckCustom",
+          "Nickname": "Deck",
+          "DeckIDs": [200, 200, 100],
+          "CustomDeck": {
+            "1": {"FaceURL": "a.png", "BackURL": "back.png", "NumWidth": 1, "NumHeight": 1},
+            "2": {"FaceURL": "b.png", "BackURL": "back.png", "NumWidth": 1, "NumHeight": 1}
+          },
+          "ContainedObjects": [
+            {"Name": "Card", "Nickname": "B", "CardID": 200},
+            {"Name": "Card", "Nickname": "B", "CardID": 200},
+            {"Name": "Card", "Nickname": "A", "CardID": 100}
+          ]
+        },
+        {
+          "Name": "Card",
+          "Nickname": "Single\nDetails",
+          "CardID": 300,
+          "CustomDeck": {
+            "3": {"FaceURL": "c.png", "BackURL": "back.png", "NumWidth": 1, "NumHeight": 1}
+          }
+        }
+      ]
+    }
+  ]
+}`))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	decks := ExtractDecks(read, "")
+	if !assert.Len(t, decks, 2) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, "Deck", decks[0].Name)
+	assert.Equal(t, "back.png", decks[0].BackURL)
+	assert.Equal(t, []plugins.CardInfo{
+		{Name: "B", ImageURL: "b.png", Count: 2},
+		{Name: "A", ImageURL: "a.png", Count: 1},
+	}, decks[0].Cards)
+
+	assert.Equal(t, "Single", decks[1].Name)
+	assert.Equal(t, []plugins.CardInfo{
+		{Name: "Single\nDetails", ImageURL: "c.png", Count: 1},
+	}, decks[1].Cards)
+}
+
+func TestReadSavedObjectInvalid(t *testing.T) {
+	_, err := ReadSavedObject(strings.NewReader("{"))
+	assert.NotNil(t, err)
+}
+
+func TestMetadata(t *testing.T) {
+	metadata := map[string]string{"set": "ELD", "number": "59"}
+
+	gmNotes := encodeMetadata(metadata)
+	assert.Equal(t, `{"number":"59","set":"ELD"}`, gmNotes)
+	assert.Equal(t, metadata, decodeMetadata(gmNotes))
+
+	assert.Empty(t, encodeMetadata(nil))
+	assert.Nil(t, decodeMetadata(""))
+	assert.Nil(t, decodeMetadata("Some notes"))
+	assert.Nil(t, decodeMetadata("{not JSON"))
+}
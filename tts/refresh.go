@@ -0,0 +1,109 @@
+package tts
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+// Refresh regenerates the saved object found at path from deck (e.g. after
+// retrieving its cards again with different options), overwriting its JSON
+// file and thumbnail.
+// The position, rotation, scripts and GUIDs of the existing object are kept,
+// as well as the GUIDs and scripts of the cards which are still in the deck.
+// The templates of the deck should have been generated in the folder of path.
+func Refresh(path string, deck *plugins.Deck, backURL string, indent bool, options ...GenerateOption) error {
+	// Default options
+	opts := &generateOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	if deck.TemplateInfo != nil && len(deck.TemplateInfo.FileName) > 0 {
+		// The deck is saved to path instead
+		defer releaseName(NewFolderSink(filepath.Dir(path)), deck.TemplateInfo.FileName)
+	}
+
+	if len(backURL) > 0 {
+		deck.BackURL = backURL
+	}
+	if len(deck.Cards) == 0 {
+		return fmt.Errorf("deck %s is empty", deck.Name)
+	}
+
+	previous, err := ReadSavedObjectFile(path)
+	if err != nil {
+		return err
+	}
+	if len(previous.ObjectStates) != 1 {
+		return fmt.Errorf("%s contains %d objects, expected a single deck or card", path, len(previous.ObjectStates))
+	}
+
+	object, thumbnailSource := buildSavedObject(deck, opts)
+	if len(object.ObjectStates) != 1 {
+		return errors.New("the generated saved object doesn't contain a single object")
+	}
+
+	object.LuaScript = previous.LuaScript
+	object.LuaScriptState = previous.LuaScriptState
+	object.XMLUI = previous.XMLUI
+
+	keepObjectState(&object.ObjectStates[0], previous.ObjectStates[0])
+
+	log.Infof("Refreshing %s", path)
+
+	basePath := strings.TrimSuffix(path, filepath.Ext(path))
+	if opts.conflictPolicy == ConflictBackup {
+		if err := backupSavedObject(basePath); err != nil {
+			return err
+		}
+	}
+
+	return save(object, deck, thumbnailSource, NewFolderSink(filepath.Dir(basePath)), filepath.Base(basePath), indent, opts)
+}
+
+// keepObjectState copies the position, rotation, scripts and GUID of a
+// previous version of an object, as well as the GUIDs and scripts of the
+// objects it contains. Contained objects are matched on their names, in
+// order.
+func keepObjectState(object *Object, previous Object) {
+	keepObjectData(object, previous)
+	object.Transform.PosX = previous.Transform.PosX
+	object.Transform.PosY = previous.Transform.PosY
+	object.Transform.PosZ = previous.Transform.PosZ
+	object.Transform.RotX = previous.Transform.RotX
+	object.Transform.RotY = previous.Transform.RotY
+	object.Transform.RotZ = previous.Transform.RotZ
+	object.Locked = previous.Locked
+
+	previousByName := make(map[string][]Object)
+	for _, contained := range previous.ContainedObjects {
+		name := plugins.BaseCardName(contained.Nickname)
+		previousByName[name] = append(previousByName[name], contained)
+	}
+
+	for i := range object.ContainedObjects {
+		name := plugins.BaseCardName(object.ContainedObjects[i].Nickname)
+		candidates := previousByName[name]
+		if len(candidates) == 0 {
+			continue
+		}
+		keepObjectData(&object.ContainedObjects[i], candidates[0])
+		previousByName[name] = candidates[1:]
+	}
+}
+
+// keepObjectData copies the scripts and GUID of a previous version of an
+// object.
+func keepObjectData(object *Object, previous Object) {
+	if len(previous.GUID) > 0 {
+		object.GUID = previous.GUID
+	}
+	object.LuaScript = previous.LuaScript
+	object.LuaScriptState = previous.LuaScriptState
+	object.XMLUI = previous.XMLUI
+}
@@ -0,0 +1,88 @@
+package tts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+)
+
+func TestRefresh(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	createTestImage(t, filepath.Join(tmpDir, "card.png"), 10, 14)
+	server := httptest.NewServer(http.FileServer(http.Dir(tmpDir)))
+	defer server.Close()
+
+	imageURL := server.URL + "/card.png"
+	deck := &plugins.Deck{
+		Name: "Test",
+		Cards: []plugins.CardInfo{
+			{Name: "Card 1", ImageURL: imageURL + "?1", Count: 2},
+			{Name: "Card 2", ImageURL: imageURL + "?2", Count: 1},
+		},
+		BackURL: imageURL,
+	}
+	assert.Nil(t, create(deck, NewFolderSink(tmpDir), true, &generateOptions{}))
+
+	// Move the deck and add scripts to it, as if it was edited in TTS
+	path := filepath.Join(tmpDir, "Test.json")
+	previous, err := ReadSavedObjectFile(path)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	previous.ObjectStates[0].Transform.PosX = 3
+	previous.ObjectStates[0].Transform.RotY = 90
+	previous.ObjectStates[0].LuaScript = "print('deck')"
+	previous.ObjectStates[0].GUID = "abcdef"
+	previous.ObjectStates[0].ContainedObjects[2].LuaScript = "print('card')"
+	previous.ObjectStates[0].ContainedObjects[2].GUID = "123456"
+	data, err := json.Marshal(previous)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path, data, 0644))
+
+	refreshed := &plugins.Deck{
+		Name: "Test",
+		Cards: []plugins.CardInfo{
+			{Name: "Card 2", ImageURL: imageURL + "?4", Count: 1},
+			{Name: "Card 3", ImageURL: imageURL + "?5", Count: 1},
+		},
+	}
+	assert.Nil(t, Refresh(path, refreshed, imageURL+"?back", true))
+
+	object, err := ReadSavedObjectFile(path)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	deckObject := object.ObjectStates[0]
+	assert.Equal(t, 3.0, deckObject.Transform.PosX)
+	assert.Equal(t, 90.0, deckObject.Transform.RotY)
+	assert.Equal(t, "print('deck')", deckObject.LuaScript)
+	assert.Equal(t, "abcdef", deckObject.GUID)
+	if assert.Len(t, deckObject.ContainedObjects, 2) {
+		assert.Equal(t, "print('card')", deckObject.ContainedObjects[0].LuaScript)
+		assert.Equal(t, "123456", deckObject.ContainedObjects[0].GUID)
+		assert.Equal(t, imageURL+"?4", deckObject.ContainedObjects[0].CustomDeck["1"].FaceURL)
+		assert.Equal(t, imageURL+"?back", deckObject.ContainedObjects[0].CustomDeck["1"].BackURL)
+		assert.Regexp(t, guidRegex, deckObject.ContainedObjects[1].GUID)
+		assert.Empty(t, deckObject.ContainedObjects[1].LuaScript)
+	}
+
+	_, err = os.Stat(filepath.Join(tmpDir, "Test.png"))
+	assert.Nil(t, err)
+}
+
+func TestRefreshEmpty(t *testing.T) {
+	assert.NotNil(t, Refresh("missing.json", &plugins.Deck{Name: "Empty"}, "", false))
+}
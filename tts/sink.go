@@ -0,0 +1,330 @@
+package tts
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+)
+
+// Sink is the destination of the generated files: the saved objects, their
+// thumbnails and the templates.
+type Sink interface {
+	// WriteFile writes the file name, relative to the root of the sink,
+	// using write to produce its content. It returns where the file has been
+	// written, or an empty string if the sink doesn't keep this kind of
+	// file (write isn't called in that case).
+	WriteFile(name string, write func(w io.Writer) error) (location string, err error)
+	// Exists returns true if the file name has already been written.
+	Exists(name string) bool
+}
+
+// FolderSink writes the files in a folder, creating the subfolders as
+// needed.
+type FolderSink struct {
+	folder string
+}
+
+// NewFolderSink returns a sink writing the files in folder.
+func NewFolderSink(folder string) *FolderSink {
+	return &FolderSink{folder: folder}
+}
+
+func (s *FolderSink) path(name string) string {
+	path := filepath.Join(s.folder, name)
+	if absPath, err := filepath.Abs(path); err == nil {
+		return absPath
+	}
+
+	return path
+}
+
+// WriteFile writes the file name in the folder. The file is removed if
+// write fails.
+func (s *FolderSink) WriteFile(name string, write func(w io.Writer) error) (location string, err error) {
+	path := s.path(name)
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("couldn't create folder %s: %w", filepath.Dir(path), err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("couldn't write file %s: %w", path, err)
+	}
+
+	err = write(file)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", fmt.Errorf("couldn't write file %s: %w", path, err)
+	}
+
+	return path, nil
+}
+
+// Exists returns true if the file name exists in the folder.
+func (s *FolderSink) Exists(name string) bool {
+	return fileExists(s.path(name))
+}
+
+// WriterSink streams the saved objects to a writer (e.g. the standard
+// output), one JSON document after the other. The thumbnails and templates
+// are left out.
+// It is safe for concurrent use.
+type WriterSink struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+// NewWriterSink returns a sink streaming the saved objects to w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// WriteFile writes the saved objects to the writer, and ignores the other
+// files.
+func (s *WriterSink) WriteFile(name string, write func(w io.Writer) error) (location string, err error) {
+	if !strings.EqualFold(filepath.Ext(name), ".json") {
+		log.Debugf("Leaving %s out of the output stream", name)
+		return "", nil
+	}
+
+	// Generate the whole document first, so that the documents of
+	// concurrent conversions aren't mixed
+	var buf bytes.Buffer
+	if err = write(&buf); err != nil {
+		return "", err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err = s.w.Write(buf.Bytes()); err != nil {
+		return "", err
+	}
+	// Separate the documents
+	if _, err = s.w.Write([]byte("\n")); err != nil {
+		return "", err
+	}
+
+	return "-", nil
+}
+
+// Exists always returns false, since the documents are streamed.
+func (s *WriterSink) Exists(name string) bool {
+	return false
+}
+
+// ZipSink writes the files to a zip archive. Each file is added to the
+// archive once it has been generated, so a file cannot be overwritten once
+// written.
+// It is safe for concurrent use.
+type ZipSink struct {
+	mutex sync.Mutex
+	path  string
+	file  *os.File
+	zw    *zip.Writer
+	names map[string]struct{}
+}
+
+// NewZipSink returns a sink writing the files to the zip archive at path.
+// The archive is created when the first file is written, and Close should
+// be called to finish it.
+func NewZipSink(path string) *ZipSink {
+	return &ZipSink{
+		path:  path,
+		names: make(map[string]struct{}),
+	}
+}
+
+// open creates the archive, if it hasn't been created yet. The mutex
+// should be held.
+func (s *ZipSink) open() error {
+	if s.zw != nil {
+		return nil
+	}
+
+	file, err := os.Create(s.path)
+	if err != nil {
+		return fmt.Errorf("couldn't create archive %s: %w", s.path, err)
+	}
+
+	s.file = file
+	s.zw = zip.NewWriter(file)
+
+	return nil
+}
+
+// WriteFile adds the file name to the archive. The file is generated first,
+// so that nothing is added to the archive if write fails, and concurrent
+// calls only wait for the files to be added one after the other.
+func (s *ZipSink) WriteFile(name string, write func(w io.Writer) error) (location string, err error) {
+	// Zip files always use slashes
+	name = filepath.ToSlash(name)
+
+	if s.Exists(name) {
+		return "", fmt.Errorf("%s has already been added to archive %s", name, s.path)
+	}
+
+	var buf bytes.Buffer
+	if err = write(&buf); err != nil {
+		return "", fmt.Errorf("couldn't add %s to archive %s: %w", name, s.path, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// The file might have been added while it was generated
+	if _, found := s.names[name]; found {
+		return "", fmt.Errorf("%s has already been added to archive %s", name, s.path)
+	}
+
+	if err = s.open(); err != nil {
+		return "", err
+	}
+
+	w, err := s.zw.Create(name)
+	if err != nil {
+		return "", fmt.Errorf("couldn't add %s to archive %s: %w", name, s.path, err)
+	}
+	// The entry is in the archive even if it cannot be written completely
+	s.names[name] = struct{}{}
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		return "", fmt.Errorf("couldn't add %s to archive %s: %w", name, s.path, err)
+	}
+	if err = s.zw.Flush(); err != nil {
+		return "", fmt.Errorf("couldn't add %s to archive %s: %w", name, s.path, err)
+	}
+
+	return filepath.Join(s.path, filepath.FromSlash(name)), nil
+}
+
+// Exists returns true if the file name has already been added to the
+// archive.
+func (s *ZipSink) Exists(name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, found := s.names[filepath.ToSlash(name)]
+
+	return found
+}
+
+// Close finishes the archive. An empty archive is created if no file has
+// been written.
+func (s *ZipSink) Close() (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err = s.open(); err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := s.file.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("couldn't write archive %s: %w", s.path, cerr)
+		}
+	}()
+
+	if err = s.zw.Close(); err != nil {
+		return fmt.Errorf("couldn't write archive %s: %w", s.path, err)
+	}
+
+	log.Infof("Wrote %d files to %s", len(s.names), s.path)
+
+	return nil
+}
+
+// writeData returns a function writing data, to be used with
+// Sink.WriteFile.
+func writeData(data []byte) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}
+}
+
+// copyFile returns a function writing the content of the file at path, to
+// be used with Sink.WriteFile.
+func copyFile(path string) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+}
+
+// bundledImagesFolder is the folder of the sink where the card images are
+// written with WithBundledImages. The images are named like in the TTS mod
+// cache, so that they can be copied to the Mods/Images folder to load the
+// decks offline.
+const bundledImagesFolder = "Images"
+
+// bundleImages writes all the remote images of a saved object to the sink.
+// The images already in the mod cache are copied from there.
+func bundleImages(sink Sink, object SavedObject, cached map[string]string) {
+	links := make(map[string]*link)
+	for _, objectState := range object.ObjectStates {
+		collectLinks(objectState, "", links)
+	}
+
+	for location := range links {
+		source := parseImageSource(location)
+		if source.sourceType != sourceHTTP {
+			continue
+		}
+
+		data, err := readImage(source, cached[location])
+		if err != nil {
+			log.Warnf("Couldn't bundle %s: %v", location, err)
+			continue
+		}
+
+		ext, err := imageExtension(data)
+		if err != nil {
+			log.Warnf("Couldn't bundle %s: %v", location, err)
+			continue
+		}
+
+		name := filepath.Join(bundledImagesFolder, modCacheName(location)+ext)
+		if sink.Exists(name) {
+			continue
+		}
+		if _, err = sink.WriteFile(name, writeData(data)); err != nil {
+			log.Warnf("Couldn't bundle %s: %v", location, err)
+		}
+	}
+}
+
+// readImage returns the content of an image, read from cachedPath if it is
+// set, or downloaded otherwise.
+func readImage(source imageSource, cachedPath string) ([]byte, error) {
+	if len(cachedPath) > 0 {
+		return ioutil.ReadFile(cachedPath)
+	}
+
+	reader, err := source.open()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(reader)
+	// The image has been read, so the close error can be ignored
+	_ = reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't download %s: %w", source, err)
+	}
+
+	return data, nil
+}
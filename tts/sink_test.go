@@ -0,0 +1,241 @@
+package tts
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts/upload"
+)
+
+// newSinkTestDecks returns decks whose images are served by a test server.
+func newSinkTestDecks(t *testing.T, tmpDir string) []*plugins.Deck {
+	createTestImage(t, filepath.Join(tmpDir, "card.png"), 10, 14)
+	server := httptest.NewServer(http.FileServer(http.Dir(tmpDir)))
+	t.Cleanup(server.Close)
+
+	return []*plugins.Deck{
+		{
+			Name: "Test",
+			Cards: []plugins.CardInfo{
+				{Name: "Card 1", ImageURL: server.URL + "/card.png?1", Count: 2},
+				{Name: "Card 2", ImageURL: server.URL + "/card.png?2", Count: 1},
+			},
+			BackURL: server.URL + "/card.png",
+		},
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	decks := newSinkTestDecks(t, tmpDir)
+	decks = append(decks, &plugins.Deck{
+		Name:    "Test 2",
+		Cards:   decks[0].Cards,
+		BackURL: decks[0].BackURL,
+	})
+
+	var (
+		buf       bytes.Buffer
+		generated []GeneratedDeck
+	)
+	errs := Generate(decks, "", "", false, WithSink(NewWriterSink(&buf)), WithOnGenerated(func(deck GeneratedDeck) {
+		generated = append(generated, deck)
+	}))
+	assert.Empty(t, errs)
+
+	decoder := json.NewDecoder(&buf)
+	for i := 0; i < 2; i++ {
+		var object SavedObject
+		assert.Nil(t, decoder.Decode(&object))
+		assert.Len(t, object.ObjectStates, 1)
+	}
+	var object SavedObject
+	assert.Equal(t, io.EOF, decoder.Decode(&object))
+
+	// The thumbnails are left out
+	if assert.Len(t, generated, 2) {
+		assert.Equal(t, []string{"-"}, generated[0].Files)
+	}
+}
+
+func TestZipSink(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	decks := newSinkTestDecks(t, tmpDir)
+	zipPath := filepath.Join(tmpDir, "decks.zip")
+	sink := NewZipSink(zipPath)
+
+	// The game can't load the templates from the archive
+	errs := GenerateTemplates([][]*plugins.Deck{newSinkTestDecks(t, tmpDir)}, "", upload.ManualUploader{}, WithSink(sink))
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "template Test - Template.jpg cannot be saved where the game can load it from")
+	}
+	errs = Generate(decks, "", "", true, WithSink(sink), WithBundledImages())
+	assert.Empty(t, errs)
+	// The files of the deck cannot be overwritten
+	errs = Generate(decks, "", "", true, WithSink(sink))
+	assert.NotEmpty(t, errs)
+	assert.Nil(t, sink.Close())
+
+	archive, err := zip.OpenReader(zipPath)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer archive.Close()
+
+	names := make([]string, 0, len(archive.File))
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	sort.Strings(names)
+
+	// The card images and the back are bundled
+	if assert.Len(t, names, 6) {
+		for _, name := range names[:3] {
+			assert.Regexp(t, "^"+bundledImagesFolder+"/http.*cardpng.*\\.png$", name)
+		}
+		assert.Equal(t, []string{"Test - Template.jpg", "Test.json", "Test.png"}, names[3:])
+	}
+}
+
+// testUploader pretends to upload the templates.
+type testUploader struct{}
+
+func (testUploader) Upload(templatePath string, templateName string, _ *http.Client) (string, error) {
+	return "https://example.com/" + templateName + ".jpg", nil
+}
+
+func (testUploader) UploaderID() string {
+	return "test"
+}
+
+func (testUploader) UploaderName() string {
+	return "Test"
+}
+
+func (testUploader) UploaderDescription() string {
+	return "Test uploader"
+}
+
+func TestZipSinkUploadedTemplate(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	decks := newSinkTestDecks(t, tmpDir)
+	zipPath := filepath.Join(tmpDir, "decks.zip")
+	sink := NewZipSink(zipPath)
+
+	errs := GenerateTemplates([][]*plugins.Deck{decks}, "", testUploader{}, WithSink(sink))
+	assert.Empty(t, errs)
+	errs = Generate(decks, "", "", true, WithSink(sink))
+	assert.Empty(t, errs)
+	assert.Nil(t, sink.Close())
+
+	archive, err := zip.OpenReader(zipPath)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer archive.Close()
+
+	names := make([]string, 0, len(archive.File))
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	sort.Strings(names)
+
+	// The uploaded template is still part of the archive
+	assert.Equal(t, []string{"Test - Template.jpg", "Test.json", "Test.png"}, names)
+}
+
+func TestZipSinkStream(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	zipPath := filepath.Join(tmpDir, "decks.zip")
+	sink := NewZipSink(zipPath)
+
+	location, err := sink.WriteFile(filepath.Join("a", "Test.json"), writeData([]byte("{}")))
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(zipPath, "a", "Test.json"), location)
+	assert.True(t, sink.Exists(filepath.Join("a", "Test.json")))
+	// The file is written to the archive before it is closed
+	info, err := os.Stat(zipPath)
+	if assert.Nil(t, err) {
+		assert.NotZero(t, info.Size())
+	}
+
+	_, err = sink.WriteFile(filepath.Join("a", "Test.json"), writeData([]byte("{}")))
+	assert.EqualError(t, err, "a/Test.json has already been added to archive "+zipPath)
+
+	// Nothing is added if the file cannot be generated
+	_, err = sink.WriteFile("Test.png", func(w io.Writer) error {
+		if _, err := w.Write([]byte("partial")); err != nil {
+			return err
+		}
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "couldn't add Test.png to archive "+zipPath+": failed")
+	assert.False(t, sink.Exists("Test.png"))
+	assert.Nil(t, sink.Close())
+
+	archive, err := zip.OpenReader(zipPath)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer archive.Close()
+
+	if assert.Len(t, archive.File, 1) {
+		assert.Equal(t, "a/Test.json", archive.File[0].Name)
+	}
+}
+
+func TestFolderSinkBackup(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	sink := NewFolderSink(tmpDir)
+	location, err := sink.WriteFile(filepath.Join("a", "Test.json"), writeData([]byte("{}")))
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "a", "Test.json"), location)
+	assert.True(t, sink.Exists(filepath.Join("a", "Test.json")))
+
+	name, err := resolveConflict(sink, filepath.Join("a", "Test"), ConflictRename)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join("a", "Test (2)"), name)
+
+	name, err = resolveConflict(sink, filepath.Join("a", "Test"), ConflictBackup)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join("a", "Test"), name)
+	assert.False(t, sink.Exists(filepath.Join("a", "Test.json")))
+}
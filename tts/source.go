@@ -0,0 +1,182 @@
+package tts
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// sourceType is the type of location an image is read from.
+type sourceType int
+
+const (
+	// sourceFile is a local file, given as a path or a file:// URL.
+	sourceFile sourceType = iota
+	// sourceHTTP is a http:// or https:// URL.
+	sourceHTTP
+	// sourceData is a data: URI, containing the image itself.
+	sourceData
+)
+
+// maxSourceDescriptionLength is the maximum length of a data URI in log and
+// error messages.
+const maxSourceDescriptionLength = 48
+
+// imageSource is the location of an image (card face, card back, etc.).
+type imageSource struct {
+	// location of the image, as provided by the plugin or the user.
+	location string
+	// sourceType is the type of location.
+	sourceType sourceType
+	// path is the path of the image, for local files.
+	path string
+}
+
+// parseImageSource returns the source of the image found at location, which
+// can be a http(s):// or file:// URL, a data: URI or a local path.
+func parseImageSource(location string) imageSource {
+	source := imageSource{
+		location:   location,
+		sourceType: sourceFile,
+		path:       location,
+	}
+
+	if strings.HasPrefix(location, "data:") {
+		source.sourceType = sourceData
+		source.path = ""
+		return source
+	}
+
+	// Windows paths (e.g. C:\Users) are parsed as URLs with a one-letter
+	// scheme, hence the explicit list of schemes
+	u, err := url.Parse(location)
+	if err != nil {
+		return source
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		source.sourceType = sourceHTTP
+		source.path = ""
+	case "file":
+		path := u.Path
+		// file:///C:/Users/... on Windows
+		if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+			path = path[1:]
+		}
+		source.path = filepath.FromSlash(path)
+	}
+
+	return source
+}
+
+// String returns the location of the image, truncated for data URIs.
+func (s imageSource) String() string {
+	if s.sourceType == sourceData && len(s.location) > maxSourceDescriptionLength {
+		return s.location[:maxSourceDescriptionLength] + "..."
+	}
+
+	return s.location
+}
+
+// isLocal returns whether or not the image is a local file.
+func (s imageSource) isLocal() bool {
+	return s.sourceType == sourceFile
+}
+
+// cacheName returns a file name which can be used to store the image
+// locally.
+func (s imageSource) cacheName() string {
+	if s.sourceType == sourceData {
+		hash := sha1.Sum([]byte(s.location))
+		return "data-" + hex.EncodeToString(hash[:])
+	}
+
+	return filepathReplacer.Replace(s.location)
+}
+
+// open returns a reader for the content of the image.
+func (s imageSource) open() (io.ReadCloser, error) {
+	var (
+		reader io.ReadCloser
+		err    error
+	)
+
+	switch s.sourceType {
+	case sourceHTTP:
+		reader, err = openHTTP(s.location)
+	case sourceData:
+		var data []byte
+		data, err = decodeDataURI(s.location)
+		reader = ioutil.NopCloser(bytes.NewReader(data))
+	default:
+		reader, err = os.Open(s.path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open image %s: %w", s, err)
+	}
+
+	return reader, nil
+}
+
+func openHTTP(url string) (io.ReadCloser, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check server response
+	if resp.StatusCode != http.StatusOK {
+		// The body isn't used, so the close error can be ignored
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// decodeDataURI returns the data contained in a data URI.
+// See https://developer.mozilla.org/en-US/docs/Web/HTTP/Basics_of_HTTP/Data_URIs.
+func decodeDataURI(uri string) ([]byte, error) {
+	content := strings.TrimPrefix(uri, "data:")
+
+	commaIdx := strings.Index(content, ",")
+	if commaIdx < 0 {
+		return nil, errors.New("invalid data URI: missing comma")
+	}
+
+	mediaType := content[:commaIdx]
+	data := content[commaIdx+1:]
+
+	if strings.HasSuffix(strings.ToLower(mediaType), ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			// Some encoders omit the padding
+			var rawErr error
+			decoded, rawErr = base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+			if rawErr != nil {
+				return nil, fmt.Errorf("invalid base64 data: %w", err)
+			}
+		}
+		return decoded, nil
+	}
+
+	decoded, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data: %w", err)
+	}
+
+	return []byte(decoded), nil
+}
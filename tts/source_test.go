@@ -0,0 +1,130 @@
+package tts
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImageSource(t *testing.T) {
+	source := parseImageSource("https://example.com/card.png")
+	assert.Equal(t, sourceHTTP, source.sourceType)
+	assert.False(t, source.isLocal())
+
+	source = parseImageSource("data:image/png;base64,AAAA")
+	assert.Equal(t, sourceData, source.sourceType)
+	assert.False(t, source.isLocal())
+
+	source = parseImageSource("/home/user/card.png")
+	assert.Equal(t, sourceFile, source.sourceType)
+	assert.Equal(t, "/home/user/card.png", source.path)
+
+	source = parseImageSource(`C:\Users\User\card.png`)
+	assert.Equal(t, sourceFile, source.sourceType)
+	assert.Equal(t, `C:\Users\User\card.png`, source.path)
+
+	source = parseImageSource("file:///home/user/my%20card.png")
+	assert.Equal(t, sourceFile, source.sourceType)
+	assert.Equal(t, filepath.FromSlash("/home/user/my card.png"), source.path)
+}
+
+func TestImageSourceString(t *testing.T) {
+	location := "data:image/png;base64," + strings.Repeat("A", 100)
+	assert.Equal(t, location[:maxSourceDescriptionLength]+"...", parseImageSource(location).String())
+	assert.Equal(t, "/home/user/card.png", parseImageSource("/home/user/card.png").String())
+}
+
+func TestDecodeDataURI(t *testing.T) {
+	data, err := decodeDataURI("data:text/plain;base64,SGVsbG8=")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("Hello"), data)
+
+	data, err = decodeDataURI("data:text/plain;base64,SGVsbG8")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("Hello"), data)
+
+	data, err = decodeDataURI("data:,Hello%20World")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("Hello World"), data)
+
+	_, err = decodeDataURI("data:text/plain;base64")
+	assert.NotNil(t, err)
+
+	_, err = decodeDataURI("data:text/plain;base64,!!!")
+	assert.NotNil(t, err)
+}
+
+func TestLoadImage(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "card.png")
+	createTestImage(t, path, 10, 14)
+
+	server := httptest.NewServer(http.FileServer(http.Dir(tmpDir)))
+	defer server.Close()
+
+	var buffer bytes.Buffer
+	err = imaging.Encode(&buffer, imaging.New(10, 14, white), imaging.PNG)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	for _, location := range []string{
+		path,
+		"file://" + filepath.ToSlash(path),
+		server.URL + "/card.png",
+		"data:image/png;base64," + base64.StdEncoding.EncodeToString(buffer.Bytes()),
+	} {
+		img, err := loadImage(location)
+		if assert.Nil(t, err, location) {
+			assert.Equal(t, 10, img.Bounds().Dx(), location)
+			assert.Equal(t, 14, img.Bounds().Dy(), location)
+		}
+	}
+
+	_, err = loadImage(server.URL + "/missing.png")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "404")
+	}
+
+	_, err = loadImage(filepath.Join(tmpDir, "missing.png"))
+	assert.NotNil(t, err)
+}
+
+func TestDownloadImageIfRequired(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tts-deckconverter")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	filename, err := downloadImageIfRequired("/home/user/card.png", tmpDir, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "/home/user/card.png", filename)
+
+	location := "data:text/plain;base64,SGVsbG8="
+	filename, err = downloadImageIfRequired(location, tmpDir, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, tmpDir, filepath.Dir(filename))
+	data, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("Hello"), data)
+
+	// The downloaded file is reused
+	reused, err := downloadImageIfRequired(location, tmpDir, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, filename, reused)
+}
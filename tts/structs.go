@@ -93,11 +93,42 @@ type CustomDeckMap map[string]CustomDeck
 // order as if they were integer instead of strings (i.e. "2" will be serialized
 // after "1", instead of "10").
 func (cdm CustomDeckMap) MarshalJSON() ([]byte, error) {
-	length := len(cdm)
+	keys := make([]string, 0, len(cdm))
+	for key := range cdm {
+		keys = append(keys, key)
+	}
+
+	return marshalIntKeyedMap(keys, func(key string) (interface{}, bool) {
+		value, ok := cdm[key]
+		return value, ok
+	})
+}
+
+// StatesMap is a map of Object, whose keys are the state indexes.
+type StatesMap map[string]Object
+
+// MarshalJSON implements the json.Marshaler interface.
+// Like CustomDeckMap, the keys are serialized in integer order.
+func (sm StatesMap) MarshalJSON() ([]byte, error) {
+	keys := make([]string, 0, len(sm))
+	for key := range sm {
+		keys = append(keys, key)
+	}
+
+	return marshalIntKeyedMap(keys, func(key string) (interface{}, bool) {
+		value, ok := sm[key]
+		return value, ok
+	})
+}
+
+// marshalIntKeyedMap serializes a map whose keys are strings containing
+// integers, in the integer order of its keys.
+func marshalIntKeyedMap(strKeys []string, get func(string) (interface{}, bool)) ([]byte, error) {
+	length := len(strKeys)
 
 	// Convert the keys to integer and sort them in a slice
 	keys := make([]int, 0, length)
-	for key := range cdm {
+	for _, key := range strKeys {
 		intKey, err := strconv.Atoi(key)
 		if err != nil {
 			// The key cannot be converted to an integer
@@ -117,7 +148,7 @@ func (cdm CustomDeckMap) MarshalJSON() ([]byte, error) {
 	// Iterate through the ordered keys
 	for _, key := range keys {
 		strKey := strconv.Itoa(key)
-		value, ok := cdm[strKey]
+		value, ok := get(strKey)
 		if !ok {
 			return nil, errors.New("key " + strKey + " not found")
 		}
@@ -195,7 +226,7 @@ type Object struct {
 	ContainedObjects []Object `json:"ContainedObjects,omitempty"`
 	// States lists the differents states of the object.
 	// See https://berserk-games.com/knowledgebase/creating-states/.
-	States map[string]Object `json:"States,omitempty"`
+	States StatesMap `json:"States,omitempty"`
 	// GUID is the Globally Unique Identifier of the object.
 	GUID string `json:"GUID"`
 }
@@ -8,7 +8,6 @@ import (
 	"io/ioutil"
 	"math"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 
@@ -68,116 +67,221 @@ func getImageSize(filepath string) (width int, height int, err error) {
 	return image.Width, image.Height, nil
 }
 
-func downloadFile(url string, filepath string) (err error) {
+// downloadFile copies the image found at location to filepath.
+func downloadFile(source imageSource, filepath string) (err error) {
 	if _, err = os.Stat(filepath); err == nil {
 		err = errAlreadyExists
 		return
 	}
-	output, err := os.Create(filepath)
+
+	input, err := source.open()
 	if err != nil {
-		log.Errorf("Error while creating %s: %s", filepath, err)
+		log.Error(err)
 		return
 	}
 	defer func() {
-		if cerr := output.Close(); cerr != nil && err == nil {
+		if cerr := input.Close(); cerr != nil && err == nil {
 			err = cerr
 		}
 	}()
 
-	resp, err := http.Get(url)
+	output, err := os.Create(filepath)
 	if err != nil {
-		log.Errorf("Error while downloading %s: %s", url, err)
+		log.Errorf("Error while creating %s: %s", filepath, err)
 		return
 	}
 	defer func() {
-		if cerr := resp.Body.Close(); cerr != nil && err == nil {
+		if cerr := output.Close(); cerr != nil && err == nil {
 			err = cerr
 		}
 	}()
 
-	// Check server response
-	if resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("bad status: %s", resp.Status)
-		log.Error(err)
-		return
-	}
-
-	n, err := io.Copy(output, resp.Body)
+	n, err := io.Copy(output, input)
 	if err != nil {
-		log.Errorf("Error while downloading %s: %s", url, err)
+		log.Errorf("Error while downloading %s: %s", source, err)
 		return
 	}
 
-	log.Debugf("Downloaded file %s to %s (%d bytes)", url, filepath, n)
+	log.Debugf("Downloaded file %s to %s (%d bytes)", source, filepath, n)
 	return nil
 }
 
-func downloadImageIfRequired(imageURL string, tmpDir string) (string, error) {
-	var filename string
+// downloadImageIfRequired returns the path of a local copy of the image found
+// at location (see parseImageSource), downloading it to tmpDir if needed.
+// If cache is set, remote images are downloaded to the mod cache instead, so
+// that they aren't downloaded again when caching the images of the decks or
+// generating their thumbnails.
+func downloadImageIfRequired(location string, tmpDir string, cache *ModCache) (string, error) {
+	source := parseImageSource(location)
 
-	if u, err := url.Parse(imageURL); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
-		// If the card image is a URL, download it to the temporary folder
-		filename = filepath.Join(tmpDir, filepathReplacer.Replace(imageURL))
-		err = downloadFile(imageURL, filename)
-		if err != nil && err == errAlreadyExists {
-			log.Debugf("File %s already exists, reusing it (path: %s)", filename, imageURL)
-			return filename, nil
-		} else if err != nil {
-			return filename, err
-		}
-	} else {
+	if source.isLocal() {
 		// If the card image is a file, use it directly
-		filename = imageURL
+		return source.path, nil
+	}
+
+	if cache != nil {
+		path, err := cache.fetch(location)
+		if err == nil && len(path) > 0 {
+			return path, nil
+		}
+		if err != nil {
+			log.Warnf("Couldn't cache %s: %v", location, err)
+		}
+	}
+
+	// If the card image is remote, download it to the temporary folder
+	filename := filepath.Join(tmpDir, source.cacheName())
+	err := downloadFile(source, filename)
+	if err != nil && err == errAlreadyExists {
+		log.Debugf("File %s already exists, reusing it (path: %s)", filename, source)
+		return filename, nil
+	} else if err != nil {
+		return filename, err
 	}
 
 	return filename, nil
 }
 
-func generateTemplate(cards []plugins.CardInfo, tmpDir, outputPath string, count int) (urlIDMap map[string]int, numCols, numRows uint, err error) {
+func generateTemplate(
+	cards []plugins.CardInfo,
+	backURL string,
+	tmpDir string,
+	cache *ModCache,
+	outputPath string,
+	backOutputPath string,
+	count int,
+) (urlIDMap map[string]int, numCols, numRows uint, hasBacks bool, err error) {
 	idFilePathMap := make(map[int]string)
+	// Map of card IDs to card back URLs, for the cards that have their own back
+	idBackURLMap := make(map[int]string)
+	// Set of the IDs of the cards displayed sideways (landscape cards)
+	sidewaysIDs := make(map[int]struct{})
 	urlIDMap = make(map[string]int)
 
 	id := startingID * count
 	for _, card := range cards {
-		var filename string
-
-		filename, err = downloadImageIfRequired(card.ImageURL, tmpDir)
-		if err != nil {
-			return
+		faces := []plugins.CardInfo{card}
+		if card.AlternativeState != nil {
+			faces = append(faces, *card.AlternativeState)
 		}
 
-		idFilePathMap[id] = filename
-		urlIDMap[card.ImageURL] = id
-
-		id++
+		for _, face := range faces {
+			var filename string
 
-		if card.AlternativeState != nil {
-			filename, err = downloadImageIfRequired(card.AlternativeState.ImageURL, tmpDir)
+			filename, err = downloadImageIfRequired(face.ImageURL, tmpDir, cache)
 			if err != nil {
 				return
 			}
 
 			idFilePathMap[id] = filename
-			urlIDMap[card.AlternativeState.ImageURL] = id
+			urlIDMap[plugins.TemplateImageKey(face.ImageURL, face.BackImageURL)] = id
+			if face.Sideways {
+				sidewaysIDs[id] = struct{}{}
+			}
+			if len(face.BackImageURL) > 0 {
+				idBackURLMap[id] = face.BackImageURL
+			}
 
 			id++
 		}
 	}
 
+	imageCount := len(idFilePathMap)
+
+	numCols, numRows, err = findTemplateSize(uint(imageCount))
+	if err != nil {
+		log.Errorw(
+			err.Error(),
+			"imageCount", imageCount,
+			"card length", len(cards),
+			"idFilePathMap", idFilePathMap,
+		)
+		return
+	}
+
+	err = saveTemplate(idFilePathMap, sidewaysIDs, startingID*count, numCols, numRows, outputPath)
+	if err != nil || len(idBackURLMap) == 0 {
+		return
+	}
+
+	// At least one card has its own back, so generate a back sheet with
+	// the same layout as the template, using the deck back for the other
+	// cards
+	hasBacks = true
+	idBackFilePathMap := make(map[int]string, imageCount)
+
+	for imageID := range idFilePathMap {
+		cardBackURL, found := idBackURLMap[imageID]
+		if !found {
+			cardBackURL = backURL
+		}
+		if len(cardBackURL) == 0 {
+			err = fmt.Errorf("no card back set for image ID %d", imageID)
+			return
+		}
+
+		var filename string
+
+		filename, err = downloadImageIfRequired(cardBackURL, tmpDir, cache)
+		if err != nil {
+			return
+		}
+
+		idBackFilePathMap[imageID] = filename
+	}
+
+	err = saveTemplate(idBackFilePathMap, sidewaysIDs, startingID*count, numCols, numRows, backOutputPath)
+
+	return
+}
+
+func saveTemplate(
+	idFilePathMap map[int]string,
+	sidewaysIDs map[int]struct{},
+	startID int,
+	numCols uint,
+	numRows uint,
+	outputPath string,
+) (err error) {
 	var (
 		width     int
 		height    int
 		maxWidth  int
 		maxHeight int
 		ratio     float64
+		portrait  bool
 	)
 
-	for _, filepath := range idFilePathMap {
+	imageSizes := make(map[int]image.Point, len(idFilePathMap))
+
+	for id, filepath := range idFilePathMap {
 		width, height, err = getImageSize(filepath)
 		if err != nil {
 			return
 		}
 
+		imageSizes[id] = image.Pt(width, height)
+
+		if height >= width {
+			portrait = true
+		}
+	}
+
+	// All the cells of a template need to have the same size, so if the
+	// template contains portrait cards, landscape images of sideways cards
+	// are rotated to fit in the cells. TTS will rotate them back when
+	// displaying them.
+	rotatedIDs := make(map[int]struct{})
+
+	for id, size := range imageSizes {
+		width = size.X
+		height = size.Y
+
+		if _, sideways := sidewaysIDs[id]; sideways && portrait && width > height {
+			rotatedIDs[id] = struct{}{}
+			width, height = height, width
+		}
+
 		if maxWidth == 0 && maxHeight == 0 {
 			maxWidth = width
 			maxHeight = height
@@ -204,16 +308,6 @@ func generateTemplate(cards []plugins.CardInfo, tmpDir, outputPath string, count
 		"count", imageCount,
 	)
 
-	numCols, numRows, err = findTemplateSize(uint(imageCount))
-	if err != nil {
-		log.Errorw(
-			err.Error(),
-			"imageCount", imageCount,
-			"card length", len(cards),
-			"idFilePathMap", idFilePathMap,
-		)
-		return
-	}
 	templateWidth := int(numCols) * maxWidth
 	templateHeight := int(numRows) * maxHeight
 	log.Infof(
@@ -232,9 +326,9 @@ func generateTemplate(cards []plugins.CardInfo, tmpDir, outputPath string, count
 	)
 
 	for i := 0; i < imageCount; i++ {
-		filepath, found := idFilePathMap[startingID*count+i]
+		filepath, found := idFilePathMap[startID+i]
 		if !found {
-			err = fmt.Errorf("image for ID %d not found", startingID+i)
+			err = fmt.Errorf("image for ID %d not found", startID+i)
 			return
 		}
 		var source *os.File
@@ -254,6 +348,10 @@ func generateTemplate(cards []plugins.CardInfo, tmpDir, outputPath string, count
 			return
 		}
 
+		if _, rotate := rotatedIDs[startID+i]; rotate {
+			cardImage = imaging.Rotate90(cardImage)
+		}
+
 		if cardImage.Bounds().Max.X != maxWidth || cardImage.Bounds().Max.Y != maxHeight {
 			// Resize the image so it fits the template
 			cardImage = imaging.Resize(cardImage, maxWidth, maxHeight, imaging.Lanczos)
@@ -279,19 +377,117 @@ func generateTemplate(cards []plugins.CardInfo, tmpDir, outputPath string, count
 	return
 }
 
-func generateTemplatesForRelatedDecks(decks []*plugins.Deck, tmpDir, outputFolder string, uploader upload.TemplateUploader) []error {
+// UploadError is the error returned when a template couldn't be uploaded.
+type UploadError struct {
+	// Path of the template, which can be uploaded manually.
+	Path string
+	Err  error
+}
+
+func (e *UploadError) Error() string {
+	return fmt.Sprintf(
+		"couldn't upload %s: %v\n"+
+			"Try to upload %s manually, and update the URL in the deck file(s) manually",
+		e.Path,
+		e.Err,
+		e.Path,
+	)
+}
+
+// Unwrap returns the error of the uploader.
+func (e *UploadError) Unwrap() error {
+	return e.Err
+}
+
+// uploadTemplate uploads the template found at path, and returns its URL.
+// The templates of the manual uploader are written to the sink, and
+// referred to by their location there. The other templates are written to
+// the sink if their upload fails, so that they can be uploaded manually, or
+// if the sink is an archive. They are also bundled with the images if
+// requested.
+func uploadTemplate(
+	uploader upload.TemplateUploader,
+	path string,
+	templateName string,
+	sink Sink,
+	options *generateOptions,
+) (string, []error) {
+	errs := []error{}
+	name := templateName + ".jpg"
+
+	if uploader.UploaderID() == "manual" {
+		location, err := sink.WriteFile(name, copyFile(path))
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("couldn't save template %s: %w", name, err))
+		case len(location) == 0 || !fileExists(location):
+			// e.g. the template has been left out of a stream, or written
+			// to an archive, where the game can't load it from
+			errs = append(errs, fmt.Errorf("template %s cannot be saved where the game can load it from", name))
+			location = ""
+		}
+		return location, errs
+	}
+
+	url, err := uploader.Upload(path, templateName, http.DefaultClient)
+	if err != nil {
+		location, sinkErr := sink.WriteFile(name, copyFile(path))
+		if sinkErr != nil || len(location) == 0 {
+			location = name
+		}
+		errs = append(errs, &UploadError{Path: location, Err: err})
+		return url, errs
+	}
+
+	if options.modCache != nil {
+		if err = options.modCache.addFile(url, path); err != nil {
+			errs = append(errs, fmt.Errorf("couldn't cache %s: %w", path, err))
+		}
+	}
+	if _, ok := sink.(*ZipSink); ok {
+		// The archives contain all the files of the decks
+		if _, err = sink.WriteFile(name, copyFile(path)); err != nil {
+			errs = append(errs, fmt.Errorf("couldn't save template %s: %w", name, err))
+		}
+	}
+	if options.bundleImages {
+		bundledName := filepath.Join(bundledImagesFolder, modCacheName(url)+".jpg")
+		if _, err = sink.WriteFile(bundledName, copyFile(path)); err != nil {
+			errs = append(errs, fmt.Errorf("couldn't bundle %s: %w", name, err))
+		}
+	}
+
+	return url, errs
+}
+
+func generateTemplatesForRelatedDecks(
+	decks []*plugins.Deck,
+	tmpDir string,
+	sink Sink,
+	uploader upload.TemplateUploader,
+	options *generateOptions,
+) []error {
 	var (
-		urlIDMap   map[string]int
-		outputPath string
-		numCols    uint
-		numRows    uint
-		err        error
+		urlIDMap       map[string]int
+		outputPath     string
+		backOutputPath string
+		numCols        uint
+		numRows        uint
+		hasBacks       bool
+		err            error
 	)
 
 	errs := []error{}
 	uniqueCards := make(map[string]struct{})
 
-	for _, deck := range decks {
+	// The templates are named after the files of their decks
+	names := resolveTemplateNames(decks, sink, options)
+	defer keepTemplateNames(decks, names, sink)
+
+	for i, deck := range decks {
+		if len(names[i]) == 0 {
+			continue
+		}
 		for _, card := range deck.Cards {
 			uniqueCards[card.ImageURL] = struct{}{}
 			if card.AlternativeState != nil {
@@ -304,7 +500,12 @@ func generateTemplatesForRelatedDecks(decks []*plugins.Deck, tmpDir, outputFolde
 
 	if totalCount > int(maxTemplateCount) {
 		totalTemplateCount := 1
-		for _, deck := range decks {
+		for i, deck := range decks {
+			if len(names[i]) == 0 {
+				// The deck is skipped
+				continue
+			}
+
 			log.Debugw(
 				"Parsing cards to generate template(s)",
 				"card count", len(deck.Cards),
@@ -358,15 +559,12 @@ func generateTemplatesForRelatedDecks(decks []*plugins.Deck, tmpDir, outputFolde
 				if templateCount > 0 {
 					suffix = fmt.Sprintf(" %d", templateCount+1)
 				}
-				templateName := filepathReplacer.Replace(deck.Name) + " - Template" + suffix
+				templateName := names[i] + " - Template" + suffix
+				backTemplateName := templateName + " - Back"
 
-				if uploader.UploaderID() != "manual" {
-					outputPath = filepath.Join(os.TempDir(), templateName+".jpg")
-				} else if outputFolder == "" {
-					outputPath = templateName + ".jpg"
-				} else {
-					outputPath = filepath.Join(outputFolder, templateName+".jpg")
-				}
+				// The file name can contain folders
+				outputPath = filepath.Join(tmpDir, filepath.Base(templateName)+".jpg")
+				backOutputPath = filepath.Join(tmpDir, filepath.Base(backTemplateName)+".jpg")
 
 				start := templateStarts[templateCount]
 				end := templateEnds[templateCount]
@@ -377,10 +575,13 @@ func generateTemplatesForRelatedDecks(decks []*plugins.Deck, tmpDir, outputFolde
 					"card count", len(deck.Cards),
 				)
 
-				urlIDMap, numCols, numRows, err = generateTemplate(
+				urlIDMap, numCols, numRows, hasBacks, err = generateTemplate(
 					deck.Cards[start:end],
+					deck.BackURL,
 					tmpDir,
+					options.modCache,
 					outputPath,
+					backOutputPath,
 					totalTemplateCount,
 				)
 				if err != nil {
@@ -389,31 +590,18 @@ func generateTemplatesForRelatedDecks(decks []*plugins.Deck, tmpDir, outputFolde
 					continue
 				}
 
-				var url string
-
-				url, err = uploader.Upload(outputPath, templateName, http.DefaultClient)
-				if err != nil {
-					err = fmt.Errorf(
-						"couldn't upload %s: %v\n"+
-							"Try to upload %s manually, and update the URL in the deck file(s) manually",
-						outputPath,
-						err,
-						outputPath,
-					)
-					errs = append(errs, err)
-				} else if uploader.UploaderID() != "manual" {
-					log.Debugf("Deleting template file %s", outputPath)
-					err = os.Remove(outputPath)
-					if err != nil {
-						errs = append(errs, fmt.Errorf("Couldn't remove %s: %v", outputPath, err))
-					}
-				}
+				url, uploadErrs := uploadTemplate(uploader, outputPath, templateName, sink, options)
+				errs = append(errs, uploadErrs...)
 
 				template := &plugins.Template{
 					URL:     url,
 					NumCols: int(numCols),
 					NumRows: int(numRows),
 				}
+				if hasBacks {
+					template.BackURL, uploadErrs = uploadTemplate(uploader, backOutputPath, backTemplateName, sink, options)
+					errs = append(errs, uploadErrs...)
+				}
 				if deck.TemplateInfo == nil {
 					deck.TemplateInfo = &plugins.TemplateInfo{
 						ImageURLCardIDMap: urlIDMap,
@@ -422,8 +610,8 @@ func generateTemplatesForRelatedDecks(decks []*plugins.Deck, tmpDir, outputFolde
 						},
 					}
 				} else {
-					for cardURL, cardID := range urlIDMap {
-						deck.TemplateInfo.ImageURLCardIDMap[cardURL] = cardID
+					for imageKey, cardID := range urlIDMap {
+						deck.TemplateInfo.ImageURLCardIDMap[imageKey] = cardID
 					}
 					deck.TemplateInfo.Templates[totalTemplateCount] = template
 				}
@@ -436,47 +624,42 @@ func generateTemplatesForRelatedDecks(decks []*plugins.Deck, tmpDir, outputFolde
 
 	cards := []plugins.CardInfo{}
 	templateName := ""
+	backURL := ""
 
-	for _, deck := range decks {
+	for i, deck := range decks {
+		if len(names[i]) == 0 {
+			// The deck is skipped
+			continue
+		}
 		if len(templateName) == 0 {
-			templateName = filepathReplacer.Replace(deck.Name) + " - Template"
+			templateName = names[i] + " - Template"
+		}
+		if len(backURL) == 0 {
+			backURL = deck.BackURL
 		}
 		cards = append(cards, deck.Cards...)
 	}
 
-	if uploader.UploaderID() != "manual" {
-		outputPath = filepath.Join(os.TempDir(), templateName+".jpg")
-	} else if outputFolder == "" {
-		outputPath = templateName + ".jpg"
-	} else {
-		outputPath = filepath.Join(outputFolder, templateName+".jpg")
+	if len(templateName) == 0 {
+		// All the decks are skipped
+		return errs
 	}
 
+	backTemplateName := templateName + " - Back"
+	// The file name can contain folders
+	outputPath = filepath.Join(tmpDir, filepath.Base(templateName)+".jpg")
+	backOutputPath = filepath.Join(tmpDir, filepath.Base(backTemplateName)+".jpg")
+
 	log.Debug("Generating new template")
 
-	urlIDMap, numCols, numRows, err = generateTemplate(cards, tmpDir, outputPath, 1)
+	urlIDMap, numCols, numRows, hasBacks, err = generateTemplate(cards, backURL, tmpDir, options.modCache, outputPath, backOutputPath, 1)
 	if err != nil {
 		errs = append(errs, fmt.Errorf("couldn't save template to %s: %w", outputPath, err))
 		return errs
 	}
 
-	url, err := uploader.Upload(outputPath, templateName, http.DefaultClient)
-	if err != nil {
-		err = fmt.Errorf(
-			"couldn't upload %s: %v\n"+
-				"Try to upload %s manually, and update the URL in the deck file(s) manually",
-			outputPath,
-			err,
-			outputPath,
-		)
-		errs = append(errs, err)
-	} else if uploader.UploaderID() != "manual" {
-		log.Debugf("Deleting template file %s", outputPath)
-		err = os.Remove(outputPath)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("Couldn't remove %s: %v", outputPath, err))
-		}
-	}
+	url, uploadErrs := uploadTemplate(uploader, outputPath, templateName, sink, options)
+	errs = append(errs, uploadErrs...)
 
 	template := &plugins.Template{
 		URL:     url,
@@ -484,7 +667,15 @@ func generateTemplatesForRelatedDecks(decks []*plugins.Deck, tmpDir, outputFolde
 		NumRows: int(numRows),
 	}
 
-	for _, deck := range decks {
+	if hasBacks {
+		template.BackURL, uploadErrs = uploadTemplate(uploader, backOutputPath, backTemplateName, sink, options)
+		errs = append(errs, uploadErrs...)
+	}
+
+	for i, deck := range decks {
+		if len(names[i]) == 0 {
+			continue
+		}
 		deck.TemplateInfo = &plugins.TemplateInfo{
 			ImageURLCardIDMap: urlIDMap,
 			Templates: map[int]*plugins.Template{
@@ -501,7 +692,23 @@ func generateTemplatesForRelatedDecks(decks []*plugins.Deck, tmpDir, outputFolde
 // All the images required to display a deck are ordered in several rows and
 // columns, to be later displayed by TTS when loading the deck.
 // See https://berserk-games.com/knowledgebase/custom-decks/.
-func GenerateTemplates(decks [][]*plugins.Deck, outputFolder string, uploader upload.TemplateUploader) (errs []error) {
+// The templates are named after the files of their decks, which are chosen
+// according to WithFilenameTemplate and WithConflictPolicy, and saved in the
+// decks so that Generate writes them under the same names.
+// Only WithModCache, WithSink, WithBundledImages, WithFilenameTemplate and
+// WithConflictPolicy are used among the options.
+func GenerateTemplates(
+	decks [][]*plugins.Deck,
+	outputFolder string,
+	uploader upload.TemplateUploader,
+	options ...GenerateOption,
+) (errs []error) {
+	// Default options
+	opts := &generateOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
 	tmpDir, err := ioutil.TempDir("", "template")
 	if err != nil {
 		errs = append(errs, err)
@@ -515,8 +722,13 @@ func GenerateTemplates(decks [][]*plugins.Deck, outputFolder string, uploader up
 		}
 	}()
 
+	sink := opts.sink
+	if sink == nil {
+		sink = NewFolderSink(outputFolder)
+	}
+
 	for _, relatedDecks := range decks {
-		generateErrs := generateTemplatesForRelatedDecks(relatedDecks, tmpDir, outputFolder, uploader)
+		generateErrs := generateTemplatesForRelatedDecks(relatedDecks, tmpDir, sink, uploader, opts)
 		errs = append(errs, generateErrs...)
 	}
 
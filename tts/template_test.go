@@ -1,9 +1,15 @@
 package tts
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/jeandeaual/tts-deckconverter/plugins"
+	"github.com/jeandeaual/tts-deckconverter/tts/upload"
 )
 
 func TestFindTemplateSize(t *testing.T) {
@@ -46,3 +52,176 @@ func TestFindTemplateSize(t *testing.T) {
 	assert.Equal(t, uint(7), row)
 	assert.Nil(t, err)
 }
+
+func TestGenerateTemplateWithBacks(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "template_test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+
+	front1 := filepath.Join(tmpDir, "front1.png")
+	front2 := filepath.Join(tmpDir, "front2.png")
+	back1 := filepath.Join(tmpDir, "back1.png")
+	deckBack := filepath.Join(tmpDir, "deck_back.png")
+	createTestImage(t, front1, 10, 14)
+	createTestImage(t, front2, 10, 14)
+	createTestImage(t, back1, 5, 7)
+	createTestImage(t, deckBack, 5, 7)
+
+	outputPath := filepath.Join(tmpDir, "template.jpg")
+	backOutputPath := filepath.Join(tmpDir, "template_back.jpg")
+
+	urlIDMap, numCols, numRows, hasBacks, err := generateTemplate(
+		[]plugins.CardInfo{
+			{ImageURL: front1, BackImageURL: back1, Count: 1},
+			{ImageURL: front2, Count: 2},
+		},
+		deckBack,
+		tmpDir,
+		nil,
+		outputPath,
+		backOutputPath,
+		1,
+	)
+	assert.Nil(t, err)
+	assert.True(t, hasBacks)
+	assert.Equal(t, uint(2), numCols)
+	assert.Equal(t, uint(1), numRows)
+	assert.Equal(t, map[string]int{plugins.TemplateImageKey(front1, back1): 100, front2: 101}, urlIDMap)
+
+	width, height, err := getImageSize(outputPath)
+	assert.Nil(t, err)
+	assert.Equal(t, 20, width)
+	assert.Equal(t, 14, height)
+
+	width, height, err = getImageSize(backOutputPath)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, width)
+	assert.Equal(t, 7, height)
+}
+
+func TestGenerateTemplateSideways(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "template_test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+
+	portrait := filepath.Join(tmpDir, "portrait.png")
+	landscape := filepath.Join(tmpDir, "landscape.png")
+	createTestImage(t, portrait, 10, 14)
+	createTestImage(t, landscape, 14, 10)
+
+	outputPath := filepath.Join(tmpDir, "template.jpg")
+
+	_, _, _, _, err = generateTemplate(
+		[]plugins.CardInfo{
+			{ImageURL: portrait, Count: 1},
+			{ImageURL: landscape, Count: 1},
+		},
+		"",
+		tmpDir,
+		nil,
+		outputPath,
+		"",
+		1,
+	)
+	assert.NotNil(t, err)
+
+	_, numCols, numRows, hasBacks, err := generateTemplate(
+		[]plugins.CardInfo{
+			{ImageURL: portrait, Count: 1},
+			{ImageURL: landscape, Count: 1, Sideways: true},
+		},
+		"",
+		tmpDir,
+		nil,
+		outputPath,
+		"",
+		1,
+	)
+	assert.Nil(t, err)
+	assert.False(t, hasBacks)
+
+	width, height, err := getImageSize(outputPath)
+	assert.Nil(t, err)
+	assert.Equal(t, int(numCols)*10, width)
+	assert.Equal(t, int(numRows)*14, height)
+}
+
+func TestGenerateTemplatesSameFaceWithBacks(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "template_test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+
+	front := filepath.Join(tmpDir, "front.png")
+	back1 := filepath.Join(tmpDir, "back1.png")
+	back2 := filepath.Join(tmpDir, "back2.png")
+	createTestImage(t, front, 10, 14)
+	createTestImage(t, back1, 10, 14)
+	createTestImage(t, back2, 10, 14)
+
+	deck := &plugins.Deck{
+		Name: "Test",
+		Cards: []plugins.CardInfo{
+			{Name: "Card 1", ImageURL: front, BackImageURL: back1, Count: 1},
+			{Name: "Card 2", ImageURL: front, BackImageURL: back2, Count: 2},
+		},
+		BackURL: back1,
+	}
+
+	errs := GenerateTemplates([][]*plugins.Deck{{deck}}, tmpDir, upload.ManualUploader{})
+	assert.Empty(t, errs)
+
+	// Each back gets its own card in the template
+	assert.Equal(t, map[string]int{
+		plugins.TemplateImageKey(front, back1): 100,
+		plugins.TemplateImageKey(front, back2): 101,
+	}, deck.TemplateInfo.ImageURLCardIDMap)
+
+	object, _ := createDeck(deck)
+	assert.Equal(t, []int{100, 101, 101}, object.ObjectStates[0].DeckIDs)
+	cardIDs := make([]int, 0, len(object.ObjectStates[0].ContainedObjects))
+	for _, card := range object.ObjectStates[0].ContainedObjects {
+		cardIDs = append(cardIDs, card.CardID)
+	}
+	assert.Equal(t, []int{100, 101, 101}, cardIDs)
+}
+
+func TestGenerateTemplatesConflictRename(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "template_test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+
+	image := filepath.Join(tmpDir, "image.png")
+	createTestImage(t, image, 10, 14)
+
+	deck := func() *plugins.Deck {
+		return &plugins.Deck{
+			Name:    "Test",
+			Cards:   []plugins.CardInfo{{Name: "Card", ImageURL: image, Count: 1}},
+			BackURL: image,
+		}
+	}
+	decks := []*plugins.Deck{deck(), deck()}
+	options := []GenerateOption{WithConflictPolicy(ConflictRename)}
+
+	errs := GenerateTemplates([][]*plugins.Deck{{decks[0]}, {decks[1]}}, tmpDir, upload.ManualUploader{}, options...)
+	assert.Empty(t, errs)
+	errs = Generate(decks, "", tmpDir, false, options...)
+	assert.Empty(t, errs)
+
+	// Each deck is saved next to its own template
+	for _, name := range []string{"Test", "Test (2)"} {
+		object, err := ReadSavedObjectFile(filepath.Join(tmpDir, name+".json"))
+		if !assert.Nil(t, err) {
+			continue
+		}
+		assert.Equal(t, filepath.Join(tmpDir, name+" - Template.jpg"), object.ObjectStates[0].CustomDeck["1"].FaceURL)
+	}
+}
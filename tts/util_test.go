@@ -0,0 +1,24 @@
+package tts
+
+import (
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/jeandeaual/tts-deckconverter/log"
+)
+
+func init() {
+	logger := zap.NewExample()
+	log.SetLogger(logger.Sugar())
+}
+
+// Create a blank image of the given size at path
+func createTestImage(t *testing.T, path string, width, height int) {
+	err := imaging.Save(imaging.New(width, height, white), path)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+}